@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	activeFlightSaveInterval = 15 * time.Second // how often the last sample of the active flight is saved
+	resumeMaxGap             = 2 * time.Hour    // longest interruption a flight can be resumed after
+	resumeDistanceSlack      = 5.0              // NM on top of the distance the aircraft could have flown
+	resumeFuelSlack          = 100.0            // lbs on top of the fuel it could have burned
+)
+
+// savedFlight is the active flight as persisted to the database, enough to
+// pick it up after the app crashed or was restarted.
+type savedFlight struct {
+	FlightID  string           `json:"flightId"`
+	Callsign  string           `json:"callsign"`
+	Departure string           `json:"departure"`
+	Arrival   string           `json:"arrival"`
+	StartTime time.Time        `json:"startTime"`
+	Sequence  int64            `json:"sequence"` // of the last position report
+	OOOI      OOOITimes        `json:"oooi"`
+	Landing   *LandingReport   `json:"landing"`
+	Events    []IntegrityEvent `json:"events"`
+	Last      *lastSample      `json:"last"` // nil until the first sample
+}
+
+// lastSample is the state of the aircraft the continuity checks of a resume
+// compare against.
+type lastSample struct {
+	Time      time.Time `json:"time"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Altitude  float64   `json:"altitude"`
+	GS        float64   `json:"gs"`
+	Fuel      float64   `json:"fuel"`     // lbs
+	FuelFlow  float64   `json:"fuelFlow"` // lbs/h of all engines
+	OnGround  bool      `json:"onGround"`
+}
+
+func newLastSample(fd *FlightData, at time.Time) *lastSample {
+	var fuelFlow float64
+	for _, e := range fd.Engines {
+		fuelFlow += e.FuelFlow
+	}
+	return &lastSample{
+		Time:      at.UTC(),
+		Latitude:  fd.Position.Latitude,
+		Longitude: fd.Position.Longitude,
+		Altitude:  fd.Position.Altitude,
+		GS:        fd.Attitude.GS,
+		Fuel:      fd.Weight.FuelWeight,
+		FuelFlow:  fuelFlow,
+		OnGround:  fd.Sensors.OnGround,
+	}
+}
+
+// ResumableFlight describes a flight a previous run left active, for the UI.
+type ResumableFlight struct {
+	Callsign  string    `json:"callsign"`
+	Departure string    `json:"departure"`
+	Arrival   string    `json:"arrival"`
+	StartTime time.Time `json:"startTime"`
+	LastSeen  time.Time `json:"lastSeen"` // zero when no sample was saved
+}
+
+// ResumeCheck is the outcome of the continuity checks of a resume.
+type ResumeCheck struct {
+	Gap        float64  `json:"gap"`        // s since the last saved sample
+	Distance   float64  `json:"distance"`   // NM from the last saved position
+	FuelChange float64  `json:"fuelChange"` // lbs, negative when burned
+	Problems   []string `json:"problems"`   // failed checks; empty when the flight can be resumed
+}
+
+// activeFlightStore keeps the active flight in the active_flight table, a
+// single row that exists while a flight is active.
+type activeFlightStore struct {
+	db *sql.DB
+}
+
+func newActiveFlightStore(db *sql.DB) *activeFlightStore {
+	if db == nil {
+		return nil
+	}
+	return &activeFlightStore{db: db}
+}
+
+func (s *activeFlightStore) save(flight *savedFlight) error {
+	data, err := json.Marshal(flight)
+	if err != nil {
+		return fmt.Errorf("marshal active flight: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO active_flight (id, data) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = CURRENT_TIMESTAMP`, string(data))
+	if err != nil {
+		return fmt.Errorf("save active flight: %w", err)
+	}
+	return nil
+}
+
+// load returns the saved flight, or nil when there is none.
+func (s *activeFlightStore) load() (*savedFlight, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM active_flight WHERE id = 1`).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load active flight: %w", err)
+	}
+	var flight savedFlight
+	if err := json.Unmarshal([]byte(data), &flight); err != nil {
+		return nil, fmt.Errorf("parse active flight: %w", err)
+	}
+	return &flight, nil
+}
+
+func (s *activeFlightStore) clear() error {
+	if _, err := s.db.Exec(`DELETE FROM active_flight`); err != nil {
+		return fmt.Errorf("clear active flight: %w", err)
+	}
+	return nil
+}
+
+// checkContinuity compares the aircraft now with the last saved sample of a
+// flight. The simulator may have kept flying while the app was closed, so the
+// aircraft may have covered the distance and burned the fuel of the gap at
+// its last ground speed and fuel flow, but not more; nor may it have gained
+// fuel in the air. A flight saved before its first sample cannot be checked,
+// so it cannot be resumed.
+func checkContinuity(last *lastSample, fd *FlightData, now time.Time) ResumeCheck {
+	check := ResumeCheck{Problems: []string{}}
+	if last == nil {
+		check.Problems = append(check.Problems, "no position was saved before the interruption")
+		return check
+	}
+	gap := now.Sub(last.Time)
+	check.Gap = gap.Seconds()
+	check.Distance = greatCircleNM(last.Latitude, last.Longitude, fd.Position.Latitude, fd.Position.Longitude)
+	check.FuelChange = fd.Weight.FuelWeight - last.Fuel
+
+	if gap > resumeMaxGap {
+		check.Problems = append(check.Problems, fmt.Sprintf("interrupted for %s, longer than %s", gap.Round(time.Minute), resumeMaxGap))
+	}
+	if reach := last.GS*integritySpeedFactor*gap.Hours() + resumeDistanceSlack; check.Distance > reach {
+		check.Problems = append(check.Problems, fmt.Sprintf("aircraft is %.0f NM from its last position", check.Distance))
+	}
+	if !last.OnGround && check.FuelChange > integrityRefuelMin {
+		check.Problems = append(check.Problems, fmt.Sprintf("aircraft gained %.0f lbs of fuel in the air", check.FuelChange))
+	}
+	if burn := last.FuelFlow*integritySpeedFactor*gap.Hours() + resumeFuelSlack; -check.FuelChange > burn {
+		check.Problems = append(check.Problems, fmt.Sprintf("aircraft lost %.0f lbs of fuel", -check.FuelChange))
+	}
+	return check
+}
+
+// interruptionEvent records the gap of a resumed flight in its integrity log.
+func interruptionEvent(last *lastSample, fd *FlightData, check ResumeCheck) IntegrityEvent {
+	return IntegrityEvent{
+		Type:      integrityInterruption,
+		Time:      last.Time,
+		SimTime:   simZuluTimestamp(fd.SimTime),
+		Duration:  math.Round(check.Gap),
+		Latitude:  last.Latitude,
+		Longitude: last.Longitude,
+		Evidence: map[string]measurement{
+			"distance":     m(check.Distance, "NM"),
+			"fuelChange":   m(check.FuelChange, "lbs"),
+			"fromAltitude": m(last.Altitude, "ft"),
+			"toAltitude":   m(fd.Position.Altitude, "ft"),
+			"toLatitude":   m(fd.Position.Latitude, "deg"),
+			"toLongitude":  m(fd.Position.Longitude, "deg"),
+		},
+	}
+}
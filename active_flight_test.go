@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveFlightStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := openDB(path)
+	require.NoError(t, err)
+	s := newActiveFlightStore(db)
+
+	saved, err := s.load()
+	require.NoError(t, err)
+	assert.Nil(t, saved, "no flight is active")
+
+	start := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.save(&savedFlight{FlightID: "BAW123-1", Callsign: "BAW123", StartTime: start}))
+	require.NoError(t, s.save(&savedFlight{FlightID: "BAW123-1", Callsign: "BAW123", StartTime: start,
+		Last: newLastSample(sampleFlightData(), start.Add(time.Hour))}))
+	db.Close()
+
+	db, err = openDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	s = newActiveFlightStore(db)
+	saved, err = s.load()
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, "BAW123", saved.Callsign)
+	assert.True(t, start.Equal(saved.StartTime))
+	require.NotNil(t, saved.Last)
+	assert.Equal(t, 2200.0, saved.Last.FuelFlow, "both engines")
+
+	require.NoError(t, s.clear())
+	saved, _ = s.load()
+	assert.Nil(t, saved)
+}
+
+func TestCheckContinuity(t *testing.T) {
+	fd := sampleFlightData()
+	fd.Sensors.OnGround = false
+	fd.Attitude.GS = 450
+	saved := time.Now()
+	last := newLastSample(fd, saved)
+
+	check := checkContinuity(last, fd, saved.Add(time.Minute))
+	assert.Empty(t, check.Problems, "the simulator was restarted where the app last saw it")
+	assert.Equal(t, 60.0, check.Gap)
+
+	// The simulator kept flying for 10 minutes at 450 kts.
+	flown := *fd
+	flown.Position.Latitude += 1.25
+	flown.Weight.FuelWeight -= 400
+	check = checkContinuity(last, &flown, saved.Add(10*time.Minute))
+	assert.Empty(t, check.Problems)
+	assert.InDelta(t, 75, check.Distance, 1)
+	assert.Equal(t, -400.0, check.FuelChange)
+
+	moved := *fd
+	moved.Position.Latitude += 5
+	refueled := *fd
+	refueled.Weight.FuelWeight += 2000
+	drained := *fd
+	drained.Weight.FuelWeight -= 5000
+	for _, tc := range []struct {
+		name string
+		fd   *FlightData
+		gap  time.Duration
+		want string
+	}{
+		{"moved", &moved, time.Minute, "NM from its last position"},
+		{"refueled", &refueled, time.Minute, "gained 2000 lbs"},
+		{"drained", &drained, time.Minute, "lost 5000 lbs"},
+		{"too long", fd, 3 * time.Hour, "interrupted for 3h0m0s"},
+	} {
+		check := checkContinuity(last, tc.fd, saved.Add(tc.gap))
+		require.Len(t, check.Problems, 1, tc.name)
+		assert.Contains(t, check.Problems[0], tc.want, tc.name)
+	}
+}
+
+func TestResumeFlightWithoutSample(t *testing.T) {
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	fds := &FlightDataService{connector: &MockSimConnector{data: sampleFlightData(), name: "TestSim"}, simActive: true}
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	// The app stopped before the flight's first sample was saved.
+	store := newActiveFlightStore(db)
+	require.NoError(t, store.save(&savedFlight{FlightID: "BAW123-1", Callsign: "BAW123", Departure: "EGLL",
+		StartTime: time.Now().Add(-time.Hour)}))
+
+	f := &FlightService{auth: auth, flightData: fds, store: store, state: "idle"}
+	f.Start()
+	require.NotNil(t, f.GetResumableFlight())
+	check, err := f.ResumeFlight()
+	assert.ErrorContains(t, err, "no position was saved")
+	assert.Len(t, check.Problems, 1)
+	assert.Equal(t, "idle", f.GetFlightState())
+	assert.NotNil(t, f.GetResumableFlight(), "it can still be discarded")
+}
+
+func TestResumeFlight(t *testing.T) {
+	var mu sync.Mutex
+	var integrity []map[string]interface{}
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/acars/integrity" {
+			var p map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&p)
+			mu.Lock()
+			integrity = append(integrity, p)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	fd := sampleFlightData()
+	mock := &MockSimConnector{data: fd, name: "TestSim"}
+	fds := &FlightDataService{connector: mock, simActive: true}
+	startTestStream(t, fds)
+
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// A previous run saved the flight and crashed.
+	start := time.Now().Add(-time.Hour)
+	prev := &FlightService{store: newActiveFlightStore(db), state: "active", flightID: "BAW123-1",
+		callsign: "BAW123", departure: "EGLL", arrival: "KJFK", startTime: start}
+	prev.oooi.times.Out = &OOOIEvent{Time: start.Add(10 * time.Minute)}
+	prev.mu.Lock()
+	prev.persistFlight()
+	prev.mu.Unlock()
+	prev.saveProgress(fd, time.Now().Add(-5*time.Minute))
+
+	// Reports queued after the last save still count.
+	_, err = newPositionOutbox(db).enqueue("BAW123-1", 42, map[string]interface{}{})
+	require.NoError(t, err)
+
+	f := &FlightService{auth: auth, flightData: fds, outbox: newPositionOutbox(db), store: newActiveFlightStore(db), state: "idle"}
+	f.Start()
+	resumable := f.GetResumableFlight()
+	require.NotNil(t, resumable)
+	assert.Equal(t, "BAW123", resumable.Callsign)
+	assert.ErrorContains(t, f.StartFlight("AFR1", "LFPG", "EGLL"), "resume or discard")
+
+	check, err := f.ResumeFlight()
+	require.NoError(t, err)
+	assert.InDelta(t, 300, check.Gap, 5)
+	assert.Equal(t, "active", f.GetFlightState())
+	assert.Nil(t, f.GetResumableFlight())
+
+	f.mu.Lock()
+	assert.Equal(t, "BAW123-1", f.flightID, "the same flight identity")
+	assert.GreaterOrEqual(t, f.sequence, int64(42), "numbering carries on")
+	assert.NotNil(t, f.oooi.times.Out)
+	summary := f.integrity.summary()
+	f.mu.Unlock()
+	assert.InDelta(t, 300, summary.Interrupted, 5)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(integrity) == 1
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, integrityInterruption, integrity[0]["event"].(map[string]interface{})["type"])
+	mu.Unlock()
+
+	require.NoError(t, f.StopFlight())
+	saved, err := f.store.load()
+	require.NoError(t, err)
+	assert.Nil(t, saved, "an ended flight is not resumed")
+}
+
+func TestResumeFlightRefusesDiscontinuity(t *testing.T) {
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	fd := sampleFlightData()
+	mock := &MockSimConnector{data: fd, name: "TestSim"}
+	fds := &FlightDataService{connector: mock, simActive: true}
+
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	elsewhere := *fd
+	elsewhere.Position.Latitude += 10
+	store := newActiveFlightStore(db)
+	require.NoError(t, store.save(&savedFlight{FlightID: "BAW123-1", Callsign: "BAW123",
+		Last: newLastSample(&elsewhere, time.Now().Add(-time.Minute))}))
+
+	f := &FlightService{auth: auth, flightData: fds, store: store, state: "idle"}
+	f.Start()
+	_, err = f.ResumeFlight()
+	assert.ErrorContains(t, err, "NM from its last position")
+	assert.Equal(t, "idle", f.GetFlightState())
+
+	require.NoError(t, f.DiscardResumableFlight())
+	assert.Nil(t, f.GetResumableFlight())
+	saved, _ := store.load()
+	assert.Nil(t, saved)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -164,6 +165,22 @@ func (a *AuthService) OpenAuthorizationURL(userCode string) error {
 // doRequest makes an authenticated HTTP request to the tenant API.
 // Used internally by other services in the same package.
 func (a *AuthService) doRequest(method, path string, body interface{}) ([]byte, int, error) {
+	return a.send(method, path, body, false, "")
+}
+
+// doGzipRequest is doRequest with the JSON body gzip-compressed, for large
+// uploads.
+func (a *AuthService) doGzipRequest(method, path string, body interface{}) ([]byte, int, error) {
+	return a.send(method, path, body, true, "")
+}
+
+// doIdempotentRequest is doRequest with an Idempotency-Key header, so the
+// server applies a request sent more than once with the same key only once.
+func (a *AuthService) doIdempotentRequest(method, path string, body interface{}, key string) ([]byte, int, error) {
+	return a.send(method, path, body, false, key)
+}
+
+func (a *AuthService) send(method, path string, body interface{}, compress bool, idempotencyKey string) ([]byte, int, error) {
 	a.mu.RLock()
 	baseURL := a.tenantBaseURL
 	token := a.token
@@ -179,6 +196,17 @@ func (a *AuthService) doRequest(method, path string, body interface{}) ([]byte,
 		if err != nil {
 			return nil, 0, fmt.Errorf("marshal body: %w", err)
 		}
+		if compress {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(jsonBytes); err != nil {
+				return nil, 0, fmt.Errorf("compress body: %w", err)
+			}
+			if err := zw.Close(); err != nil {
+				return nil, 0, fmt.Errorf("compress body: %w", err)
+			}
+			jsonBytes = buf.Bytes()
+		}
 		bodyReader = bytes.NewReader(jsonBytes)
 	}
 
@@ -187,6 +215,12 @@ func (a *AuthService) doRequest(method, path string, body interface{}) ([]byte,
 		return nil, 0, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
@@ -204,3 +238,10 @@ func (a *AuthService) doRequest(method, path string, body interface{}) ([]byte,
 
 	return respBody, resp.StatusCode, nil
 }
+
+// tenantURL returns the base URL of the selected tenant.
+func (a *AuthService) tenantURL() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.tenantBaseURL
+}
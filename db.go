@@ -20,28 +20,24 @@ func initDB() (*sql.DB, error) {
 		return nil, fmt.Errorf("create db dir: %w", err)
 	}
 
-	dbPath := filepath.Join(dbDir, "flight_data.db")
-	db, err := sql.Open("sqlite", dbPath)
+	return openDB(filepath.Join(dbDir, "flight_data.db"))
+}
+
+// openDB opens the SQLite database at dbPath and migrates its schema.
+// Recording and the position outbox write from different goroutines, so a
+// connection waits for another's write instead of failing with SQLITE_BUSY.
+// In WAL mode readers do not block the writer, and with synchronous=NORMAL a
+// commit does not wait for the disk; a power cut may lose the last commits
+// but cannot corrupt the database.
+func openDB(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)")
 	if err != nil {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
 
-	// Migrate: drop old column-per-field schema if it exists
-	var colCount int
-	row := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('flight_data') WHERE name = 'altitude'`)
-	if err := row.Scan(&colCount); err == nil && colCount > 0 {
-		db.Exec(`DROP TABLE flight_data`)
-	}
-
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS flight_data (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		data TEXT NOT NULL
-	)`)
-	if err != nil {
+	if err := migrate(db, dbPath); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("create table: %w", err)
+		return nil, fmt.Errorf("migrate db: %w", err)
 	}
-
 	return db, nil
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultDRHeading     = 3.0   // deg
+	defaultDRSpeed       = 10.0  // kts
+	defaultDRVS          = 250.0 // fpm
+	defaultDRMaxInterval = 60.0  // s
+)
+
+// drThresholds are how far the aircraft may depart from the state of its last
+// report before dead reckoning sends another.
+type drThresholds struct {
+	heading     float64 // deg
+	speed       float64 // kts of ground speed
+	vs          float64 // fpm
+	maxInterval time.Duration
+}
+
+// drPhases are the phases in which dead reckoning may hold reports back. The
+// others, from the gate to the initial climb and from the approach on, keep
+// the cadence's rate.
+var drPhases = map[FlightPhase]bool{
+	PhaseClimb:   true,
+	PhaseCruise:  true,
+	PhaseDescent: true,
+}
+
+// deadReckoning holds back the position reports the server can predict:
+// between reports it assumes the aircraft keeps the heading, ground speed and
+// vertical speed of the last one, so a report is only due when the aircraft
+// departs from that, the phase changes, or maxInterval has passed.
+type deadReckoning struct {
+	thresholds drThresholds
+	last       *FlightData // last reported sample
+	lastAt     time.Time
+	lastPhase  FlightPhase
+	held       int64 // reports held back
+}
+
+func newDeadReckoning(s Settings) *deadReckoning {
+	t := drThresholds{
+		heading:     orDefault(s.DRHeadingThreshold, defaultDRHeading),
+		speed:       orDefault(s.DRSpeedThreshold, defaultDRSpeed),
+		vs:          orDefault(s.DRVSThreshold, defaultDRVS),
+		maxInterval: time.Duration(orDefault(s.DRMaxInterval, defaultDRMaxInterval) * float64(time.Second)),
+	}
+	return &deadReckoning{thresholds: t}
+}
+
+func orDefault(v, def float64) float64 {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// due reports whether fd, sampled at now in phase, needs a report. critical
+// is set when the cadence is at its critical rate, which always reports.
+func (d *deadReckoning) due(fd *FlightData, now time.Time, phase FlightPhase, critical bool) bool {
+	last := d.last
+	if last == nil || critical || !drPhases[phase] || phase != d.lastPhase {
+		return true
+	}
+	t := d.thresholds
+	switch {
+	case now.Sub(d.lastAt) >= t.maxInterval,
+		headingDiff(fd.Attitude.HeadingTrue, last.Attitude.HeadingTrue) > t.heading,
+		math.Abs(fd.Attitude.GS-last.Attitude.GS) > t.speed,
+		math.Abs(fd.Attitude.VS-last.Attitude.VS) > t.vs:
+		return true
+	}
+	d.held++
+	return false
+}
+
+// reported records the sample of the report just sent.
+func (d *deadReckoning) reported(fd *FlightData, now time.Time, phase FlightPhase) {
+	d.last, d.lastAt, d.lastPhase = fd, now, phase
+}
+
+// headingDiff returns the angle between two headings in degrees, 0 to 180.
+func headingDiff(a, b float64) float64 {
+	diff := math.Mod(math.Abs(a-b), 360)
+	return min(diff, 360-diff)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadReckoningDue(t *testing.T) {
+	dr := newDeadReckoning(Settings{DRMaxInterval: 30})
+	now := time.Now()
+	fd := sampleFlightData()
+	fd.Attitude.HeadingTrue = 358
+	fd.Attitude.GS = 450
+	fd.Attitude.VS = 0
+
+	assert.True(t, dr.due(fd, now, PhaseCruise, false), "the first sample is reported")
+	dr.reported(fd, now, PhaseCruise)
+
+	steady := *fd
+	steady.Attitude.HeadingTrue = 1 // 3 degrees across north
+	steady.Attitude.GS = 458
+	steady.Attitude.VS = -200
+	now = now.Add(10 * time.Second)
+	assert.False(t, dr.due(&steady, now, PhaseCruise, false), "within the thresholds")
+
+	for _, tc := range []struct {
+		name   string
+		change func(*FlightData)
+	}{
+		{"turn", func(d *FlightData) { d.Attitude.HeadingTrue = 5 }},
+		{"speed", func(d *FlightData) { d.Attitude.GS = 430 }},
+		{"descent", func(d *FlightData) { d.Attitude.VS = -1000 }},
+	} {
+		changed := steady
+		tc.change(&changed)
+		assert.True(t, dr.due(&changed, now, PhaseCruise, false), tc.name)
+	}
+
+	assert.True(t, dr.due(&steady, now, PhaseDescent, false), "phase change")
+	assert.True(t, dr.due(&steady, now, PhaseCruise, true), "critical cadence")
+	assert.True(t, dr.due(&steady, now.Add(20*time.Second), PhaseCruise, false), "max interval")
+	assert.Equal(t, int64(1), dr.held)
+
+	dr.reported(fd, now, PhaseApproach)
+	assert.True(t, dr.due(fd, now.Add(time.Second), PhaseApproach, false), "approaches are not held back")
+}
+
+func TestDeadReckoningCutsCruiseReports(t *testing.T) {
+	flight := newSyntheticFlight(defaultFlightProfile())
+	dr := newDeadReckoning(Settings{})
+	start := time.Now()
+
+	var reports, samples int
+	for _, s := range flight.segments {
+		if s.phase != PhaseCruise {
+			continue
+		}
+		for pos := s.start; pos < s.start+s.duration; pos += posIntervalHigh {
+			samples++
+			at := start.Add(pos)
+			fd := flight.sampleAt(pos)
+			if dr.due(fd, at, PhaseCruise, false) {
+				dr.reported(fd, at, PhaseCruise)
+				reports++
+			}
+		}
+	}
+	assert.Positive(t, samples)
+	assert.Less(t, reports*10, samples, "an order of magnitude fewer reports in cruise")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// csvColumn maps one ExportCSV column to a FlightData field. field returns a
+// pointer to the value, either *float64 or *bool.
+type csvColumn struct {
+	name  string
+	field func(d *FlightData) interface{}
+}
+
+// flightDataCSVColumns lists the ExportCSV columns after "timestamp", in order.
+// The replay adapter reads CSV files back through the same table. New columns
+// go at the end; older files without them still load.
+var flightDataCSVColumns = []csvColumn{
+	{"latitude", func(d *FlightData) interface{} { return &d.Position.Latitude }},
+	{"longitude", func(d *FlightData) interface{} { return &d.Position.Longitude }},
+	{"altitude", func(d *FlightData) interface{} { return &d.Position.Altitude }},
+	{"altitudeAGL", func(d *FlightData) interface{} { return &d.Position.AltitudeAGL }},
+	{"pitch", func(d *FlightData) interface{} { return &d.Attitude.Pitch }},
+	{"roll", func(d *FlightData) interface{} { return &d.Attitude.Roll }},
+	{"headingTrue", func(d *FlightData) interface{} { return &d.Attitude.HeadingTrue }},
+	{"headingMag", func(d *FlightData) interface{} { return &d.Attitude.HeadingMag }},
+	{"vs", func(d *FlightData) interface{} { return &d.Attitude.VS }},
+	{"ias", func(d *FlightData) interface{} { return &d.Attitude.IAS }},
+	{"tas", func(d *FlightData) interface{} { return &d.Attitude.TAS }},
+	{"gs", func(d *FlightData) interface{} { return &d.Attitude.GS }},
+	{"eng1Running", func(d *FlightData) interface{} { return &d.Engines[0].Running }},
+	{"eng1N1", func(d *FlightData) interface{} { return &d.Engines[0].N1 }},
+	{"eng1N2", func(d *FlightData) interface{} { return &d.Engines[0].N2 }},
+	{"eng1Throttle", func(d *FlightData) interface{} { return &d.Engines[0].ThrottlePos }},
+	{"eng2Running", func(d *FlightData) interface{} { return &d.Engines[1].Running }},
+	{"eng2N1", func(d *FlightData) interface{} { return &d.Engines[1].N1 }},
+	{"eng2N2", func(d *FlightData) interface{} { return &d.Engines[1].N2 }},
+	{"eng2Throttle", func(d *FlightData) interface{} { return &d.Engines[1].ThrottlePos }},
+	{"onGround", func(d *FlightData) interface{} { return &d.Sensors.OnGround }},
+	{"stallWarning", func(d *FlightData) interface{} { return &d.Sensors.StallWarning }},
+	{"overspeedWarning", func(d *FlightData) interface{} { return &d.Sensors.OverspeedWarning }},
+	{"com1", func(d *FlightData) interface{} { return &d.Radios.Com1 }},
+	{"com2", func(d *FlightData) interface{} { return &d.Radios.Com2 }},
+	{"nav1", func(d *FlightData) interface{} { return &d.Radios.Nav1 }},
+	{"nav2", func(d *FlightData) interface{} { return &d.Radios.Nav2 }},
+	{"xpdrCode", func(d *FlightData) interface{} { return &d.Radios.XpdrCode }},
+	{"apMaster", func(d *FlightData) interface{} { return &d.Autopilot.Master }},
+	{"apHeading", func(d *FlightData) interface{} { return &d.Autopilot.Heading }},
+	{"apAltitude", func(d *FlightData) interface{} { return &d.Autopilot.Altitude }},
+	{"apVS", func(d *FlightData) interface{} { return &d.Autopilot.VS }},
+	{"apSpeed", func(d *FlightData) interface{} { return &d.Autopilot.Speed }},
+	{"altimeterInHg", func(d *FlightData) interface{} { return &d.Altimeter }},
+	{"beacon", func(d *FlightData) interface{} { return &d.Lights.Beacon }},
+	{"strobe", func(d *FlightData) interface{} { return &d.Lights.Strobe }},
+	{"landing", func(d *FlightData) interface{} { return &d.Lights.Landing }},
+	{"elevator", func(d *FlightData) interface{} { return &d.Controls.Elevator }},
+	{"aileron", func(d *FlightData) interface{} { return &d.Controls.Aileron }},
+	{"rudder", func(d *FlightData) interface{} { return &d.Controls.Rudder }},
+	{"flaps", func(d *FlightData) interface{} { return &d.Controls.Flaps }},
+	{"spoilers", func(d *FlightData) interface{} { return &d.Controls.Spoilers }},
+	{"gearDown", func(d *FlightData) interface{} { return &d.Controls.GearDown }},
+	{"eng1FuelFlow", func(d *FlightData) interface{} { return &d.Engines[0].FuelFlow }},
+	{"eng2FuelFlow", func(d *FlightData) interface{} { return &d.Engines[1].FuelFlow }},
+	{"fuelWeight", func(d *FlightData) interface{} { return &d.Weight.FuelWeight }},
+	{"fuelLeft", func(d *FlightData) interface{} { return &d.Weight.FuelTanks[0] }},
+	{"fuelCenter", func(d *FlightData) interface{} { return &d.Weight.FuelTanks[1] }},
+	{"fuelRight", func(d *FlightData) interface{} { return &d.Weight.FuelTanks[2] }},
+	{"fuelAuxiliary", func(d *FlightData) interface{} { return &d.Weight.FuelTanks[3] }},
+	{"oat", func(d *FlightData) interface{} { return &d.Environment.OAT }},
+	{"windDirection", func(d *FlightData) interface{} { return &d.Environment.WindDirection }},
+	{"windSpeed", func(d *FlightData) interface{} { return &d.Environment.WindSpeed }},
+	{"qnh", func(d *FlightData) interface{} { return &d.Environment.QNH }},
+	{"visibility", func(d *FlightData) interface{} { return &d.Environment.Visibility }},
+	{"parkingBrake", func(d *FlightData) interface{} { return &d.Controls.ParkingBrake }},
+	{"pushback", func(d *FlightData) interface{} { return &d.Sensors.Pushback }},
+	{"flapsDetent", func(d *FlightData) interface{} { return &d.Controls.FlapsDetent }},
+}
+
+// csvHeader returns the full ExportCSV header row.
+func csvHeader() []string {
+	header := []string{"timestamp"}
+	for _, c := range flightDataCSVColumns {
+		header = append(header, c.name)
+	}
+	return header
+}
+
+func (c csvColumn) format(d *FlightData) string {
+	switch v := c.field(d).(type) {
+	case *float64:
+		return strconv.FormatFloat(*v, 'f', 4, 64)
+	case *bool:
+		if *v {
+			return "1"
+		}
+		return "0"
+	}
+	return ""
+}
+
+func (c csvColumn) parse(d *FlightData, s string) error {
+	switch v := c.field(d).(type) {
+	case *float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", c.name, err)
+		}
+		*v = f
+	case *bool:
+		*v = s == "1" || s == "true"
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// flightDataSetter stores a simulator value into one FlightData field.
+type flightDataSetter func(d *FlightData, v float64)
+
+func floatField(field func(d *FlightData) *float64) flightDataSetter {
+	return func(d *FlightData, v float64) { *field(d) = v }
+}
+
+func boolField(field func(d *FlightData) *bool) flightDataSetter {
+	return func(d *FlightData, v float64) { *field(d) = v != 0 }
+}
+
+// flightDataFloats gives access to the numeric FlightData fields by name,
+// e.g. for range checks.
+var flightDataFloats = buildFlightDataFloats()
+
+func buildFlightDataFloats() map[string]func(d *FlightData) *float64 {
+	fields := map[string]func(d *FlightData) *float64{
+		"position.latitude":    func(d *FlightData) *float64 { return &d.Position.Latitude },
+		"position.longitude":   func(d *FlightData) *float64 { return &d.Position.Longitude },
+		"position.altitude":    func(d *FlightData) *float64 { return &d.Position.Altitude },
+		"position.altitudeAGL": func(d *FlightData) *float64 { return &d.Position.AltitudeAGL },
+
+		"attitude.pitch":       func(d *FlightData) *float64 { return &d.Attitude.Pitch },
+		"attitude.roll":        func(d *FlightData) *float64 { return &d.Attitude.Roll },
+		"attitude.headingTrue": func(d *FlightData) *float64 { return &d.Attitude.HeadingTrue },
+		"attitude.headingMag":  func(d *FlightData) *float64 { return &d.Attitude.HeadingMag },
+		"attitude.vs":          func(d *FlightData) *float64 { return &d.Attitude.VS },
+		"attitude.ias":         func(d *FlightData) *float64 { return &d.Attitude.IAS },
+		"attitude.tas":         func(d *FlightData) *float64 { return &d.Attitude.TAS },
+		"attitude.gs":          func(d *FlightData) *float64 { return &d.Attitude.GS },
+		"attitude.gForce":      func(d *FlightData) *float64 { return &d.Attitude.GForce },
+
+		"sensors.simulationRate": func(d *FlightData) *float64 { return &d.Sensors.SimulationRate },
+
+		"radios.com1":     func(d *FlightData) *float64 { return &d.Radios.Com1 },
+		"radios.com2":     func(d *FlightData) *float64 { return &d.Radios.Com2 },
+		"radios.nav1":     func(d *FlightData) *float64 { return &d.Radios.Nav1 },
+		"radios.nav2":     func(d *FlightData) *float64 { return &d.Radios.Nav2 },
+		"radios.nav1OBS":  func(d *FlightData) *float64 { return &d.Radios.Nav1OBS },
+		"radios.nav2OBS":  func(d *FlightData) *float64 { return &d.Radios.Nav2OBS },
+		"radios.xpdrCode": func(d *FlightData) *float64 { return &d.Radios.XpdrCode },
+
+		"autopilot.heading":  func(d *FlightData) *float64 { return &d.Autopilot.Heading },
+		"autopilot.altitude": func(d *FlightData) *float64 { return &d.Autopilot.Altitude },
+		"autopilot.vs":       func(d *FlightData) *float64 { return &d.Autopilot.VS },
+		"autopilot.speed":    func(d *FlightData) *float64 { return &d.Autopilot.Speed },
+
+		"altimeterInHg": func(d *FlightData) *float64 { return &d.Altimeter },
+
+		"controls.elevator": func(d *FlightData) *float64 { return &d.Controls.Elevator },
+		"controls.aileron":  func(d *FlightData) *float64 { return &d.Controls.Aileron },
+		"controls.rudder":   func(d *FlightData) *float64 { return &d.Controls.Rudder },
+		"controls.flaps":    func(d *FlightData) *float64 { return &d.Controls.Flaps },
+		"controls.spoilers": func(d *FlightData) *float64 { return &d.Controls.Spoilers },
+
+		"controls.flapsDetent": func(d *FlightData) *float64 { return &d.Controls.FlapsDetent },
+
+		"simTime.zuluTime":  func(d *FlightData) *float64 { return &d.SimTime.ZuluTime },
+		"simTime.zuluDay":   func(d *FlightData) *float64 { return &d.SimTime.ZuluDay },
+		"simTime.zuluMonth": func(d *FlightData) *float64 { return &d.SimTime.ZuluMonth },
+		"simTime.zuluYear":  func(d *FlightData) *float64 { return &d.SimTime.ZuluYear },
+		"simTime.localTime": func(d *FlightData) *float64 { return &d.SimTime.LocalTime },
+
+		"apu.rpmPercent": func(d *FlightData) *float64 { return &d.APU.RPMPercent },
+
+		"weight.totalWeight": func(d *FlightData) *float64 { return &d.Weight.TotalWeight },
+		"weight.fuelWeight":  func(d *FlightData) *float64 { return &d.Weight.FuelWeight },
+
+		"environment.oat":           func(d *FlightData) *float64 { return &d.Environment.OAT },
+		"environment.windDirection": func(d *FlightData) *float64 { return &d.Environment.WindDirection },
+		"environment.windSpeed":     func(d *FlightData) *float64 { return &d.Environment.WindSpeed },
+		"environment.qnh":           func(d *FlightData) *float64 { return &d.Environment.QNH },
+		"environment.visibility":    func(d *FlightData) *float64 { return &d.Environment.Visibility },
+	}
+	for i := range len(FlightData{}.Engines) {
+		prefix := fmt.Sprintf("engine%d.", i+1)
+		fields[prefix+"n1"] = func(d *FlightData) *float64 { return &d.Engines[i].N1 }
+		fields[prefix+"n2"] = func(d *FlightData) *float64 { return &d.Engines[i].N2 }
+		fields[prefix+"throttlePos"] = func(d *FlightData) *float64 { return &d.Engines[i].ThrottlePos }
+		fields[prefix+"mixturePos"] = func(d *FlightData) *float64 { return &d.Engines[i].MixturePos }
+		fields[prefix+"propPos"] = func(d *FlightData) *float64 { return &d.Engines[i].PropPos }
+		fields[prefix+"fuelFlow"] = func(d *FlightData) *float64 { return &d.Engines[i].FuelFlow }
+	}
+	for i := range len(FlightData{}.Weight.FuelTanks) {
+		fields[fmt.Sprintf("weight.fuelTank%d", i+1)] = func(d *FlightData) *float64 { return &d.Weight.FuelTanks[i] }
+	}
+	for i := range len(FlightData{}.Doors) {
+		fields[fmt.Sprintf("door%d.openRatio", i+1)] = func(d *FlightData) *float64 { return &d.Doors[i].OpenRatio }
+	}
+	return fields
+}
+
+// flightDataFields names every FlightData field a connector can fill from a
+// number. Data-driven adapters map simulator variables onto these names.
+var flightDataFields = buildFlightDataFields()
+
+func buildFlightDataFields() map[string]flightDataSetter {
+	fields := map[string]flightDataSetter{
+		"sensors.onGround":         boolField(func(d *FlightData) *bool { return &d.Sensors.OnGround }),
+		"sensors.stallWarning":     boolField(func(d *FlightData) *bool { return &d.Sensors.StallWarning }),
+		"sensors.overspeedWarning": boolField(func(d *FlightData) *bool { return &d.Sensors.OverspeedWarning }),
+		"sensors.pushback":         boolField(func(d *FlightData) *bool { return &d.Sensors.Pushback }),
+
+		"radios.xpdrState": func(d *FlightData, v float64) {
+			d.Radios.XpdrState = TransponderStateString(v)
+		},
+
+		"autopilot.master":       boolField(func(d *FlightData) *bool { return &d.Autopilot.Master }),
+		"autopilot.approachHold": boolField(func(d *FlightData) *bool { return &d.Autopilot.ApproachHold }),
+		"autopilot.navLock":      boolField(func(d *FlightData) *bool { return &d.Autopilot.NavLock }),
+
+		"lights.beacon":  boolField(func(d *FlightData) *bool { return &d.Lights.Beacon }),
+		"lights.strobe":  boolField(func(d *FlightData) *bool { return &d.Lights.Strobe }),
+		"lights.landing": boolField(func(d *FlightData) *bool { return &d.Lights.Landing }),
+
+		"controls.gearDown":     boolField(func(d *FlightData) *bool { return &d.Controls.GearDown }),
+		"controls.parkingBrake": boolField(func(d *FlightData) *bool { return &d.Controls.ParkingBrake }),
+
+		"apu.switchOn":  boolField(func(d *FlightData) *bool { return &d.APU.SwitchOn }),
+		"apu.genSwitch": boolField(func(d *FlightData) *bool { return &d.APU.GenSwitch }),
+		"apu.genActive": boolField(func(d *FlightData) *bool { return &d.APU.GenActive }),
+
+		// Number of engines; marks the first n engines as existing.
+		"engines.count": func(d *FlightData, v float64) {
+			for i := range d.Engines {
+				d.Engines[i].Exists = int(v) > i
+			}
+		},
+	}
+
+	for name, field := range flightDataFloats {
+		fields[name] = floatField(field)
+	}
+	for i := range len(FlightData{}.Engines) {
+		prefix := fmt.Sprintf("engine%d.", i+1)
+		fields[prefix+"exists"] = boolField(func(d *FlightData) *bool { return &d.Engines[i].Exists })
+		fields[prefix+"running"] = boolField(func(d *FlightData) *bool { return &d.Engines[i].Running })
+	}
+	return fields
+}
+
+// flightDataTextFields name the FlightData fields that hold text.
+var flightDataTextFields = []string{"aircraftName", "aircraftIcao", "tailNumber"}
+
+// impliedFields are pseudo-fields that fill other fields instead of holding a
+// value of their own.
+var impliedFields = buildImpliedFields()
+
+func buildImpliedFields() map[string][]string {
+	implied := map[string][]string{
+		"engines.count":        {"engine1.exists", "engine2.exists", "engine3.exists", "engine4.exists"},
+		xplaneLocalDateField:   {"simTime.zuluDay", "simTime.zuluMonth", "simTime.zuluYear"},
+		xplaneFlapHandleField:  {"controls.flapsDetent"},
+		xplaneFlapDetentsField: {"controls.flapsDetent"},
+		xplaneWindMagField:     {"environment.windDirection"},
+	}
+	for name := range xplaneTankFields {
+		implied[name] = []string{"weight.fuelTank1", "weight.fuelTank2", "weight.fuelTank3", "weight.fuelTank4"}
+	}
+	return implied
+}
+
+// allFlightDataFields lists every FlightData field by name, sorted. These are
+// the names used by ConnectorCapabilities and FlightData.Invalid.
+var allFlightDataFields = buildAllFlightDataFields()
+
+func buildAllFlightDataFields() []string {
+	var names []string
+	for name := range flightDataFields {
+		if _, pseudo := impliedFields[name]; !pseudo {
+			names = append(names, name)
+		}
+	}
+	names = append(names, flightDataTextFields...)
+	slices.Sort(names)
+	return names
+}
+
+// withImpliedFields replaces pseudo-fields by the fields they fill and
+// returns the result sorted, without duplicates.
+func withImpliedFields(fields []string) []string {
+	var out []string
+	for _, f := range fields {
+		if implied, ok := impliedFields[f]; ok {
+			out = append(out, implied...)
+			continue
+		}
+		out = append(out, f)
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// fieldsExcept returns the fields in allFlightDataFields that are not in
+// fields.
+func fieldsExcept(fields []string) []string {
+	var out []string
+	for _, f := range allFlightDataFields {
+		if !slices.Contains(fields, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// fieldTracker follows which fields an adapter has received a value for.
+// The zero value has received nothing.
+type fieldTracker struct {
+	received map[string]bool
+	invalid  []string // fields not in received
+}
+
+// mark records a value for field, or for the fields a pseudo-field fills.
+func (t *fieldTracker) mark(field string) {
+	if t.received[field] {
+		return
+	}
+	if t.received == nil {
+		t.received = make(map[string]bool)
+	}
+	t.received[field] = true
+	for _, f := range impliedFields[field] {
+		t.received[f] = true
+	}
+	t.invalid = slices.DeleteFunc(slices.Clone(allFlightDataFields), func(f string) bool { return t.received[f] })
+}
+
+// invalidFields lists the fields without a value, for FlightData.Invalid.
+// The slice is replaced, never modified, so samples may share it.
+func (t *fieldTracker) invalidFields() []string {
+	if t.received == nil {
+		return allFlightDataFields
+	}
+	return t.invalid
+}
+
+// unitConversions turn simulator units into the units FlightData uses.
+var unitConversions = map[string]func(float64) float64{
+	"":                  func(v float64) float64 { return v },
+	"m_to_ft":           func(v float64) float64 { return v * 3.28084 },
+	"mps_to_kts":        func(v float64) float64 { return v * 1.94384 },
+	"kg_to_lbs":         func(v float64) float64 { return v * 2.20462 },
+	"kgs_to_lbsh":       func(v float64) float64 { return v * 2.20462 * 3600 },
+	"inhg_to_hpa":       func(v float64) float64 { return v * 33.8639 },
+	"ratio_to_percent":  func(v float64) float64 { return v * 100 },
+	"freq_10khz_to_mhz": func(v float64) float64 { return v / 100 },
+}
@@ -2,12 +2,10 @@ package main
 
 import (
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"strconv"
 	"sync"
 	"time"
 
@@ -18,27 +16,77 @@ const (
 	stalenessThreshold  = 10 * time.Second
 	reconnectBaseDelay  = 5 * time.Second
 	reconnectMaxBackoff = 60 * time.Second
+	sampleMaxAge        = 3 * time.Second // older samples no longer describe the simulator
+	streamSampleBuffer  = 4
 )
 
 type FlightDataService struct {
 	db                *sql.DB
+	settings          *SettingsService
 	app               *application.App
 	connector         SimConnector
 	mu                sync.Mutex
 	recording         bool
+	recordSub         *telemetrySubscription // feeding recordLoop
+	recordDone        chan struct{}          // closed when recordLoop returns
+	recordRate        int                    // Hz of the session being recorded
 	startTime         time.Time
 	dataCount         int
+	sessions          *sessionStore
+	writer            *sampleWriter
+	session           int64         // being recorded
+	sessionStats      sessionStats  // of the session being recorded
+	flight            sessionFlight // active flight, recordings are labelled with
 	streaming         bool
 	streamStopCh      chan struct{}
 	simActive         bool
 	adapterName       string
 	reconnectAttempts int
 	lastReconnectAt   time.Time
+	phase             FlightPhase
+	updateRate        int
+	xplane            xplaneEndpoint // endpoint of the last X-Plane connection, reused on reconnect
+	discovery         *xplaneDiscovery
+	discoveryOnce     sync.Once
+	telemetry         telemetryBroadcaster
+	filter            telemetryFilter
 }
 
-func NewFlightDataService(db *sql.DB) *FlightDataService {
+func NewFlightDataService(db *sql.DB, settings *SettingsService) *FlightDataService {
 	return &FlightDataService{
-		db: db,
+		db:       db,
+		settings: settings,
+		sessions: newSessionStore(db),
+		writer:   newSampleWriter(db, recorderQueueSize, recorderBatchSize, recorderFlushInterval),
+	}
+}
+
+// shutdown ends the recording and writes the samples still queued.
+func (f *FlightDataService) shutdown() {
+	f.StopRecording()
+	if f.writer != nil {
+		f.writer.close()
+	}
+}
+
+// Start ends the recording sessions a previous run left open and applies the
+// recording retention setting.
+func (f *FlightDataService) Start() {
+	if f.sessions == nil {
+		return
+	}
+	if n, err := f.sessions.recover(); err != nil {
+		slog.Warn("failed to recover recording sessions", "error", err)
+	} else if n > 0 {
+		slog.Info("recovered interrupted recording sessions", "count", n)
+	}
+	if days := f.currentSettings().RecordingRetention; days > 0 {
+		n, err := f.sessions.prune(time.Duration(days * 24 * float64(time.Hour)))
+		if err != nil {
+			slog.Warn("failed to prune recording sessions", "error", err)
+		} else if n > 0 {
+			slog.Info("pruned recording sessions", "deleted", n)
+		}
 	}
 }
 
@@ -46,41 +94,174 @@ func (f *FlightDataService) setApp(app *application.App) {
 	f.app = app
 }
 
+// currentSettings returns the saved settings, or zero values when the service
+// was created without a SettingsService.
+func (f *FlightDataService) currentSettings() Settings {
+	if f.settings == nil {
+		return Settings{}
+	}
+	return f.settings.GetSettings()
+}
+
 func (f *FlightDataService) ConnectSim(simType string) (string, error) {
-	f.mu.Lock()
+	label, err := f.connectWith(func(settings Settings) (SimConnector, bool, error) {
+		switch simType {
+		case "xplane":
+			return NewXPlaneAdapter(xplaneEndpointFromSettings(settings), settings.XPlaneDatarefs), false, nil
+		case "xplaneweb":
+			return NewXPlaneWebAdapter(xplaneWebEndpointFromSettings(settings), settings.XPlaneDatarefs), false, nil
+		case "replay":
+			return NewReplayAdapter(f.db, settings.ReplaySource, settings.ReplaySpeed), false, nil
+		case "synthetic":
+			return NewSyntheticAdapter(settings.SyntheticProfile, settings.ReplaySpeed), false, nil
+		case "flightgear":
+			return NewFlightGearAdapter(settings.FlightGearPort), false, nil
+		case "foreflight":
+			return NewForeFlightAdapter(settings.ForeFlightPort), false, nil
+		case "simconnect":
+			connector := NewSimConnectAdapter()
+			if connector == nil {
+				return nil, false, fmt.Errorf("SimConnect not available on this platform")
+			}
+			return connector, false, nil
+		default: // "auto"
+			web := NewXPlaneWebAdapter(xplaneWebEndpointFromSettings(settings), settings.XPlaneDatarefs)
+			err := web.Connect()
+			if err == nil {
+				return web, true, nil
+			}
+			slog.Info("X-Plane web API not available, trying SimConnect", "error", err)
 
-	if f.connector != nil {
-		f.stopDataStreamLocked()
-		f.connector.Disconnect()
+			sc := NewSimConnectAdapter()
+			if sc != nil {
+				err := sc.Connect()
+				if err == nil {
+					return sc, true, nil
+				}
+				slog.Info("SimConnect not available, trying X-Plane", "error", err)
+			}
+			return nil, false, errTryXPlane
+		}
+	})
+	if !errors.Is(err, errTryXPlane) {
+		return label, err
 	}
 
-	var connector SimConnector
-	connected := false
+	// Discovery listens for beacons for a while, so the X-Plane of "auto" is
+	// looked up outside connectWith, which holds f.mu.
+	endpoint := f.autoXPlaneEndpoint(f.currentSettings())
+	return f.connectWith(func(settings Settings) (SimConnector, bool, error) {
+		return NewXPlaneAdapter(endpoint, settings.XPlaneDatarefs), false, nil
+	})
+}
 
-	switch simType {
-	case "xplane":
-		connector = NewXPlaneAdapter("127.0.0.1", 49000)
-	case "simconnect":
-		connector = NewSimConnectAdapter()
-		if connector == nil {
-			f.mu.Unlock()
-			return "", fmt.Errorf("SimConnect not available on this platform")
-		}
-	default: // "auto"
-		sc := NewSimConnectAdapter()
-		if sc != nil {
-			if err := sc.Connect(); err == nil {
-				connector = sc
-				connected = true
-			} else {
-				slog.Info("SimConnect not available, trying X-Plane", "error", err)
+// errTryXPlane is "auto" finding neither the X-Plane web API nor SimConnect,
+// leaving the X-Plane UDP adapter to try.
+var errTryXPlane = errors.New("no simulator found, trying X-Plane")
+
+// ConnectXPlaneInstance connects to an instance listed by GetXPlaneInstances
+// and remembers its computer name, so "auto" picks it again next time.
+func (f *FlightDataService) ConnectXPlaneInstance(host string, port int) (string, error) {
+	label, err := f.connectWith(func(settings Settings) (SimConnector, bool, error) {
+		endpoint := xplaneEndpointFromSettings(settings)
+		endpoint.host, endpoint.port = host, port
+		return NewXPlaneAdapter(endpoint, settings.XPlaneDatarefs), false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if d := f.xplaneDiscovery(); d != nil && f.settings != nil {
+		for _, inst := range d.list() {
+			if inst.Host == host && inst.Port == port {
+				settings := f.settings.GetSettings()
+				if settings.XPlaneComputer != inst.ComputerName {
+					settings.XPlaneComputer = inst.ComputerName
+					if err := f.settings.UpdateSettings(settings); err != nil {
+						slog.Warn("failed to remember X-Plane instance", "error", err)
+					}
+				}
+				break
 			}
 		}
-		if connector == nil {
-			connector = NewXPlaneAdapter("127.0.0.1", 49000)
+	}
+	return label, nil
+}
+
+// GetXPlaneInstances lists the X-Plane instances announcing themselves on the
+// local network, master first. The first call listens briefly for beacons.
+func (f *FlightDataService) GetXPlaneInstances() []XPlaneInstance {
+	d := f.xplaneDiscovery()
+	if d == nil {
+		return []XPlaneInstance{}
+	}
+	return d.wait(xplaneDiscoveryWait, func(list []XPlaneInstance) bool { return len(list) > 0 })
+}
+
+// xplaneDiscovery starts listening for BECN beacons on first use. It returns
+// nil if the beacon port cannot be opened.
+func (f *FlightDataService) xplaneDiscovery() *xplaneDiscovery {
+	f.discoveryOnce.Do(func() {
+		if f.discovery != nil {
+			return
+		}
+		d := newXPlaneDiscovery(xplaneBeaconAddr)
+		if err := d.start(); err != nil {
+			slog.Warn("X-Plane discovery unavailable", "error", err)
+			return
 		}
+		f.discovery = d
+	})
+	return f.discovery
+}
+
+// autoXPlaneEndpoint picks the X-Plane to use in "auto" mode. A host typed
+// into the settings wins; otherwise a discovered instance is used, falling
+// back to the local default when none announces itself.
+func (f *FlightDataService) autoXPlaneEndpoint(settings Settings) xplaneEndpoint {
+	endpoint := xplaneEndpointFromSettings(settings)
+	if endpoint.host != defaultXPlaneHost {
+		return endpoint
+	}
+
+	d := f.xplaneDiscovery()
+	if d == nil {
+		return endpoint
+	}
+	list := d.wait(xplaneDiscoveryWait, func(list []XPlaneInstance) bool {
+		inst, ok := pickXPlaneInstance(list, settings.XPlaneComputer)
+		return ok && (settings.XPlaneComputer == "" || inst.ComputerName == settings.XPlaneComputer)
+	})
+	if inst, ok := pickXPlaneInstance(list, settings.XPlaneComputer); ok {
+		slog.Info("using discovered X-Plane", "host", inst.Host, "port", inst.Port, "name", inst.ComputerName)
+		endpoint.host, endpoint.port = inst.Host, inst.Port
+	}
+	return endpoint
+}
+
+// connectWith replaces the current connector with the one chosen by choose
+// and waits for the first data. choose runs with f.mu held, so it must not
+// wait for anything but the adapter's own Connect, and reports whether it
+// already connected the adapter.
+func (f *FlightDataService) connectWith(choose func(settings Settings) (SimConnector, bool, error)) (string, error) {
+	settings := f.currentSettings()
+	f.mu.Lock()
+
+	if f.connector != nil {
+		f.stopDataStreamLocked()
+		f.connector.Disconnect()
+		f.connector = nil
 	}
 
+	connector, connected, err := choose(settings)
+	if err != nil {
+		f.mu.Unlock()
+		return "", err
+	}
+	f.telemetry.reset()
+	f.filter.reset(settings.SpikeWindow)
+	f.attachTelemetry(connector)
+
 	if !connected {
 		if err := connector.Connect(); err != nil {
 			f.mu.Unlock()
@@ -89,11 +270,22 @@ func (f *FlightDataService) ConnectSim(simType string) (string, error) {
 	}
 
 	f.connector = connector
+	f.applyUpdateRateLocked()
 	f.simActive = false
 	f.adapterName = connector.Name()
+	switch xp := connector.(type) {
+	case *XPlaneAdapter:
+		f.xplane = xp.endpoint
+	case *XPlaneWebAdapter:
+		f.xplane = xp.endpoint
+	}
+	f.phase = PhaseUnknown
 	f.reconnectAttempts = 0
 	f.lastReconnectAt = time.Time{}
-	slog.Info("adapter opened, waiting for data", "adapter", connector.Name())
+	slog.Info("adapter opened, waiting for data", "adapter", connectorLabel(connector))
+	if missing := fieldsExcept(connector.Capabilities().Fields); len(missing) > 0 {
+		slog.Info("adapter cannot supply some fields", "adapter", connector.Name(), "unavailable", missing)
+	}
 
 	f.startDataStreamLocked()
 	f.mu.Unlock()
@@ -106,15 +298,19 @@ func (f *FlightDataService) ConnectSim(simType string) (string, error) {
 	for {
 		select {
 		case <-deadline:
+			_, dataErr := connector.GetFlightData()
 			f.DisconnectSim()
-			return "", fmt.Errorf("no data received from %s — is the simulator running?", connector.Name())
+			if dataErr != nil {
+				return "", fmt.Errorf("no data received from %s: %w", connectorLabel(connector), dataErr)
+			}
+			return "", fmt.Errorf("no data received from %s — is the simulator running?", connectorLabel(connector))
 		case <-tick.C:
 			f.mu.Lock()
 			active := f.simActive
 			f.mu.Unlock()
 			if active {
-				slog.Info("connected to simulator", "adapter", connector.Name())
-				return connector.Name(), nil
+				slog.Info("connected to simulator", "adapter", connectorLabel(connector))
+				return connectorLabel(connector), nil
 			}
 		}
 	}
@@ -130,9 +326,12 @@ func (f *FlightDataService) DisconnectSim() {
 		f.connector.Disconnect()
 		f.connector = nil
 	}
+	f.telemetry.reset()
+	f.filter.reset(0)
 
 	f.simActive = false
 	f.adapterName = ""
+	f.phase = PhaseUnknown
 	f.reconnectAttempts = 0
 	f.lastReconnectAt = time.Time{}
 	if f.app != nil {
@@ -149,8 +348,17 @@ func (f *FlightDataService) reconnectSim() error {
 	f.connector = nil
 	f.simActive = false
 	name := f.adapterName
+	xplane := f.xplane
 	f.mu.Unlock()
 
+	// Playback connectors pick up where the old one stopped instead of
+	// starting the flight over.
+	var resume *ReplayStatus
+	if pc, ok := old.(playbackController); ok {
+		status := pc.Status()
+		resume = &status
+	}
+
 	// Blocking I/O outside lock
 	if old != nil {
 		old.Disconnect()
@@ -158,34 +366,199 @@ func (f *FlightDataService) reconnectSim() error {
 
 	var connector SimConnector
 	switch name {
+	case "Replay":
+		settings := f.currentSettings()
+		connector = NewReplayAdapter(f.db, settings.ReplaySource, settings.ReplaySpeed)
+	case "Synthetic":
+		settings := f.currentSettings()
+		connector = NewSyntheticAdapter(settings.SyntheticProfile, settings.ReplaySpeed)
+	case "FlightGear":
+		connector = NewFlightGearAdapter(f.currentSettings().FlightGearPort)
+	case "ForeFlight":
+		connector = NewForeFlightAdapter(f.currentSettings().ForeFlightPort)
 	case "SimConnect":
 		connector = NewSimConnectAdapter()
 		if connector == nil {
 			return fmt.Errorf("SimConnect not available")
 		}
+	case "X-Plane Web":
+		settings := f.currentSettings()
+		if xplane.host == "" {
+			xplane = xplaneWebEndpointFromSettings(settings)
+		}
+		connector = NewXPlaneWebAdapter(xplane, settings.XPlaneDatarefs)
 	case "X-Plane":
-		connector = NewXPlaneAdapter("127.0.0.1", 49000)
+		settings := f.currentSettings()
+		if xplane.host == "" {
+			xplane = xplaneEndpointFromSettings(settings)
+		}
+		connector = NewXPlaneAdapter(xplane, settings.XPlaneDatarefs)
 	default:
 		return fmt.Errorf("unknown adapter: %s", name)
 	}
 
+	f.attachTelemetry(connector)
 	if err := connector.Connect(); err != nil {
 		return fmt.Errorf("reconnect %s: %w", name, err)
 	}
+	if pc, ok := connector.(playbackController); ok && resume != nil {
+		pc.SetSpeed(resume.Speed)
+		pc.Seek(time.Duration(resume.Position * float64(time.Second)))
+		if resume.Paused {
+			pc.Pause()
+		}
+	}
 
 	f.mu.Lock()
 	f.connector = connector
+	f.applyUpdateRateLocked()
 	f.mu.Unlock()
 	return nil
 }
 
+// attachTelemetry routes the samples of a push connector into the
+// broadcaster. Other connectors are polled by dataStreamLoop.
+func (f *FlightDataService) attachTelemetry(c SimConnector) {
+	if p, ok := c.(SamplePusher); ok {
+		p.SetSampleSink(f.ingest)
+	}
+}
+
+// ingest passes a sample from the connector through the sanity filter and
+// publishes what survives. Every consumer sees only filtered samples.
+func (f *FlightDataService) ingest(data *FlightData) {
+	now := time.Now()
+	clean, reason := f.filter.check(data, now)
+	if reason != "" {
+		rejected, _ := f.filter.rejections()
+		slog.Debug("rejected simulator sample", "reason", reason, "rejected", rejected)
+		if f.app != nil && f.filter.notifyDue(now) {
+			f.app.Event.Emit("telemetry-rejected", rejected)
+		}
+		return
+	}
+	f.telemetry.publishAt(clean, now)
+}
+
+// subscribeTelemetry subscribes to simulator samples, at most one per
+// interval. Close the subscription when done.
+func (f *FlightDataService) subscribeTelemetry(buffer int, interval time.Duration) *telemetrySubscription {
+	return f.telemetry.subscribe(buffer, interval)
+}
+
+// pollInterval is how often dataStreamLoop polls the connector: at the
+// update rate for connectors that cannot push, once a second otherwise to
+// watch the connection.
+func (f *FlightDataService) pollInterval() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rate := f.updateRateLocked()
+	if _, push := f.connector.(SamplePusher); push || rate <= 1 {
+		return time.Second
+	}
+	return time.Second / time.Duration(rate)
+}
+
+// updateRateLocked is the rate the connector is asked for: the one set with
+// setUpdateRate, or the recording rate while that is higher. Must be called
+// with f.mu held.
+func (f *FlightDataService) updateRateLocked() int {
+	if f.recording && f.recordRate > f.updateRate {
+		return f.recordRate
+	}
+	return f.updateRate
+}
+
+// setUpdateRate asks the connector to deliver samples at hz. The rate is kept
+// across reconnects; connectors that cannot change their rate ignore it.
+func (f *FlightDataService) setUpdateRate(hz int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updateRate = hz
+	f.applyUpdateRateLocked()
+}
+
+// applyUpdateRateLocked forwards a non-default update rate to the connector.
+// Must be called with f.mu held.
+func (f *FlightDataService) applyUpdateRateLocked() {
+	rate := f.updateRateLocked()
+	if rate <= 0 {
+		return
+	}
+	if ra, ok := f.connector.(RateAdjustable); ok {
+		ra.SetUpdateRate(rate)
+	}
+}
+
 func (f *FlightDataService) IsConnected() bool {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	return f.simActive
 }
 
+// ConnectedAdapter returns the display label of the live connector, including
+// the remote address for networked simulators.
 func (f *FlightDataService) ConnectedAdapter() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.simActive && f.connector != nil {
+		return connectorLabel(f.connector)
+	}
+	return ""
+}
+
+// GetConnectorCapabilities describes the fields the connected simulator can
+// supply. It is empty when no simulator is connected.
+func (f *FlightDataService) GetConnectorCapabilities() ConnectorCapabilities {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.connector == nil {
+		return ConnectorCapabilities{Fields: []string{}}
+	}
+	return f.connector.Capabilities()
+}
+
+// GetUnavailableFields lists the FlightData fields the connected simulator
+// cannot supply. It is empty for connectors that fill everything.
+func (f *FlightDataService) GetUnavailableFields() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.connector == nil {
+		return []string{}
+	}
+	if missing := fieldsExcept(f.connector.Capabilities().Fields); missing != nil {
+		return missing
+	}
+	return []string{}
+}
+
+// GetRecorderStats reports how recorded samples keep up with the database.
+func (f *FlightDataService) GetRecorderStats() RecorderStats {
+	if f.writer == nil {
+		return RecorderStats{}
+	}
+	return f.writer.getStats()
+}
+
+// GetConnectorStats reports the sample rate and latency of the connected
+// simulator over the last few seconds.
+func (f *FlightDataService) GetConnectorStats() ConnectorStats {
+	f.mu.Lock()
+	connector := f.connector
+	f.mu.Unlock()
+
+	stats := f.telemetry.stats(time.Now())
+	stats.Rejected, stats.RejectedBy = f.filter.rejections()
+	if connector != nil {
+		stats.Adapter = connectorLabel(connector)
+		_, stats.Push = connector.(SamplePusher)
+	}
+	return stats
+}
+
+// connectedSimulator returns the plain adapter name for position reports,
+// without addresses from the pilot's network.
+func (f *FlightDataService) connectedSimulator() string {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	if f.simActive && f.connector != nil {
@@ -194,6 +567,78 @@ func (f *FlightDataService) ConnectedAdapter() string {
 	return ""
 }
 
+// replayAdapter returns the active connector if it is a replay or a
+// synthetic flight.
+func (f *FlightDataService) replayAdapter() (playbackController, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.connector.(playbackController)
+	if !ok {
+		return nil, fmt.Errorf("no replay running")
+	}
+	return r, nil
+}
+
+// ReplayPause freezes replay playback.
+func (f *FlightDataService) ReplayPause() error {
+	r, err := f.replayAdapter()
+	if err != nil {
+		return err
+	}
+	r.Pause()
+	return nil
+}
+
+// ReplayResume continues replay playback.
+func (f *FlightDataService) ReplayResume() error {
+	r, err := f.replayAdapter()
+	if err != nil {
+		return err
+	}
+	r.Resume()
+	return nil
+}
+
+// ReplaySeek moves replay playback to the given number of seconds from the start.
+func (f *FlightDataService) ReplaySeek(seconds float64) error {
+	r, err := f.replayAdapter()
+	if err != nil {
+		return err
+	}
+	r.Seek(time.Duration(seconds * float64(time.Second)))
+	return nil
+}
+
+// ReplaySetSpeed changes the replay speed multiplier (1 = real time).
+func (f *FlightDataService) ReplaySetSpeed(speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("speed must be positive")
+	}
+	r, err := f.replayAdapter()
+	if err != nil {
+		return err
+	}
+	r.SetSpeed(speed)
+	return nil
+}
+
+// GetReplayStatus returns the replay playback position.
+func (f *FlightDataService) GetReplayStatus() (*ReplayStatus, error) {
+	r, err := f.replayAdapter()
+	if err != nil {
+		return nil, err
+	}
+	status := r.Status()
+	return &status, nil
+}
+
+// GetFlightPhase returns the flight phase derived from the live data stream.
+func (f *FlightDataService) GetFlightPhase() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return string(f.phase)
+}
+
 func (f *FlightDataService) StartRecording() error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -204,10 +649,30 @@ func (f *FlightDataService) StartRecording() error {
 	if f.recording {
 		return fmt.Errorf("already recording")
 	}
+	if f.sessions == nil {
+		return fmt.Errorf("no database")
+	}
 
+	now := time.Now()
+	id, err := f.sessions.create(f.flight, now)
+	if err != nil {
+		return err
+	}
 	f.recording = true
-	f.startTime = time.Now()
+	f.startTime = now
 	f.dataCount = 0
+	f.session = id
+	f.sessionStats = sessionStats{}
+
+	// Recording has a subscription of its own, so it keeps its rate whatever
+	// the UI and the flight services ask for.
+	f.recordRate = recordingRate(f.currentSettings())
+	f.recordSub = f.subscribeTelemetry(recorderSampleBuffer, time.Second/time.Duration(f.recordRate))
+	f.recordDone = make(chan struct{})
+	go f.recordLoop(f.recordSub, f.recordDone)
+	if f.recordRate > 1 {
+		f.applyUpdateRateLocked()
+	}
 
 	if f.app != nil {
 		f.app.Event.Emit("recording-state", true)
@@ -215,15 +680,36 @@ func (f *FlightDataService) StartRecording() error {
 	return nil
 }
 
+// StopRecording ends the recording session once its queued samples are
+// written.
 func (f *FlightDataService) StopRecording() {
 	f.mu.Lock()
-	defer f.mu.Unlock()
-
-	if !f.recording {
+	sub, done := f.recordSub, f.recordDone
+	if !f.recording || sub == nil {
+		f.mu.Unlock()
 		return
 	}
+	f.recordSub = nil
+	f.mu.Unlock()
+
+	// recordLoop records what the subscription still holds before it returns.
+	sub.close()
+	<-done
 
+	f.mu.Lock()
 	f.recording = false
+	session, stats := f.session, f.sessionStats
+	f.session = 0
+	if f.recordRate > 1 {
+		f.updateRate = max(f.updateRate, 1)
+		f.applyUpdateRateLocked()
+	}
+	f.mu.Unlock()
+
+	f.writer.flush()
+	if err := f.sessions.finish(session, &stats, time.Now()); err != nil {
+		slog.Error("failed to finish recording session", "error", err)
+	}
 
 	if f.app != nil {
 		f.app.Event.Emit("recording-state", false)
@@ -249,88 +735,150 @@ func (f *FlightDataService) GetRecordingInfo() map[string]interface{} {
 		"recording": f.recording,
 		"duration":  duration,
 		"dataCount": f.dataCount,
+		"session":   f.session,
 	}
 }
 
-func (f *FlightDataService) ExportCSV(filePath string) error {
-	rows, err := f.db.Query(`SELECT timestamp, data FROM flight_data ORDER BY id`)
+// setFlight labels the recordings with the active flight, or with none when
+// flight is the zero value. A session being recorded takes the flight too.
+func (f *FlightDataService) setFlight(flight sessionFlight) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flight = flight
+	if f.recording && flight.flightID != "" {
+		if err := f.sessions.label(f.session, flight); err != nil {
+			slog.Warn("failed to label recording session", "error", err)
+		}
+	}
+}
+
+// ListSessions returns the recording sessions, newest first. The summary of
+// the session being recorded is as of now.
+func (f *FlightDataService) ListSessions() ([]RecordingSession, error) {
+	if f.sessions == nil {
+		return []RecordingSession{}, nil
+	}
+	sessions, err := f.sessions.list()
 	if err != nil {
-		return fmt.Errorf("query data: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range sessions {
+		if f.recording && sessions[i].ID == f.session {
+			f.sessionStats.apply(&sessions[i])
+			sessions[i].Duration = time.Since(f.startTime).Seconds()
+		}
+	}
+	return sessions, nil
+}
 
-	file, err := os.Create(filePath)
+// GetSession returns a recording session with its track.
+func (f *FlightDataService) GetSession(id int64) (RecordingSession, error) {
+	if f.sessions == nil {
+		return RecordingSession{}, fmt.Errorf("no database")
+	}
+	session, err := f.sessions.get(id)
 	if err != nil {
-		return fmt.Errorf("create file: %w", err)
-	}
-	defer file.Close()
-
-	w := csv.NewWriter(file)
-	defer w.Flush()
-
-	w.Write([]string{
-		"timestamp",
-		"latitude", "longitude", "altitude", "altitudeAGL",
-		"pitch", "roll", "headingTrue", "headingMag", "vs", "ias", "tas", "gs",
-		"eng1Running", "eng1N1", "eng1N2", "eng1Throttle",
-		"eng2Running", "eng2N1", "eng2N2", "eng2Throttle",
-		"onGround", "stallWarning", "overspeedWarning",
-		"com1", "com2", "nav1", "nav2", "xpdrCode",
-		"apMaster", "apHeading", "apAltitude", "apVS", "apSpeed",
-		"altimeterInHg",
-		"beacon", "strobe", "landing",
-		"elevator", "aileron", "rudder", "flaps", "spoilers", "gearDown",
-	})
+		return session, err
+	}
+	f.mu.Lock()
+	if f.recording && id == f.session {
+		f.sessionStats.apply(&session)
+		session.Duration = time.Since(f.startTime).Seconds()
+	}
+	f.mu.Unlock()
+	session.Track, err = f.sessions.track(id, session.Samples)
+	return session, err
+}
 
-	ff := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
-	fb := func(v bool) string {
-		if v {
-			return "1"
-		}
-		return "0"
+// ExportSession writes the samples of a recording session to a CSV file.
+// The session is kept.
+func (f *FlightDataService) ExportSession(id int64, filePath string) error {
+	if f.sessions == nil {
+		return fmt.Errorf("no database")
 	}
+	return f.sessions.export(id, filePath)
+}
 
-	for rows.Next() {
-		var ts, dataJSON string
-		if err := rows.Scan(&ts, &dataJSON); err != nil {
-			return fmt.Errorf("scan row: %w", err)
-		}
+// DeleteSession deletes a recording session and its samples.
+func (f *FlightDataService) DeleteSession(id int64) error {
+	if f.sessions == nil {
+		return fmt.Errorf("no database")
+	}
+	f.mu.Lock()
+	recording := f.recording && id == f.session
+	f.mu.Unlock()
+	if recording {
+		return fmt.Errorf("stop recording before deleting the session")
+	}
+	return f.sessions.remove(id)
+}
 
-		var d FlightData
-		if err := json.Unmarshal([]byte(dataJSON), &d); err != nil {
-			return fmt.Errorf("unmarshal row: %w", err)
-		}
+// InstallFlightGearProtocol copies the FlightGear output protocol into the
+// FlightGear data folder (FG_ROOT) and returns the file written.
+func (f *FlightDataService) InstallFlightGearProtocol(fgRoot string) (string, error) {
+	return installFlightGearProtocol(fgRoot)
+}
 
-		w.Write([]string{
-			ts,
-			ff(d.Position.Latitude), ff(d.Position.Longitude), ff(d.Position.Altitude), ff(d.Position.AltitudeAGL),
-			ff(d.Attitude.Pitch), ff(d.Attitude.Roll), ff(d.Attitude.HeadingTrue), ff(d.Attitude.HeadingMag),
-			ff(d.Attitude.VS), ff(d.Attitude.IAS), ff(d.Attitude.TAS), ff(d.Attitude.GS),
-			fb(d.Engines[0].Running), ff(d.Engines[0].N1), ff(d.Engines[0].N2), ff(d.Engines[0].ThrottlePos),
-			fb(d.Engines[1].Running), ff(d.Engines[1].N1), ff(d.Engines[1].N2), ff(d.Engines[1].ThrottlePos),
-			fb(d.Sensors.OnGround), fb(d.Sensors.StallWarning), fb(d.Sensors.OverspeedWarning),
-			ff(d.Radios.Com1), ff(d.Radios.Com2), ff(d.Radios.Nav1), ff(d.Radios.Nav2), ff(d.Radios.XpdrCode),
-			fb(d.Autopilot.Master), ff(d.Autopilot.Heading), ff(d.Autopilot.Altitude), ff(d.Autopilot.VS), ff(d.Autopilot.Speed),
-			ff(d.Altimeter),
-			fb(d.Lights.Beacon), fb(d.Lights.Strobe), fb(d.Lights.Landing),
-			ff(d.Controls.Elevator), ff(d.Controls.Aileron), ff(d.Controls.Rudder),
-			ff(d.Controls.Flaps), ff(d.Controls.Spoilers), fb(d.Controls.GearDown),
-		})
-	}
-
-	// Purge DB after export
-	_, err = f.db.Exec(`DELETE FROM flight_data`)
+// ExportCSV writes the latest recording session to a CSV file. The session
+// is kept.
+func (f *FlightDataService) ExportCSV(filePath string) error {
+	if f.sessions == nil {
+		return fmt.Errorf("no database")
+	}
+	id, err := f.sessions.latest()
 	if err != nil {
-		return fmt.Errorf("purge db: %w", err)
+		return err
+	}
+	if id == 0 {
+		return fmt.Errorf("nothing recorded yet")
 	}
+	return f.sessions.export(id, filePath)
+}
 
-	f.mu.Lock()
-	f.dataCount = 0
-	f.mu.Unlock()
+// saveLandingReport persists a touchdown so it can be reviewed later.
+func (f *FlightDataService) saveLandingReport(r *LandingReport) error {
+	if f.db == nil {
+		return fmt.Errorf("no database")
+	}
 
+	jsonBytes, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal landing report: %w", err)
+	}
+
+	_, err = f.db.Exec(`INSERT INTO landings (data) VALUES (?)`, string(jsonBytes))
+	if err != nil {
+		return fmt.Errorf("insert landing report: %w", err)
+	}
 	return nil
 }
 
+// GetLandingReports returns every recorded touchdown, newest first.
+func (f *FlightDataService) GetLandingReports() ([]LandingReport, error) {
+	rows, err := f.db.Query(`SELECT data FROM landings ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("query landings: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []LandingReport{}
+	for rows.Next() {
+		var dataJSON string
+		if err := rows.Scan(&dataJSON); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		var r LandingReport
+		if err := json.Unmarshal([]byte(dataJSON), &r); err != nil {
+			return nil, fmt.Errorf("unmarshal row: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
 // startDataStreamLocked starts the continuous data stream goroutine.
 // Must be called with f.mu held.
 func (f *FlightDataService) startDataStreamLocked() {
@@ -352,21 +900,36 @@ func (f *FlightDataService) stopDataStreamLocked() {
 	close(f.streamStopCh)
 }
 
-// dataStreamLoop is the single goroutine that polls the simulator.
-// It always emits flight-data events, and writes to DB when recording.
+// dataStreamLoop is the single goroutine that feeds the telemetry broadcaster
+// and watches the connection. Push connectors publish on their own; the
+// others are polled here at the update rate. It also consumes the stream
+// once a second: it emits flight-data events and tracks the flight phase.
 // On stale connections it automatically reconnects with exponential backoff.
 func (f *FlightDataService) dataStreamLoop() {
-	ticker := time.NewTicker(time.Second)
+	stopCh := f.streamStopCh
+	interval := f.pollInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	samples := f.subscribeTelemetry(streamSampleBuffer, time.Second)
+	defer samples.close()
+
+	var phases PhaseDetector
+
 	for {
 		select {
-		case <-f.streamStopCh:
+		case <-stopCh:
 			return
+		case sample := <-samples.C:
+			f.handleSample(sample, &phases)
 		case <-ticker.C:
+			if d := f.pollInterval(); d != interval {
+				interval = d
+				ticker.Reset(interval)
+			}
+
 			f.mu.Lock()
 			connector := f.connector
-			recording := f.recording
 			wasActive := f.simActive
 			adapterName := f.adapterName
 			f.mu.Unlock()
@@ -375,8 +938,7 @@ func (f *FlightDataService) dataStreamLoop() {
 				continue
 			}
 
-			data, err := connector.GetFlightData()
-			if err != nil {
+			if err := f.feed(connector); err != nil {
 				if wasActive {
 					f.mu.Lock()
 					f.simActive = false
@@ -396,36 +958,11 @@ func (f *FlightDataService) dataStreamLoop() {
 				f.lastReconnectAt = time.Time{}
 				f.mu.Unlock()
 				if f.app != nil {
-					f.app.Event.Emit("connection-state", connector.Name())
+					f.app.Event.Emit("connection-state", connectorLabel(connector))
 				}
 				slog.Info("simulator data received", "adapter", connector.Name())
 			}
 
-			if f.app != nil {
-				f.app.Event.Emit("flight-data", data)
-			}
-
-			if recording {
-				jsonBytes, err := json.Marshal(data)
-				if err != nil {
-					slog.Error("failed to marshal flight data", "error", err)
-					continue
-				}
-
-				_, err = f.db.Exec(
-					`INSERT INTO flight_data (data) VALUES (?)`,
-					string(jsonBytes),
-				)
-				if err != nil {
-					slog.Error("failed to insert flight data", "error", err)
-					continue
-				}
-
-				f.mu.Lock()
-				f.dataCount++
-				f.mu.Unlock()
-			}
-
 			// Staleness check: if data was active but adapter hasn't received
 			// fresh data recently, attempt a reconnect.
 			if wasActive && !connector.LastReceived().IsZero() &&
@@ -473,7 +1010,75 @@ func (f *FlightDataService) dataStreamLoop() {
 	}
 }
 
-// GetFlightDataNow returns a one-shot read of the current flight data.
+// feed polls the connector and publishes the sample. Push connectors are
+// only read when their own samples have stopped arriving, which also yields
+// the reason, e.g. a lost connection.
+func (f *FlightDataService) feed(connector SimConnector) error {
+	if _, push := connector.(SamplePusher); push {
+		if s, ok := f.telemetry.latestSample(); ok && time.Since(s.at) <= sampleMaxAge {
+			return nil
+		}
+	}
+
+	data, err := connector.GetFlightData()
+	if err != nil {
+		return err
+	}
+	f.ingest(data)
+	return nil
+}
+
+// handleSample emits a sample to the UI and tracks the flight phase.
+func (f *FlightDataService) handleSample(sample telemetrySample, phases *PhaseDetector) {
+	now := time.Now()
+	f.telemetry.recordLatency(now, now.Sub(sample.at))
+
+	if f.app != nil {
+		f.app.Event.Emit("flight-data", sample.data)
+	}
+
+	if phase, changed := phases.Update(sample.data, sample.at); changed {
+		f.mu.Lock()
+		f.phase = phase
+		f.mu.Unlock()
+		if f.app != nil {
+			f.app.Event.Emit("flight-phase", string(phase))
+		}
+		slog.Info("flight phase changed", "phase", phase)
+	}
+}
+
+// recordLoop records the samples of sub, at the recording rate, until sub is
+// closed.
+func (f *FlightDataService) recordLoop(sub *telemetrySubscription, done chan struct{}) {
+	defer close(done)
+	for sample := range sub.C {
+		f.record(sample)
+	}
+}
+
+// record queues a sample for the writer.
+func (f *FlightDataService) record(sample telemetrySample) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.recording {
+		return
+	}
+
+	// The writer stores the sample on its own goroutine; a full queue drops
+	// it rather than stalling the stream.
+	data := *sample.data
+	if !f.writer.write(recordedSample{session: f.session, at: sample.at, data: &data}) {
+		slog.Debug("recorder queue full, sample dropped")
+		return
+	}
+	f.dataCount++
+	f.sessionStats.add(&data)
+}
+
+// GetFlightDataNow returns the latest sample from the telemetry stream. The
+// connector is only read when the stream has nothing current, e.g. right
+// after connecting.
 func (f *FlightDataService) GetFlightDataNow() (*FlightData, error) {
 	f.mu.Lock()
 	connector := f.connector
@@ -483,5 +1088,17 @@ func (f *FlightDataService) GetFlightDataNow() (*FlightData, error) {
 		return nil, fmt.Errorf("no simulator connected")
 	}
 
-	return connector.GetFlightData()
+	if s, ok := f.telemetry.latestSample(); ok && time.Since(s.at) <= sampleMaxAge {
+		data := *s.data
+		return &data, nil
+	}
+	data, err := connector.GetFlightData()
+	if err != nil {
+		return nil, err
+	}
+	clean, reason := sanitizeFlightData(data)
+	if reason != "" {
+		return nil, fmt.Errorf("implausible sample from simulator (%s)", reason)
+	}
+	return clean, nil
 }
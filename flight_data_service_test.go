@@ -225,3 +225,37 @@ func TestReconnectSimUnknownAdapter(t *testing.T) {
 		assert.Contains(t, err.Error(), "FakeSimulator")
 	})
 }
+
+func TestDataStreamLoopTracksFlightPhase(t *testing.T) {
+	mock := &ReconnectableMockConnector{
+		data: sampleFlightData(),
+		name: "TestSim",
+	}
+	fds := &FlightDataService{
+		connector: mock,
+		streaming: true,
+	}
+	fds.streamStopCh = make(chan struct{})
+	go fds.dataStreamLoop()
+	defer close(fds.streamStopCh)
+
+	require.Eventually(t, func() bool {
+		return fds.GetFlightPhase() == string(PhaseBoarding)
+	}, 5*time.Second, 100*time.Millisecond, "parked aircraft should be classified as boarding")
+}
+
+func TestReconnectSimResumesPlayback(t *testing.T) {
+	old := NewSyntheticAdapter("", 1)
+	require.NoError(t, old.Connect())
+	old.Seek(40 * time.Minute)
+	old.SetSpeed(10)
+
+	fds := &FlightDataService{connector: old, adapterName: old.Name()}
+	require.NoError(t, fds.reconnectSim())
+
+	status, err := fds.GetReplayStatus()
+	require.NoError(t, err)
+	assert.NotSame(t, old, fds.connector, "a fresh adapter is created")
+	assert.InDelta(t, (40 * time.Minute).Seconds(), status.Position, 5, "the flight continues where it was")
+	assert.Equal(t, 10.0, status.Speed)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// FlightPhase identifies the current stage of a flight from gate to gate.
+type FlightPhase string
+
+const (
+	PhaseUnknown        FlightPhase = ""
+	PhaseBoarding       FlightPhase = "boarding"
+	PhasePushback       FlightPhase = "pushback"
+	PhaseTaxiOut        FlightPhase = "taxi_out"
+	PhaseTakeoffRoll    FlightPhase = "takeoff_roll"
+	PhaseInitialClimb   FlightPhase = "initial_climb"
+	PhaseClimb          FlightPhase = "climb"
+	PhaseCruise         FlightPhase = "cruise"
+	PhaseDescent        FlightPhase = "descent"
+	PhaseApproach       FlightPhase = "approach"
+	PhaseLandingRollout FlightPhase = "landing_rollout"
+	PhaseTaxiIn         FlightPhase = "taxi_in"
+	PhaseOnBlock        FlightPhase = "on_block"
+)
+
+const (
+	phaseConfirmTime   = 3 * time.Second // a candidate phase must hold this long before it is committed
+	phaseMovingGS      = 1.0             // kts, above this the aircraft is considered moving
+	phaseTaxiGS        = 5.0             // kts, engines running and faster than a pushback
+	phaseTakeoffGS     = 40.0            // kts, on the runway accelerating for takeoff
+	phaseRolloutExitGS = 30.0            // kts, landing rollout (or rejected takeoff) is over
+	phaseClimbVS       = 500.0           // fpm, enter climb
+	phaseDescentVS     = -500.0          // fpm, enter descent
+	phaseLevelVS       = 300.0           // fpm, |VS| below this counts as level flight
+	phaseInitialAGL    = 1500.0          // ft, top of the initial climb segment
+	phaseApproachAGL   = 3000.0          // ft, below this with gear or flaps out is an approach
+	phaseDoorOpenRatio = 0.5             // door considered open at or above this ratio
+)
+
+// PhaseDetector is a state machine that derives the FlightPhase from a stream
+// of FlightData samples. Transitions follow the gate-to-gate sequence, enter and
+// exit thresholds differ, and a new phase must be observed continuously for
+// phaseConfirmTime before it is committed, so noisy telemetry does not make
+// the phase flap.
+type PhaseDetector struct {
+	phase        FlightPhase
+	pending      FlightPhase
+	pendingSince time.Time
+}
+
+// Phase returns the last committed phase.
+func (p *PhaseDetector) Phase() FlightPhase {
+	return p.phase
+}
+
+// Update feeds a sample observed at now and returns the committed phase and
+// whether it changed with this sample.
+func (p *PhaseDetector) Update(fd *FlightData, now time.Time) (FlightPhase, bool) {
+	if fd == nil {
+		return p.phase, false
+	}
+
+	// First sample: classify directly, there is no history to debounce against.
+	if p.phase == PhaseUnknown {
+		p.phase = classifyPhase(fd)
+		p.pending = PhaseUnknown
+		return p.phase, true
+	}
+
+	next := nextPhase(p.phase, fd)
+	if next == p.phase {
+		p.pending = PhaseUnknown
+		return p.phase, false
+	}
+
+	if next != p.pending {
+		p.pending = next
+		p.pendingSince = now
+		return p.phase, false
+	}
+
+	if now.Sub(p.pendingSince) < phaseConfirmTime {
+		return p.phase, false
+	}
+
+	p.phase = next
+	p.pending = PhaseUnknown
+	return p.phase, true
+}
+
+// classifyPhase makes a best guess for a sample without any history, e.g.
+// when the simulator is connected in the middle of a flight.
+func classifyPhase(fd *FlightData) FlightPhase {
+	if fd.Sensors.OnGround {
+		switch {
+		case fd.Attitude.GS >= phaseTakeoffGS:
+			return PhaseTakeoffRoll
+		case fd.Attitude.GS >= phaseMovingGS && anyEngineRunning(fd):
+			return PhaseTaxiOut
+		case fd.Attitude.GS >= phaseMovingGS:
+			return PhasePushback
+		default:
+			return PhaseBoarding
+		}
+	}
+
+	switch {
+	case fd.Attitude.VS >= phaseClimbVS && fd.Position.AltitudeAGL < phaseInitialAGL:
+		return PhaseInitialClimb
+	case fd.Attitude.VS >= phaseClimbVS:
+		return PhaseClimb
+	case fd.Position.AltitudeAGL < phaseApproachAGL && approachConfigured(fd):
+		return PhaseApproach
+	case fd.Attitude.VS <= phaseDescentVS:
+		return PhaseDescent
+	default:
+		return PhaseCruise
+	}
+}
+
+// nextPhase returns the phase the state machine would move to from current
+// given the sample, or current if no transition applies.
+func nextPhase(current FlightPhase, fd *FlightData) FlightPhase {
+	onGround := fd.Sensors.OnGround
+	gs := fd.Attitude.GS
+	vs := fd.Attitude.VS
+	agl := fd.Position.AltitudeAGL
+	engines := anyEngineRunning(fd)
+
+	switch current {
+	case PhaseBoarding:
+		if !onGround {
+			return classifyPhase(fd)
+		}
+		if gs >= phaseTaxiGS && engines {
+			return PhaseTaxiOut
+		}
+		if gs >= phaseMovingGS {
+			return PhasePushback
+		}
+
+	case PhasePushback:
+		if !onGround {
+			return classifyPhase(fd)
+		}
+		if gs >= phaseTaxiGS && engines {
+			return PhaseTaxiOut
+		}
+
+	case PhaseTaxiOut:
+		if !onGround {
+			return PhaseInitialClimb
+		}
+		if gs >= phaseTakeoffGS {
+			return PhaseTakeoffRoll
+		}
+
+	case PhaseTakeoffRoll:
+		if !onGround {
+			return PhaseInitialClimb
+		}
+		if gs < phaseRolloutExitGS {
+			return PhaseTaxiOut // rejected takeoff
+		}
+
+	case PhaseInitialClimb:
+		if onGround {
+			return PhaseLandingRollout
+		}
+		if agl >= phaseInitialAGL {
+			return PhaseClimb
+		}
+
+	case PhaseClimb:
+		if onGround {
+			return PhaseLandingRollout
+		}
+		if vs <= phaseDescentVS {
+			return PhaseDescent
+		}
+		if math.Abs(vs) < phaseLevelVS {
+			return PhaseCruise
+		}
+
+	case PhaseCruise:
+		if onGround {
+			return PhaseLandingRollout
+		}
+		if vs >= phaseClimbVS {
+			return PhaseClimb
+		}
+		if vs <= phaseDescentVS {
+			return PhaseDescent
+		}
+
+	case PhaseDescent:
+		if onGround {
+			return PhaseLandingRollout
+		}
+		if agl < phaseApproachAGL && approachConfigured(fd) {
+			return PhaseApproach
+		}
+		if vs >= phaseClimbVS {
+			return PhaseClimb
+		}
+		if math.Abs(vs) < phaseLevelVS {
+			return PhaseCruise
+		}
+
+	case PhaseApproach:
+		if onGround {
+			return PhaseLandingRollout
+		}
+		if vs >= phaseClimbVS {
+			return PhaseInitialClimb // go-around
+		}
+
+	case PhaseLandingRollout:
+		if !onGround {
+			return PhaseInitialClimb // touch-and-go
+		}
+		if gs < phaseRolloutExitGS {
+			return PhaseTaxiIn
+		}
+
+	case PhaseTaxiIn:
+		if !onGround {
+			return PhaseInitialClimb
+		}
+		if gs < phaseMovingGS && (!engines || anyDoorOpen(fd)) {
+			return PhaseOnBlock
+		}
+
+	case PhaseOnBlock:
+		if !onGround {
+			return classifyPhase(fd)
+		}
+		if gs >= phaseMovingGS {
+			return PhaseTaxiIn
+		}
+	}
+
+	return current
+}
+
+func anyEngineRunning(fd *FlightData) bool {
+	for _, e := range fd.Engines {
+		if e.Running {
+			return true
+		}
+	}
+	return false
+}
+
+func anyDoorOpen(fd *FlightData) bool {
+	for _, d := range fd.Doors {
+		if d.OpenRatio >= phaseDoorOpenRatio {
+			return true
+		}
+	}
+	return false
+}
+
+func approachConfigured(fd *FlightData) bool {
+	return fd.Controls.GearDown || fd.Controls.Flaps > 0
+}
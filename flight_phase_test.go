@@ -0,0 +1,145 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// phaseSample builds a FlightData with just the fields the phase detector reads.
+func phaseSample(onGround bool, gs, vs, agl float64, engines bool) *FlightData {
+	fd := &FlightData{}
+	fd.Sensors.OnGround = onGround
+	fd.Attitude.GS = gs
+	fd.Attitude.VS = vs
+	fd.Position.AltitudeAGL = agl
+	fd.Engines[0] = EngineData{Exists: true, Running: engines}
+	return fd
+}
+
+// feedPhase sends the same sample once a second for n seconds.
+func feedPhase(p *PhaseDetector, fd *FlightData, start time.Time, n int) time.Time {
+	t := start
+	for range n {
+		p.Update(fd, t)
+		t = t.Add(time.Second)
+	}
+	return t
+}
+
+func TestClassifyPhase(t *testing.T) {
+	approach := phaseSample(false, 140, -700, 1200, true)
+	approach.Controls.GearDown = true
+
+	tests := []struct {
+		name string
+		fd   *FlightData
+		want FlightPhase
+	}{
+		{"parked", phaseSample(true, 0, 0, 0, false), PhaseBoarding},
+		{"pushed without engines", phaseSample(true, 2, 0, 0, false), PhasePushback},
+		{"taxiing", phaseSample(true, 15, 0, 0, true), PhaseTaxiOut},
+		{"takeoff roll", phaseSample(true, 90, 0, 0, true), PhaseTakeoffRoll},
+		{"initial climb", phaseSample(false, 160, 2000, 400, true), PhaseInitialClimb},
+		{"climb", phaseSample(false, 300, 2000, 8000, true), PhaseClimb},
+		{"cruise", phaseSample(false, 450, 0, 35000, true), PhaseCruise},
+		{"descent", phaseSample(false, 400, -1800, 20000, true), PhaseDescent},
+		{"approach", approach, PhaseApproach},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyPhase(tt.fd))
+		})
+	}
+}
+
+func TestPhaseDetectorFullFlight(t *testing.T) {
+	var p PhaseDetector
+	now := time.Now()
+
+	approach := phaseSample(false, 140, -700, 1500, true)
+	approach.Controls.GearDown = true
+	approach.Controls.Flaps = 50
+
+	onBlock := phaseSample(true, 0, 0, 0, false)
+	onBlock.Doors[0].OpenRatio = 1
+
+	steps := []struct {
+		fd   *FlightData
+		want FlightPhase
+	}{
+		{phaseSample(true, 0, 0, 0, false), PhaseBoarding},
+		{phaseSample(true, 2, 0, 0, false), PhasePushback},
+		{phaseSample(true, 15, 0, 0, true), PhaseTaxiOut},
+		{phaseSample(true, 100, 0, 0, true), PhaseTakeoffRoll},
+		{phaseSample(false, 160, 2000, 300, true), PhaseInitialClimb},
+		{phaseSample(false, 250, 2500, 5000, true), PhaseClimb},
+		{phaseSample(false, 450, 0, 35000, true), PhaseCruise},
+		{phaseSample(false, 400, -2000, 20000, true), PhaseDescent},
+		{approach, PhaseApproach},
+		{phaseSample(true, 120, 0, 0, true), PhaseLandingRollout},
+		{phaseSample(true, 15, 0, 0, true), PhaseTaxiIn},
+		{onBlock, PhaseOnBlock},
+	}
+
+	for _, step := range steps {
+		now = feedPhase(&p, step.fd, now, 5)
+		assert.Equal(t, step.want, p.Phase())
+	}
+}
+
+func TestPhaseDetectorHysteresis(t *testing.T) {
+	var p PhaseDetector
+	now := time.Now()
+
+	cruise := phaseSample(false, 450, 0, 35000, true)
+	now = feedPhase(&p, cruise, now, 1)
+	assert.Equal(t, PhaseCruise, p.Phase())
+
+	t.Run("short excursion does not change phase", func(t *testing.T) {
+		bump := phaseSample(false, 450, -800, 35000, true)
+		now = feedPhase(&p, bump, now, 2)
+		now = feedPhase(&p, cruise, now, 1)
+		assert.Equal(t, PhaseCruise, p.Phase())
+	})
+
+	t.Run("sustained change is committed after confirm time", func(t *testing.T) {
+		descent := phaseSample(false, 450, -1500, 34000, true)
+		_, changed := p.Update(descent, now)
+		assert.False(t, changed)
+
+		_, changed = p.Update(descent, now.Add(phaseConfirmTime-time.Millisecond))
+		assert.False(t, changed)
+
+		phase, changed := p.Update(descent, now.Add(phaseConfirmTime))
+		assert.True(t, changed)
+		assert.Equal(t, PhaseDescent, phase)
+	})
+
+	t.Run("VS inside the level band keeps descent", func(t *testing.T) {
+		shallow := phaseSample(false, 450, -400, 30000, true)
+		now = feedPhase(&p, shallow, now.Add(phaseConfirmTime), 10)
+		assert.Equal(t, PhaseDescent, p.Phase())
+	})
+}
+
+func TestPhaseDetectorRejectedTakeoff(t *testing.T) {
+	var p PhaseDetector
+	now := time.Now()
+
+	now = feedPhase(&p, phaseSample(true, 15, 0, 0, true), now, 1)
+	now = feedPhase(&p, phaseSample(true, 80, 0, 0, true), now, 5)
+	assert.Equal(t, PhaseTakeoffRoll, p.Phase())
+
+	feedPhase(&p, phaseSample(true, 10, 0, 0, true), now, 5)
+	assert.Equal(t, PhaseTaxiOut, p.Phase())
+}
+
+func TestPhaseDetectorNilSample(t *testing.T) {
+	var p PhaseDetector
+	phase, changed := p.Update(nil, time.Now())
+	assert.Equal(t, PhaseUnknown, phase)
+	assert.False(t, changed)
+}
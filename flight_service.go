@@ -2,34 +2,98 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 type FlightService struct {
 	auth       *AuthService
 	flightData *FlightDataService
+	settings   *SettingsService
 	app        *application.App
+	outbox     *positionOutbox    // nil without a database
+	store      *activeFlightStore // nil without a database
+	actions    *pendingActions    // nil without a database
+	batching   positionBatching
+	events     eventQueue
 
 	mu        sync.Mutex
-	state     string // "idle" or "active"
+	state     string // "idle", "active" or "pending-sync"
+	flightID  string // UUID of the active flight, sent with its requests and keying its queued reports
+	sequence  int64  // number of the last position report of the active flight
+	starting  pendingStart
 	callsign  string
 	departure string
 	arrival   string
 	startTime time.Time
 	stopCh    chan struct{}
+	oooi      oooiTracker
+	landing   *LandingReport
+	integrity integrityMonitor
+	last      *lastSample  // latest sample saved with the active flight
+	resumable *savedFlight // flight a previous run left active
 }
 
-func NewFlightService(auth *AuthService, fd *FlightDataService) *FlightService {
-	return &FlightService{
+func NewFlightService(auth *AuthService, fd *FlightDataService, settings *SettingsService) *FlightService {
+	f := &FlightService{
 		auth:       auth,
 		flightData: fd,
+		settings:   settings,
 		state:      "idle",
 	}
+	if fd != nil {
+		f.outbox = newPositionOutbox(fd.db)
+		f.store = newActiveFlightStore(fd.db)
+		f.actions = newPendingActions(fd.db)
+	}
+	return f
+}
+
+// Flight states besides "idle" and "active": a flight that ended while the
+// server was unreachable is "pending-sync" until the server has its finish or
+// stop. A new flight can be started meanwhile; the older finishes and stops
+// are still sent, and listed in pending-actions.
+const flightPendingSync = "pending-sync"
+
+// Start sends the position reports and flight actions a previous run left
+// queued and offers to resume the flight it left active.
+func (f *FlightService) Start() {
+	if f.outbox != nil {
+		go f.outboxLoop()
+	}
+	if f.actions != nil {
+		if actions, err := f.actions.list(); err == nil && len(actions) > 0 {
+			slog.Info("flight actions still to be sent", "count", len(actions))
+			f.mu.Lock()
+			f.state = flightPendingSync
+			f.mu.Unlock()
+		}
+		go f.actionLoop()
+	}
+	if f.store != nil {
+		saved, err := f.store.load()
+		if err != nil {
+			slog.Warn("failed to load interrupted flight", "error", err)
+		}
+		if saved != nil {
+			slog.Info("interrupted flight can be resumed", "callsign", saved.Callsign, "flightId", saved.FlightID)
+			f.mu.Lock()
+			f.resumable = saved
+			f.mu.Unlock()
+			if f.app != nil {
+				f.app.Event.Emit("flight-resumable", f.GetResumableFlight())
+			}
+		}
+	}
 }
 
 func (f *FlightService) setApp(app *application.App) {
@@ -42,6 +106,13 @@ func (f *FlightService) GetFlightState() string {
 	return f.state
 }
 
+// GetOOOITimes returns the Out/Off/On/In times captured for the active flight.
+func (f *FlightService) GetOOOITimes() OOOITimes {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.oooi.times
+}
+
 func (f *FlightService) GetBooking() (map[string]interface{}, error) {
 	body, _, err := f.auth.doRequest("GET", "/api/acars/booking", nil)
 	if err != nil {
@@ -74,15 +145,33 @@ func (f *FlightService) StartFlight(callsign, departure, arrival string) error {
 	if f.state == "active" {
 		return fmt.Errorf("flight already active")
 	}
+	if f.resumable != nil {
+		return fmt.Errorf("resume or discard the interrupted flight %s first", f.resumable.Callsign)
+	}
+
+	// A start the server may have got is sent again with the same flight ID,
+	// so it is not taken for a second flight.
+	start := pendingStart{callsign: callsign, departure: departure, arrival: arrival}
+	if f.starting.flightID != "" && f.starting.same(start) {
+		start.flightID = f.starting.flightID
+	} else {
+		start.flightID = uuid.NewString()
+	}
 
 	payload := map[string]string{
+		"flightId":  start.flightID,
 		"callsign":  callsign,
 		"departure": departure,
 		"arrival":   arrival,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
 
-	_, status, err := f.auth.doRequest("POST", "/api/acars/start", payload)
+	_, status, err := f.auth.doIdempotentRequest("POST", "/api/acars/start", payload, idempotencyKey(start.flightID, "start"))
+	if err != nil || retryableStatus(status) {
+		f.starting = start
+	} else {
+		f.starting = pendingStart{}
+	}
 	if err != nil {
 		return fmt.Errorf("start flight: %w", err)
 	}
@@ -95,9 +184,21 @@ func (f *FlightService) StartFlight(callsign, departure, arrival string) error {
 	f.departure = departure
 	f.arrival = arrival
 	f.startTime = time.Now()
+	f.flightID = start.flightID
+	f.sequence = 0
+	if f.outbox != nil {
+		f.pruneOutbox(f.flightID)
+	}
+	f.oooi = oooiTracker{}
+	f.landing = nil
+	f.integrity = integrityMonitor{}
+	f.last = nil
 	f.stopCh = make(chan struct{})
+	f.persistFlight()
+	f.labelRecordings()
 
 	go f.positionLoop(f.stopCh)
+	go f.monitorLoop(f.stopCh)
 
 	slog.Info("flight started", "callsign", callsign, "dep", departure, "arr", arrival)
 
@@ -116,20 +217,41 @@ func (f *FlightService) StopFlight() error {
 	}
 
 	payload := map[string]string{
+		"flightId":  f.flightID,
 		"callsign":  f.callsign,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
 
-	_, _, err := f.doRequestWithRetry("POST", "/api/acars/stop", payload)
+	// The reports of a cancelled flight are of no use to the server.
+	if f.outbox != nil {
+		if n, err := f.outbox.abandon(f.flightID); err != nil {
+			slog.Warn("failed to drop queued position reports", "error", err)
+		} else if n > 0 {
+			slog.Info("dropped queued position reports of cancelled flight", "count", n)
+		}
+	}
+
+	err := f.sendAction(actionStop, f.flightID, payload)
+	if err != nil && !errors.Is(err, errActionRefused) && f.queueAction(actionStop, f.flightID, f.callsign, payload, err) {
+		f.endFlight(flightPendingSync)
+		slog.Info("flight stopped/cancelled, server not told yet", "error", err)
+		return nil
+	}
 	if err != nil {
-		slog.Warn("stop flight request failed after retries", "error", err)
+		slog.Warn("stop flight request failed", "error", err)
 	}
 
-	f.endFlight()
+	f.endFlight("idle")
 	slog.Info("flight stopped/cancelled")
 	return nil
 }
 
+// FinishFlight ends the flight and sends the server its OOOI times, landing
+// and integrity summary. The server scores the flight from its track, so the
+// finish is sent after the flight's queued position reports; while those or
+// the finish itself cannot reach the server, the finish is queued and the
+// flight is pending-sync. An error means the server refused the finish and
+// the flight is still active.
 func (f *FlightService) FinishFlight() error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -138,172 +260,819 @@ func (f *FlightService) FinishFlight() error {
 		return fmt.Errorf("no active flight")
 	}
 
-	payload := map[string]string{
+	f.integrity.finish(time.Now())
+	payload := map[string]interface{}{
+		"flightId":  f.flightID,
 		"callsign":  f.callsign,
 		"departure": f.departure,
 		"arrival":   f.arrival,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"oooi":      f.oooi.times,
+		"landing":   f.landing,
+		"integrity": f.integrity.summary(),
 	}
 
-	body, status, err := f.doRequestWithRetry("POST", "/api/acars/finish", payload)
-	if err != nil {
-		return fmt.Errorf("finish flight: %w", err)
+	err := f.sendAction(actionFinish, f.flightID, payload)
+	if errors.Is(err, errActionRefused) {
+		return fmt.Errorf("finish flight: %s", strings.TrimPrefix(err.Error(), errActionRefused.Error()+": "))
 	}
-	if status >= 400 {
-		var errResp map[string]interface{}
-		json.Unmarshal(body, &errResp)
-		if msg, ok := errResp["error"].(string); ok {
-			return fmt.Errorf("finish flight: %s", msg)
+	if err != nil {
+		if !f.queueAction(actionFinish, f.flightID, f.callsign, payload, err) {
+			return fmt.Errorf("finish flight: %w", err)
 		}
-		return fmt.Errorf("finish flight: server returned %d", status)
+		f.endFlight(flightPendingSync)
+		slog.Info("flight finished, server not told yet", "error", err)
+		return nil
 	}
 
-	f.endFlight()
+	f.endFlight("idle")
 	slog.Info("flight finished")
 	return nil
 }
 
-// endFlight stops the position loop and resets state. Must be called with mu held.
-func (f *FlightService) endFlight() {
+// endFlight stops the position loop and resets state to state, "idle" or
+// pending-sync. Must be called with mu held.
+func (f *FlightService) endFlight(state string) {
 	if f.stopCh != nil {
 		close(f.stopCh)
 		f.stopCh = nil
 	}
-	f.state = "idle"
+	// An earlier flight may still be waiting for the server.
+	if state == "idle" && f.actions != nil {
+		if actions, err := f.actions.list(); err == nil && len(actions) > 0 {
+			state = flightPendingSync
+		}
+	}
+	f.state = state
+	f.flightID = ""
+	f.sequence = 0
 	f.callsign = ""
 	f.departure = ""
 	f.arrival = ""
+	f.oooi = oooiTracker{}
+	f.landing = nil
+	f.integrity = integrityMonitor{}
+	f.last = nil
+	f.labelRecordings()
+	if f.store != nil {
+		if err := f.store.clear(); err != nil {
+			slog.Warn("failed to clear saved flight", "error", err)
+		}
+	}
 
 	if f.app != nil {
-		f.app.Event.Emit("flight-state", "idle")
+		f.app.Event.Emit("flight-state", state)
 	}
 }
 
+// labelRecordings labels the recording sessions with the active flight, or
+// with none. Must be called with mu held.
+func (f *FlightService) labelRecordings() {
+	if f.flightData != nil {
+		f.flightData.setFlight(sessionFlight{f.flightID, f.callsign, f.departure, f.arrival})
+	}
+}
+
+// snapshot returns the active flight as it is saved. Must be called with mu
+// held.
+func (f *FlightService) snapshot() *savedFlight {
+	return &savedFlight{
+		FlightID:  f.flightID,
+		Callsign:  f.callsign,
+		Departure: f.departure,
+		Arrival:   f.arrival,
+		StartTime: f.startTime,
+		Sequence:  f.sequence,
+		OOOI:      f.oooi.times,
+		Landing:   f.landing,
+		Events:    slices.Clone(f.integrity.events),
+		Last:      f.last,
+	}
+}
+
+// persistFlight saves the active flight so it survives a restart. Must be
+// called with mu held.
+func (f *FlightService) persistFlight() {
+	if f.store == nil {
+		return
+	}
+	if err := f.store.save(f.snapshot()); err != nil {
+		slog.Warn("failed to save active flight", "error", err)
+	}
+}
+
+// saveProgress saves the active flight with fd as its latest sample, or with
+// the sample it has when fd is nil.
+func (f *FlightService) saveProgress(fd *FlightData, at time.Time) {
+	if f.store == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state != "active" {
+		return
+	}
+	if fd != nil {
+		f.last = newLastSample(fd, at)
+	}
+	f.persistFlight()
+}
+
+// GetResumableFlight returns the flight a previous run left active, or nil.
+func (f *FlightService) GetResumableFlight() *ResumableFlight {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved := f.resumable
+	if saved == nil {
+		return nil
+	}
+	r := &ResumableFlight{
+		Callsign:  saved.Callsign,
+		Departure: saved.Departure,
+		Arrival:   saved.Arrival,
+		StartTime: saved.StartTime,
+	}
+	if saved.Last != nil {
+		r.LastSeen = saved.Last.Time
+	}
+	return r
+}
+
+// ResumeFlight picks up the flight a previous run left active, with the same
+// identity, once the aircraft is where that run last saw it. The gap is
+// recorded as an integrity event. When a continuity check fails the flight is
+// not resumed and the error lists the failed checks; DiscardResumableFlight
+// cancels it instead.
+func (f *FlightService) ResumeFlight() (ResumeCheck, error) {
+	fd, err := f.flightData.GetFlightDataNow()
+	if err != nil {
+		return ResumeCheck{}, fmt.Errorf("simulator not connected")
+	}
+	now := time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state == "active" {
+		return ResumeCheck{}, fmt.Errorf("flight already active")
+	}
+	saved := f.resumable
+	if saved == nil {
+		return ResumeCheck{}, fmt.Errorf("no flight to resume")
+	}
+	check := checkContinuity(saved.Last, fd, now)
+	if len(check.Problems) > 0 {
+		slog.Warn("interrupted flight failed continuity checks", "callsign", saved.Callsign, "problems", check.Problems)
+		return check, errors.New(strings.Join(check.Problems, "; "))
+	}
+
+	f.state = "active"
+	f.flightID = saved.FlightID
+	f.sequence = saved.Sequence
+	if f.outbox != nil {
+		// Reports may have been queued after the flight was last saved.
+		if n, err := f.outbox.lastSequence(saved.FlightID); err == nil {
+			f.sequence = max(f.sequence, n)
+		}
+	}
+	f.callsign = saved.Callsign
+	f.departure = saved.Departure
+	f.arrival = saved.Arrival
+	f.startTime = saved.StartTime
+	f.landing = saved.Landing
+	f.oooi = oooiTracker{times: saved.OOOI}
+	f.integrity = integrityMonitor{events: saved.Events}
+	f.oooi.seen, f.oooi.wasOnGround = true, saved.Last.OnGround
+	gap := []IntegrityEvent{interruptionEvent(saved.Last, fd, check)}
+	f.integrity.events = append(f.integrity.events, gap...)
+	f.last = newLastSample(fd, now)
+	f.resumable = nil
+	f.stopCh = make(chan struct{})
+	f.persistFlight()
+	f.labelRecordings()
+
+	f.reportIntegrityEvents(f.callsign, gap)
+	go f.positionLoop(f.stopCh)
+	go f.monitorLoop(f.stopCh)
+
+	slog.Info("flight resumed", "callsign", f.callsign, "gap", check.Gap, "distance", check.Distance, "fuelChange", check.FuelChange)
+
+	if f.app != nil {
+		f.app.Event.Emit("flight-state", "active")
+	}
+	return check, nil
+}
+
+// DiscardResumableFlight cancels the flight a previous run left active, with
+// the server and locally, when it is not going to be resumed.
+func (f *FlightService) DiscardResumableFlight() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	saved := f.resumable
+	if saved == nil {
+		return fmt.Errorf("no flight to resume")
+	}
+
+	payload := map[string]string{
+		"flightId":  saved.FlightID,
+		"callsign":  saved.Callsign,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	err := f.sendAction(actionStop, saved.FlightID, payload)
+	if err != nil && !errors.Is(err, errActionRefused) && f.queueAction(actionStop, saved.FlightID, saved.Callsign, payload, err) {
+		f.state = flightPendingSync
+		if f.app != nil {
+			f.app.Event.Emit("flight-state", f.state)
+		}
+	} else if err != nil {
+		slog.Warn("stop flight request failed", "error", err)
+	}
+	if f.outbox != nil {
+		if _, err := f.outbox.abandon(saved.FlightID); err != nil {
+			slog.Warn("failed to drop queued position reports", "error", err)
+		}
+	}
+	if f.store != nil {
+		if err := f.store.clear(); err != nil {
+			return fmt.Errorf("discard flight: %w", err)
+		}
+	}
+	f.resumable = nil
+	slog.Info("interrupted flight discarded", "callsign", saved.Callsign)
+	return nil
+}
+
 const (
 	posIntervalCritical  = 500 * time.Millisecond // airborne below 50 ft AGL (2hz)
 	posIntervalLow       = 1 * time.Second        // below 10,000 ft AGL
 	posIntervalHigh      = 2 * time.Second        // at/above 10,000 ft AGL
 	posIntervalStatic    = 60 * time.Second       // position unchanged
+	monitorInterval      = 1 * time.Second        // OOOI and touchdown monitoring away from the ground
 	criticalAltThreshold = 50.0
 	highAltThreshold     = 10_000.0
-	maxPendingReports    = 500 // max queued position reports
-	retryAttempts        = 4
 )
 
-// doRequestWithRetry wraps doRequest with exponential backoff retries for connection failures.
-func (f *FlightService) doRequestWithRetry(method, path string, body interface{}) ([]byte, int, error) {
-	var lastErr error
-	backoff := 2 * time.Second
-	for attempt := range retryAttempts {
-		respBody, status, err := f.auth.doRequest(method, path, body)
-		if err == nil {
-			return respBody, status, nil
-		}
-		lastErr = err
-		if attempt < retryAttempts-1 {
-			slog.Warn("request failed, retrying", "method", method, "path", path, "attempt", attempt+1, "error", err, "backoff", backoff)
-			time.Sleep(backoff)
-			backoff *= 2
-		}
+// positionCadence picks the position report interval from successive samples:
+// static → 60s, critical → 500ms, altitude-based otherwise.
+type positionCadence struct {
+	lastLat, lastLng float64
+	lastChanged      time.Time
+}
+
+func (c *positionCadence) interval(fd *FlightData, now time.Time) time.Duration {
+	posChanged := fd.Position.Latitude != c.lastLat || fd.Position.Longitude != c.lastLng
+	if posChanged {
+		c.lastLat = fd.Position.Latitude
+		c.lastLng = fd.Position.Longitude
+		c.lastChanged = now
+	}
+
+	switch {
+	case !posChanged && now.Sub(c.lastChanged) > 5*time.Second:
+		return posIntervalStatic
+	case !fd.Sensors.OnGround && fd.Position.AltitudeAGL < criticalAltThreshold:
+		return posIntervalCritical
+	case fd.Position.AltitudeAGL >= highAltThreshold:
+		return posIntervalHigh
+	default:
+		return posIntervalLow
 	}
-	return nil, 0, fmt.Errorf("all %d attempts failed: %w", retryAttempts, lastErr)
 }
 
+// positionLoop reports the latest sample at the cadence's interval. Its
+// subscription holds a single sample, so after a slow request it reports the
+// newest position rather than a backlog. Reports go through the outbox, so
+// those the server misses are sent, in order, once it is reachable again.
+// With dead reckoning on, the reports the server can extrapolate are skipped.
 func (f *FlightService) positionLoop(stopCh chan struct{}) {
-	ticker := time.NewTicker(posIntervalLow)
-	defer ticker.Stop()
+	samples := f.flightData.subscribeTelemetry(1, posIntervalLow)
+	defer samples.close()
+
+	f.mu.Lock()
+	flight := f.flightID
+	f.mu.Unlock()
 
 	currentInterval := posIntervalLow
-	var lastLat, lastLng float64
-	lastChanged := time.Now()
+	cadence := positionCadence{lastChanged: time.Now()}
+
+	var dr *deadReckoning
+	if f.settings != nil && f.settings.GetSettings().DeadReckoning {
+		dr = newDeadReckoning(f.settings.GetSettings())
+		defer func() { slog.Info("dead reckoning held back position reports", "count", dr.held) }()
+	}
 
-	var pendingReports []map[string]interface{}
 	var consecutiveFailures int
+	var saved time.Time
 
 	for {
 		select {
 		case <-stopCh:
-			// Flight ending — flush remaining queued reports
-			f.flushPendingReports(pendingReports)
 			return
-		case <-ticker.C:
-			fd, err := f.flightData.GetFlightDataNow()
-			if err != nil {
-				continue
-			}
-
-			// Detect position change
-			posChanged := fd.Position.Latitude != lastLat || fd.Position.Longitude != lastLng
-			if posChanged {
-				lastLat = fd.Position.Latitude
-				lastLng = fd.Position.Longitude
-				lastChanged = time.Now()
-			}
+		case sample := <-samples.C:
+			fd := sample.data
 
-			// Adaptive interval: static → 60s, critical → 500ms, altitude-based otherwise
-			var newInterval time.Duration
-			if !posChanged && time.Since(lastChanged) > 5*time.Second {
-				newInterval = posIntervalStatic
-			} else if !fd.Sensors.OnGround && fd.Position.AltitudeAGL < criticalAltThreshold {
-				newInterval = posIntervalCritical
-			} else if fd.Position.AltitudeAGL >= highAltThreshold {
-				newInterval = posIntervalHigh
-			} else {
-				newInterval = posIntervalLow
-			}
+			newInterval := cadence.interval(fd, sample.at)
 			if newInterval != currentInterval {
 				currentInterval = newInterval
-				ticker.Reset(currentInterval)
+				samples.setInterval(currentInterval)
 			}
 
-			// Drain pending reports first (stop at first failure)
-			if len(pendingReports) > 0 {
-				sent := 0
-				for _, queued := range pendingReports {
-					if _, _, err := f.auth.doRequest("POST", "/api/v2/acars/position", queued); err != nil {
-						break
-					}
-					sent++
+			if sample.at.Sub(saved) >= activeFlightSaveInterval {
+				f.saveProgress(fd, sample.at)
+				saved = sample.at
+			}
+
+			report := f.buildPositionReport(fd)
+			if dr != nil {
+				phase := FlightPhase(f.flightData.GetFlightPhase())
+				if !dr.due(fd, sample.at, phase, currentInterval == posIntervalCritical) {
+					continue
 				}
-				if sent > 0 {
-					pendingReports = pendingReports[sent:]
-					slog.Info("sent queued position reports", "sent", sent, "remaining", len(pendingReports))
+				dr.reported(fd, sample.at, phase)
+				// Tells the server how long it may extrapolate this report.
+				report["deadReckoning"] = map[string]interface{}{
+					"maxInterval": m(dr.thresholds.maxInterval.Seconds(), "s"),
 				}
 			}
 
-			// Send current report
-			report := f.buildPositionReport(fd)
-			_, _, err = f.auth.doRequest("POST", "/api/v2/acars/position", report)
+			sent, err := f.queuePositionReport(flight, f.nextSequence(), report)
 			if err != nil {
 				consecutiveFailures++
-				if len(pendingReports) < maxPendingReports {
-					pendingReports = append(pendingReports, report)
-				}
 				if consecutiveFailures == 1 {
 					slog.Warn("server connection lost, queuing position reports", "error", err)
 				} else if consecutiveFailures%30 == 0 {
-					slog.Warn("server still unreachable", "failures", consecutiveFailures, "queued", len(pendingReports))
-				}
-			} else {
-				if consecutiveFailures > 0 {
-					slog.Info("server connection restored", "had_failures", consecutiveFailures, "queued_remaining", len(pendingReports))
+					slog.Warn("server still unreachable", "failures", consecutiveFailures)
 				}
+				f.emitOutboxStatus(flight)
+			} else if consecutiveFailures > 0 {
+				slog.Info("server connection restored", "had_failures", consecutiveFailures, "sent", sent)
 				consecutiveFailures = 0
+				f.emitOutboxStatus(flight)
+			}
+		}
+	}
+}
+
+// monitorLoop watches the flight for events that need finer timing than the
+// position reports: OOOI times, touchdowns and integrity events. It has its own subscription so
+// a 60s static reporting interval at the gate does not delay gate-out, and
+// below the touchdown sampling altitude it raises both its own rate and the
+// connector's to touchdownSampleRate.
+func (f *FlightService) monitorLoop(stopCh chan struct{}) {
+	samples := f.flightData.subscribeTelemetry(touchdownSampleRate, monitorInterval)
+	defer samples.close()
+
+	var landing landingAnalyzer
+	threshold := f.touchdownSampleAGL()
+	highRate := false
+	defer func() {
+		if highRate {
+			f.flightData.setUpdateRate(1)
+		}
+	}()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case sample := <-samples.C:
+			fd := sample.data
+
+			f.checkOOOI(fd, sample.at)
+			f.checkIntegrity(fd, sample.at)
+
+			if report := landing.update(fd, sample.at); report != nil {
+				f.recordLanding(report)
 			}
+
+			wantHighRate := landing.active() ||
+				(!fd.Sensors.OnGround && fd.Position.AltitudeAGL < threshold)
+			if wantHighRate != highRate {
+				highRate = wantHighRate
+				if highRate {
+					samples.setInterval(time.Second / touchdownSampleRate)
+					f.flightData.setUpdateRate(touchdownSampleRate)
+				} else {
+					samples.setInterval(monitorInterval)
+					f.flightData.setUpdateRate(1)
+				}
+				slog.Debug("touchdown sampling", "highRate", highRate, "agl", fd.Position.AltitudeAGL)
+			}
+		}
+	}
+}
+
+// touchdownSampleAGL returns the configured altitude below which touchdown
+// sampling runs at high rate.
+func (f *FlightService) touchdownSampleAGL() float64 {
+	if f.settings != nil {
+		if agl := f.settings.GetSettings().TouchdownSampleAGL; agl > 0 {
+			return agl
 		}
 	}
+	return defaultTouchdownAGL
 }
 
-// flushPendingReports attempts a best-effort drain of queued reports when the flight ends.
-func (f *FlightService) flushPendingReports(pending []map[string]interface{}) {
-	for _, report := range pending {
-		if _, _, err := f.auth.doRequest("POST", "/api/v2/acars/position", report); err != nil {
-			slog.Warn("failed to flush queued report on flight end", "remaining", len(pending), "error", err)
+// recordLanding attaches a finished landing report to the flight, persists it
+// locally and notifies the frontend.
+func (f *FlightService) recordLanding(r *LandingReport) {
+	f.mu.Lock()
+	r.Callsign = f.callsign
+	r.Departure = f.departure
+	r.Arrival = f.arrival
+	f.landing = r
+	f.persistFlight()
+	f.mu.Unlock()
+
+	slog.Info("touchdown", "vs", r.VerticalSpeed, "g", r.GForce, "bounces", r.Bounces, "sampleRate", r.SampleRate)
+
+	if err := f.flightData.saveLandingReport(r); err != nil {
+		slog.Warn("failed to save landing report", "error", err)
+	}
+	if f.app != nil {
+		f.app.Event.Emit("landing-report", r)
+	}
+}
+
+// GetLandingReport returns the last touchdown of the active flight, if any.
+func (f *FlightService) GetLandingReport() *LandingReport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.landing
+}
+
+// checkOOOI runs OOOI detection on a sample taken at the given time and
+// queues new events for the tenant API. Failures are only logged: all four
+// times are sent again with FinishFlight.
+func (f *FlightService) checkOOOI(fd *FlightData, at time.Time) {
+	f.mu.Lock()
+	records := f.oooi.update(fd, at)
+	callsign := f.callsign
+	f.mu.Unlock()
+
+	if len(records) > 0 {
+		f.saveProgress(nil, at)
+	}
+
+	for _, r := range records {
+		slog.Info("OOOI event", "event", r.Name, "time", r.Event.Time, "simTime", r.Event.SimTime)
+
+		payload := map[string]string{
+			"callsign":     callsign,
+			"event":        r.Name,
+			"timestamp":    r.Event.Time.Format(time.RFC3339),
+			"simTimestamp": r.Event.SimTime,
+		}
+		f.events.send(f.auth, queuedEvent{path: "/api/acars/oooi", kind: "OOOI", name: r.Name, payload: payload})
+	}
+}
+
+// checkIntegrity runs the integrity monitor on a sample and queues the events
+// that ended for the tenant API. Like OOOI events, failures are only logged;
+// FinishFlight sends all of them again in its summary.
+func (f *FlightService) checkIntegrity(fd *FlightData, now time.Time) {
+	f.mu.Lock()
+	events := f.integrity.update(fd, now)
+	callsign := f.callsign
+	f.mu.Unlock()
+
+	if len(events) > 0 {
+		f.saveProgress(nil, now)
+	}
+	f.reportIntegrityEvents(callsign, events)
+}
+
+// reportIntegrityEvents passes events to the frontend and queues them for the
+// tenant API.
+func (f *FlightService) reportIntegrityEvents(callsign string, events []IntegrityEvent) {
+	for _, ev := range events {
+		slog.Warn("integrity event", "type", ev.Type, "time", ev.Time, "duration", ev.Duration)
+
+		if f.app != nil {
+			f.app.Event.Emit("integrity-event", ev)
+		}
+		payload := map[string]interface{}{
+			"callsign": callsign,
+			"event":    ev,
+		}
+		f.events.send(f.auth, queuedEvent{path: "/api/acars/integrity", kind: "integrity", name: ev.Type, payload: payload})
+	}
+}
+
+// queuedEvent is an OOOI or integrity event waiting to be sent.
+type queuedEvent struct {
+	path    string
+	kind    string // for the log
+	name    string
+	payload interface{}
+}
+
+// eventQueue sends OOOI and integrity events to the tenant API in the order
+// they happened, from a goroutine of its own, so a slow or unreachable server
+// does not hold up the monitor loop while it samples a touchdown. The
+// goroutine runs while there are events to send.
+type eventQueue struct {
+	mu      sync.Mutex
+	pending []queuedEvent
+	sending bool
+}
+
+func (q *eventQueue) send(auth *AuthService, ev queuedEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, ev)
+	if !q.sending {
+		q.sending = true
+		go q.run(auth)
+	}
+}
+
+func (q *eventQueue) run(auth *AuthService) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.sending = false
+			q.mu.Unlock()
 			return
 		}
+		ev := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		if _, _, err := auth.doRequest("POST", ev.path, ev.payload); err != nil {
+			slog.Warn("failed to send "+ev.kind+" event", "event", ev.name, "error", err)
+		}
+	}
+}
+
+// errReportsPending holds back the finish of a flight while its position
+// reports are still queued; AbandonPendingReports drops them.
+var errReportsPending = errors.New("position reports not sent yet")
+
+// nextSequence numbers the next position report of the active flight.
+func (f *FlightService) nextSequence() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sequence++
+	return f.sequence
+}
+
+// queuePositionReport numbers report, stores it in the outbox and drains it,
+// returning the number of reports sent. Without an outbox, or when the
+// database fails, the report is sent directly.
+func (f *FlightService) queuePositionReport(flight string, sequence int64, report map[string]interface{}) (int, error) {
+	report["sequence"] = sequence
+	if f.outbox != nil {
+		_, err := f.outbox.enqueue(flight, sequence, report)
+		if err == nil {
+			return f.drainOutbox()
+		}
+		slog.Error("failed to queue position report", "error", err)
+		// Gaps the server reports are noted for the next drain.
+		f.outbox.draining.Lock()
+		defer f.outbox.draining.Unlock()
 	}
-	if len(pending) > 0 {
-		slog.Info("flushed all queued position reports", "count", len(pending))
+	if err := f.sendPositionReport(report); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// drainOutbox sends the queued reports of all flights in sequence order until
+// the outbox is empty or a report fails, and returns the number sent. A
+// backlog is sent in batches when the tenant server supports it.
+func (f *FlightService) drainOutbox() (int, error) {
+	f.outbox.draining.Lock()
+	defer f.outbox.draining.Unlock()
+	defer f.backfill()
+
+	sent := 0
+	for {
+		entries, err := f.outbox.next(outboxBatchSize)
+		if err != nil || len(entries) == 0 {
+			return sent, err
+		}
+		// A backlog goes up in batches where the server takes them.
+		if len(entries) > 1 {
+			if size := f.batching.limit(f.auth, f.positionBatchSize()); size > 1 {
+				if entries, err = f.outbox.next(size); err != nil {
+					return sent, err
+				}
+				ok, err := f.sendPositionBatch(entries)
+				if errors.Is(err, errBatchRefused) {
+					// Only the reports the server refuses on their own
+					// are dropped.
+					n, err := f.sendEach(entries)
+					sent += n
+					if err != nil {
+						return sent, err
+					}
+					continue
+				}
+				if err != nil {
+					return sent, err
+				}
+				if ok {
+					done := make([]int64, len(entries))
+					for i, e := range entries {
+						done[i] = e.seq
+					}
+					if err := f.outbox.markSent(done); err != nil {
+						return sent, err
+					}
+					sent += len(done)
+				}
+				// Otherwise the server turned the batch down and the
+				// next round sends smaller ones or single reports.
+				continue
+			}
+		}
+		n, err := f.sendEach(entries)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+}
+
+// sendEach sends entries one report at a time until one fails, marks the
+// ones sent and returns their number.
+func (f *FlightService) sendEach(entries []outboxEntry) (int, error) {
+	var done []int64
+	var sendErr error
+	for _, e := range entries {
+		if sendErr = f.sendPositionReport(e.report); sendErr != nil {
+			break
+		}
+		done = append(done, e.seq)
+	}
+	if err := f.outbox.markSent(done); err != nil {
+		return 0, err
+	}
+	return len(done), sendErr
+}
+
+// sendPositionReport posts one report. Server errors and an expired session
+// fail it, to be sent again later; the server refusing the report for any
+// other reason would refuse it again, so it counts as delivered.
+func (f *FlightService) sendPositionReport(report interface{}) error {
+	body, status, err := f.auth.doRequest("POST", "/api/v2/acars/position", report)
+	if err != nil {
+		return err
+	}
+	switch {
+	case retryableStatus(status):
+		return fmt.Errorf("server returned %d", status)
+	case status >= 400:
+		slog.Warn("server refused position report", "status", status)
+	default:
+		f.noteGaps(body)
+	}
+	return nil
+}
+
+// gapReport is the server asking, in its answer to a position report or
+// batch, for reports it is missing.
+type gapReport struct {
+	Missing []sequenceRange `json:"missing"`
+}
+
+// noteGaps collects the gaps the server reported in body, to be backfilled
+// once the drain in progress ends. Must be called while draining.
+func (f *FlightService) noteGaps(body []byte) {
+	if f.outbox == nil || len(body) == 0 {
+		return
+	}
+	var r gapReport
+	if json.Unmarshal(body, &r) != nil {
+		return
+	}
+	for _, g := range r.Missing {
+		if g.FlightID != "" && g.From > 0 && g.To >= g.From {
+			f.outbox.gaps = append(f.outbox.gaps, g)
+		}
+	}
+}
+
+// backfill queues the reports the server asked for again, to go with the
+// next drain. Must be called while draining.
+func (f *FlightService) backfill() {
+	for _, g := range f.outbox.gaps {
+		n, err := f.outbox.requeue(g)
+		if err != nil {
+			slog.Warn("failed to backfill position reports", "error", err)
+			continue
+		}
+		slog.Info("backfilling position reports", "flightId", g.FlightID, "from", g.From, "to", g.To, "found", n)
+		if want := g.To - g.From + 1; n < want {
+			slog.Warn("position reports missing locally, cannot backfill", "flightId", g.FlightID, "count", want-n)
+		}
+	}
+	f.outbox.gaps = nil
+}
+
+// retryableStatus reports whether a request that got status may succeed when
+// sent again: server errors, rate limiting and an expired session.
+func retryableStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests || status == http.StatusUnauthorized
+}
+
+// outboxLoop drains the outbox while no flight is active, e.g. the reports
+// of a flight the app crashed in. During a flight positionLoop drains it.
+func (f *FlightService) outboxLoop() {
+	f.pruneOutbox("")
+	ticker := time.NewTicker(outboxRetryInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if f.GetFlightState() == "active" {
+			continue
+		}
+		if n, err := f.outbox.pending(""); err != nil || n == 0 {
+			continue
+		}
+		sent, err := f.drainOutbox()
+		if sent > 0 {
+			slog.Info("sent queued position reports", "sent", sent)
+		}
+		if err != nil {
+			slog.Debug("queued position reports still unsent", "error", err)
+		}
+		f.emitOutboxStatus("")
+	}
+}
+
+// pruneOutbox applies the retention setting, keeping the unsent reports of
+// the active flight.
+func (f *FlightService) pruneOutbox(active string) {
+	retention := defaultOutboxRetention
+	if f.settings != nil {
+		if r := f.settings.GetSettings().OutboxRetention; r > 0 {
+			retention = r
+		}
+	}
+	n, err := f.outbox.prune(time.Duration(retention*float64(time.Hour)), active)
+	if err != nil {
+		slog.Warn("failed to prune position report outbox", "error", err)
+	} else if n > 0 {
+		slog.Info("pruned position report outbox", "deleted", n)
+	}
+}
+
+// GetOutboxStatus returns the number of position reports waiting to be sent.
+func (f *FlightService) GetOutboxStatus() (OutboxStatus, error) {
+	if f.outbox == nil {
+		return OutboxStatus{}, nil
+	}
+	f.mu.Lock()
+	flight := f.flightID
+	f.mu.Unlock()
+	return f.outbox.status(flight)
+}
+
+// AbandonPendingReports drops the unsent position reports of the active
+// flight and of the finished flights pending sync, so their finish no longer
+// waits for them. It returns how many were dropped.
+func (f *FlightService) AbandonPendingReports() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state != "active" && f.state != flightPendingSync {
+		return 0, fmt.Errorf("no active flight")
+	}
+	if f.outbox == nil {
+		return 0, nil
+	}
+	var flights []string
+	if f.state == "active" {
+		flights = append(flights, f.flightID)
+	}
+	if f.actions != nil {
+		actions, err := f.actions.list()
+		if err != nil {
+			return 0, err
+		}
+		for _, a := range actions {
+			flights = append(flights, a.flight)
+		}
+	}
+	var total int64
+	for _, flight := range flights {
+		n, err := f.outbox.abandon(flight)
+		if err != nil {
+			return int(total), err
+		}
+		total += n
+	}
+	slog.Warn("abandoned queued position reports", "count", total)
+	return int(total), nil
+}
+
+func (f *FlightService) emitOutboxStatus(flight string) {
+	if f.app == nil || f.outbox == nil {
+		return
+	}
+	if status, err := f.outbox.status(flight); err == nil {
+		f.app.Event.Emit("outbox-status", status)
 	}
 }
 
@@ -319,6 +1088,7 @@ func m(value interface{}, unit string) measurement {
 
 func (f *FlightService) buildPositionReport(fd *FlightData) map[string]interface{} {
 	f.mu.Lock()
+	flightID := f.flightID
 	callsign := f.callsign
 	departure := f.departure
 	arrival := f.arrival
@@ -337,9 +1107,15 @@ func (f *FlightService) buildPositionReport(fd *FlightData) map[string]interface
 			"throttle":  m(e.ThrottlePos, "%"),
 			"mixture":   m(e.MixturePos, "%"),
 			"propeller": m(e.PropPos, "%"),
+			"fuelFlow":  m(e.FuelFlow, "lbs/h"),
 		}
 	}
 
+	tanks := make(map[string]interface{}, len(fd.Weight.FuelTanks))
+	for i, name := range fuelTankNames {
+		tanks[name] = m(fd.Weight.FuelTanks[i], "lbs")
+	}
+
 	doors := make([]map[string]interface{}, len(fd.Doors))
 	for i, d := range fd.Doors {
 		doors[i] = map[string]interface{}{
@@ -348,16 +1124,29 @@ func (f *FlightService) buildPositionReport(fd *FlightData) map[string]interface
 	}
 
 	simulator := ""
+	phase := ""
+	var stats ConnectorStats
 	if f.flightData != nil {
-		simulator = f.flightData.ConnectedAdapter()
+		simulator = f.flightData.connectedSimulator()
+		phase = f.flightData.GetFlightPhase()
+		stats = f.flightData.GetConnectorStats()
+	}
+
+	// Fields the simulator cannot supply read zero; dispatch should not take
+	// them for the pilot's doing.
+	invalid := fd.Invalid
+	if invalid == nil {
+		invalid = []string{}
 	}
 
 	return map[string]interface{}{
 		"acarsVersion": Version,
 		"simulator":    simulator,
+		"flightId":     flightID,
 		"callsign":     callsign,
 		"departure":    departure,
 		"arrival":      arrival,
+		"phase":        phase,
 		"timestamp":    time.Now().UTC().Format(time.RFC3339),
 		"elapsedTime":  m(elapsed, "s"),
 		"position": map[string]interface{}{
@@ -383,6 +1172,7 @@ func (f *FlightService) buildPositionReport(fd *FlightData) map[string]interface
 			"stallWarning":     fd.Sensors.StallWarning,
 			"overspeedWarning": fd.Sensors.OverspeedWarning,
 			"simulationRate":   m(fd.Sensors.SimulationRate, "x"),
+			"pushback":         fd.Sensors.Pushback,
 		},
 		"radios": map[string]interface{}{
 			"com1":             m(fd.Radios.Com1, "MHz"),
@@ -416,6 +1206,9 @@ func (f *FlightService) buildPositionReport(fd *FlightData) map[string]interface
 			"flaps":    m(fd.Controls.Flaps, "%"),
 			"spoilers": m(fd.Controls.Spoilers, "%"),
 			"gearDown": fd.Controls.GearDown,
+
+			"flapsDetent":  m(fd.Controls.FlapsDetent, ""),
+			"parkingBrake": fd.Controls.ParkingBrake,
 		},
 		"apu": map[string]interface{}{
 			"switchOn":  fd.APU.SwitchOn,
@@ -437,6 +1230,19 @@ func (f *FlightService) buildPositionReport(fd *FlightData) map[string]interface
 		"weight": map[string]interface{}{
 			"total": m(fd.Weight.TotalWeight, "lbs"),
 			"fuel":  m(fd.Weight.FuelWeight, "lbs"),
+			"tanks": tanks,
+		},
+		"environment": map[string]interface{}{
+			"oat":           m(fd.Environment.OAT, "C"),
+			"windDirection": m(fd.Environment.WindDirection, "deg"),
+			"windSpeed":     m(fd.Environment.WindSpeed, "kts"),
+			"qnh":           m(fd.Environment.QNH, "hPa"),
+			"visibility":    m(fd.Environment.Visibility, "m"),
+		},
+		"dataQuality": map[string]interface{}{
+			"invalidFields": invalid,
+			"sampleRate":    m(stats.RateHz, "Hz"),
+			"latency":       m(stats.LatencyMs, "ms"),
 		},
 	}
 }
@@ -2,12 +2,14 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,9 +27,41 @@ func TestPositionIntervalOrdering(t *testing.T) {
 	assert.Less(t, posIntervalHigh, posIntervalStatic)
 }
 
+func TestPositionCadenceOverSyntheticFlight(t *testing.T) {
+	flight := newSyntheticFlight(defaultFlightProfile())
+	start := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	// Sample at the interval the cadence asks for, like positionLoop does.
+	var cadence positionCadence
+	intervals := map[FlightPhase]map[time.Duration]bool{}
+	for pos := time.Duration(0); pos <= flight.duration; {
+		seg := flight.segments[len(flight.segments)-1]
+		for _, s := range flight.segments {
+			if pos < s.start+s.duration {
+				seg = s
+				break
+			}
+		}
+		interval := cadence.interval(flight.sampleAt(pos), start.Add(pos))
+		if intervals[seg.phase] == nil {
+			intervals[seg.phase] = map[time.Duration]bool{}
+		}
+		intervals[seg.phase][interval] = true
+		pos += interval
+	}
+
+	assert.Equal(t, map[time.Duration]bool{posIntervalLow: true, posIntervalStatic: true}, intervals[PhaseBoarding],
+		"parked at the gate falls back to the static interval")
+	assert.Equal(t, map[time.Duration]bool{posIntervalLow: true}, intervals[PhaseTaxiOut])
+	assert.Equal(t, map[time.Duration]bool{posIntervalHigh: true}, intervals[PhaseCruise])
+	assert.True(t, intervals[PhaseInitialClimb][posIntervalCritical], "just after liftoff")
+	assert.True(t, intervals[PhaseApproach][posIntervalCritical], "short final")
+	assert.True(t, intervals[PhaseOnBlock][posIntervalStatic])
+}
+
 func TestBuildPositionReport(t *testing.T) {
 	mock := &MockSimConnector{data: sampleFlightData(), name: "TestSim"}
-	fds := &FlightDataService{connector: mock, simActive: true}
+	fds := &FlightDataService{connector: mock, simActive: true, phase: PhaseBoarding}
 
 	f := &FlightService{
 		auth:       &AuthService{},
@@ -46,6 +80,7 @@ func TestBuildPositionReport(t *testing.T) {
 	assert.Equal(t, "EGLL", report["departure"])
 	assert.Equal(t, "KJFK", report["arrival"])
 	assert.Equal(t, "TestSim", report["simulator"])
+	assert.Equal(t, "boarding", report["phase"])
 	assert.Contains(t, report, "timestamp")
 	assert.Contains(t, report, "acarsVersion")
 
@@ -75,10 +110,12 @@ func TestBuildPositionReport(t *testing.T) {
 	assert.Equal(t, true, engines[0]["running"])
 	n1 := engines[0]["n1"].(measurement)
 	assert.Equal(t, 22.5, n1.Value)
+	assert.Equal(t, measurement{1100.0, "lbs/h"}, engines[0]["fuelFlow"])
 
 	// Sensors
 	sensors := report["sensors"].(map[string]interface{})
 	assert.Equal(t, true, sensors["onGround"])
+	assert.Equal(t, false, sensors["pushback"])
 
 	// Radios
 	radios := report["radios"].(map[string]interface{})
@@ -102,6 +139,8 @@ func TestBuildPositionReport(t *testing.T) {
 	// Controls
 	controls := report["controls"].(map[string]interface{})
 	assert.Equal(t, false, controls["gearDown"])
+	assert.Equal(t, true, controls["parkingBrake"])
+	assert.Equal(t, measurement{0.0, ""}, controls["flapsDetent"])
 
 	// APU
 	apu := report["apu"].(map[string]interface{})
@@ -121,117 +160,94 @@ func TestBuildPositionReport(t *testing.T) {
 	totalWeight := weight["total"].(measurement)
 	assert.Equal(t, 130000.0, totalWeight.Value)
 	assert.Equal(t, "lbs", totalWeight.Unit)
+	tanks := weight["tanks"].(map[string]interface{})
+	assert.Len(t, tanks, 4)
+	assert.Equal(t, measurement{16000.0, "lbs"}, tanks["center"])
+
+	// Environment
+	env := report["environment"].(map[string]interface{})
+	assert.Equal(t, measurement{14.0, "C"}, env["oat"])
+	assert.Equal(t, measurement{250.0, "deg"}, env["windDirection"])
+	assert.Equal(t, measurement{12.0, "kts"}, env["windSpeed"])
+	assert.Equal(t, measurement{1018.0, "hPa"}, env["qnh"])
+	assert.Equal(t, measurement{9999.0, "m"}, env["visibility"])
 
 	// Aircraft name
 	assert.Equal(t, "Boeing 737-800", report["aircraftName"])
-}
 
-func TestDoRequestWithRetry_SucceedsFirstAttempt(t *testing.T) {
-	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"ok":true}`))
-	})
-	defer server.Close()
+	// Data quality
+	quality := report["dataQuality"].(map[string]interface{})
+	assert.Equal(t, []string{}, quality["invalidFields"])
+	assert.Equal(t, "Hz", quality["sampleRate"].(measurement).Unit)
 
-	f := &FlightService{auth: auth}
-	body, status, err := f.doRequestWithRetry("POST", "/api/test", nil)
-	require.NoError(t, err)
-	assert.Equal(t, http.StatusOK, status)
-	assert.JSONEq(t, `{"ok":true}`, string(body))
+	partial := sampleFlightData()
+	partial.Invalid = []string{"apu.rpmPercent", "apu.switchOn"}
+	quality = f.buildPositionReport(partial)["dataQuality"].(map[string]interface{})
+	assert.Equal(t, []string{"apu.rpmPercent", "apu.switchOn"}, quality["invalidFields"],
+		"a missing APU is reported as unsupported, not as switched off")
 }
 
-func TestDoRequestWithRetry_SucceedsAfterFailures(t *testing.T) {
-	var calls atomic.Int32
+func TestDrainOutbox_SendsQueuedInOrder(t *testing.T) {
+	var received []string
 
 	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
-		n := calls.Add(1)
-		if n <= 2 {
-			// Simulate a server error that causes doRequest to fail via bad status
-			// Actually we need a real connection failure. Let's respond successfully on 3rd try.
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"attempt":` + fmt.Sprintf("%d", n) + `}`))
-		} else {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"ok":true}`))
+		if r.URL.Path != "/api/v2/acars/position" {
+			w.WriteHeader(http.StatusNotFound) // no batch uploads
+			return
 		}
-	})
-	defer server.Close()
-
-	f := &FlightService{auth: auth}
-	// doRequestWithRetry retries on error (network/connection failures), not on HTTP status codes.
-	// Since the test server always responds, this should succeed on first try.
-	body, status, err := f.doRequestWithRetry("POST", "/api/test", nil)
-	require.NoError(t, err)
-	assert.Equal(t, http.StatusOK, status)
-	assert.NotNil(t, body)
-}
-
-func TestDoRequestWithRetry_AllAttemptsFail(t *testing.T) {
-	// Use an auth service with no tenant URL → always errors
-	auth := &AuthService{
-		httpClient: http.DefaultClient,
-		settings:   &SettingsService{},
-	}
-
-	f := &FlightService{auth: auth}
-	_, _, err := f.doRequestWithRetry("GET", "/api/test", nil)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "all 4 attempts failed")
-	assert.Contains(t, err.Error(), "no tenant selected")
-}
-
-func TestFlushPendingReports_Empty(t *testing.T) {
-	f := &FlightService{auth: &AuthService{}}
-	// Should not panic on empty slice
-	f.flushPendingReports(nil)
-	f.flushPendingReports([]map[string]interface{}{})
-}
-
-func TestFlushPendingReports_SendsQueued(t *testing.T) {
-	var received []map[string]interface{}
-
-	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
 		var payload map[string]interface{}
 		json.NewDecoder(r.Body).Decode(&payload)
-		received = append(received, payload)
+		received = append(received, payload["callsign"].(string))
 		w.WriteHeader(http.StatusOK)
 	})
 	defer server.Close()
 
-	f := &FlightService{auth: auth}
-
-	pending := []map[string]interface{}{
-		{"callsign": "TEST1"},
-		{"callsign": "TEST2"},
-		{"callsign": "TEST3"},
+	f := &FlightService{auth: auth, outbox: newTestOutbox(t)}
+	for i, callsign := range []string{"TEST1", "TEST2", "TEST3"} {
+		_, err := f.outbox.enqueue("flight", int64(i+1), map[string]interface{}{"callsign": callsign})
+		require.NoError(t, err)
 	}
-	f.flushPendingReports(pending)
 
-	assert.Len(t, received, 3)
+	sent, err := f.drainOutbox()
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, []string{"TEST1", "TEST2", "TEST3"}, received)
+
+	sent, err = f.drainOutbox()
+	require.NoError(t, err)
+	assert.Zero(t, sent, "sent reports are not sent again")
 }
 
-func TestFlushPendingReports_StopsOnError(t *testing.T) {
+func TestDrainOutbox_StopsOnError(t *testing.T) {
 	var calls atomic.Int32
 
 	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
-		calls.Add(1)
+		if r.URL.Path != "/api/v2/acars/position" {
+			w.WriteHeader(http.StatusNotFound) // no batch uploads
+			return
+		}
+		if calls.Add(1) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 	})
 	defer server.Close()
 
-	f := &FlightService{auth: auth}
-
-	// Close server to force connection failures
-	server.Close()
-
-	pending := []map[string]interface{}{
-		{"callsign": "TEST1"},
-		{"callsign": "TEST2"},
+	f := &FlightService{auth: auth, outbox: newTestOutbox(t)}
+	for range 3 {
+		f.outbox.enqueue("flight", 0, map[string]interface{}{"callsign": "TEST"})
 	}
-	f.flushPendingReports(pending)
 
-	// Should have tried first one and stopped
-	assert.LessOrEqual(t, int(calls.Load()), 1)
+	sent, err := f.drainOutbox()
+	assert.ErrorContains(t, err, "server returned 503")
+	assert.Equal(t, 1, sent)
+	pending, _ := f.outbox.pending("flight")
+	assert.Equal(t, 2, pending, "the failed report and those after it stay queued")
+
+	sent, err = f.drainOutbox()
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
 }
 
 func TestPositionLoop_QueuesOnFailure(t *testing.T) {
@@ -258,11 +274,14 @@ func TestPositionLoop_QueuesOnFailure(t *testing.T) {
 
 	mock := &MockSimConnector{data: sampleFlightData(), name: "TestSim"}
 	fds := &FlightDataService{connector: mock, simActive: true}
+	startTestStream(t, fds)
 
 	f := &FlightService{
 		auth:       auth,
 		flightData: fds,
+		outbox:     newTestOutbox(t),
 		state:      "active",
+		flightID:   "TEST-1",
 		callsign:   "TEST",
 		departure:  "EGLL",
 		arrival:    "KJFK",
@@ -276,17 +295,131 @@ func TestPositionLoop_QueuesOnFailure(t *testing.T) {
 	time.Sleep(3 * time.Second)
 	close(stopCh)
 
-	// Wait for flush
+	// Wait for a tick in progress
 	time.Sleep(200 * time.Millisecond)
 
 	// Some reports should have gotten through (after the failure window)
-	assert.Greater(t, int(reportCount.Load()), 0, "at least some reports should have been sent")
+	assert.Greater(t, int(reportCount.Load()), 1, "the queued report is sent with the next one")
+	pending, err := f.outbox.pending("TEST-1")
+	require.NoError(t, err)
+	assert.Zero(t, pending)
 }
 
-func TestMaxPendingReportsConstant(t *testing.T) {
-	assert.Equal(t, 500, maxPendingReports)
+func TestFinishFlightWaitsForOutbox(t *testing.T) {
+	var requests, finished atomic.Int32
+	var reachable atomic.Bool
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case !reachable.Load():
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/api/acars/finish":
+			finished.Add(1)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	defer server.Close()
+
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	f := &FlightService{auth: auth, outbox: newPositionOutbox(db), actions: newPendingActions(db),
+		state: "active", flightID: "BAW123-1", callsign: "BAW123"}
+	for range 2 {
+		f.outbox.enqueue("BAW123-1", 0, map[string]interface{}{"callsign": "BAW123"})
+	}
+
+	require.NoError(t, f.FinishFlight(), "the finish is queued, not refused")
+	assert.Zero(t, requests.Load(), "the backlog is not sent while the flight is locked")
+	assert.Equal(t, flightPendingSync, f.GetFlightState())
+	actions, err := f.GetPendingActions()
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, actionFinish, actions[0].Kind)
+	assert.Contains(t, actions[0].LastError, "2 position reports not sent yet")
+
+	// Waiting for the track is not a failed attempt, so it does not back off.
+	for range 3 {
+		f.processActions(time.Now().Add(actionRetryMin))
+	}
+	actions, _ = f.GetPendingActions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, 1, actions[0].Attempts)
+	assert.WithinDuration(t, time.Now().Add(actionPollInterval), actions[0].next, 2*time.Second)
+
+	// Not due yet.
+	reachable.Store(true)
+	f.processActions(time.Now())
+	assert.Zero(t, finished.Load())
+
+	f.processActions(time.Now().Add(actionRetryMin))
+	assert.Equal(t, int32(1), finished.Load())
+	n, _ := f.outbox.pending("BAW123-1")
+	assert.Zero(t, n, "the track went first")
+	assert.Equal(t, "idle", f.GetFlightState())
+	actions, _ = f.GetPendingActions()
+	assert.Empty(t, actions)
 }
 
-func TestRetryAttemptsConstant(t *testing.T) {
-	assert.Equal(t, 4, retryAttempts)
+func TestFlightRequestsAreIdempotent(t *testing.T) {
+	var mu sync.Mutex
+	type request struct{ path, key, flightID string }
+	var requests []request
+	var startFails atomic.Bool
+	startFails.Store(true)
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		flightID, _ := payload["flightId"].(string)
+		mu.Lock()
+		requests = append(requests, request{r.URL.Path, r.Header.Get("Idempotency-Key"), flightID})
+		mu.Unlock()
+		if r.URL.Path == "/api/acars/start" && startFails.Swap(false) {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	mock := &MockSimConnector{data: sampleFlightData(), name: "TestSim"}
+	fds := &FlightDataService{connector: mock, simActive: true}
+	f := &FlightService{auth: auth, flightData: fds, state: "idle"}
+
+	require.Error(t, f.StartFlight("BAW123", "EGLL", "KJFK"))
+	require.NoError(t, f.StartFlight("BAW123", "EGLL", "KJFK"))
+	f.mu.Lock()
+	flightID := f.flightID
+	f.mu.Unlock()
+	_, err := uuid.Parse(flightID)
+	require.NoError(t, err)
+
+	report := f.buildPositionReport(sampleFlightData())
+	assert.Equal(t, flightID, report["flightId"])
+	assert.Equal(t, int64(1), f.nextSequence())
+	assert.Equal(t, int64(2), f.nextSequence())
+
+	require.NoError(t, f.StopFlight())
+
+	mu.Lock()
+	var flightRequests []request
+	for _, r := range requests {
+		if r.path != "/api/v2/acars/position" {
+			flightRequests = append(flightRequests, r)
+		}
+	}
+	mu.Unlock()
+	assert.Equal(t, []request{
+		{"/api/acars/start", flightID + ":start", flightID},
+		{"/api/acars/start", flightID + ":start", flightID},
+		{"/api/acars/stop", flightID + ":stop", flightID},
+	}, flightRequests, "the retried start keeps its flight ID")
+
+	require.NoError(t, f.StartFlight("BAW123", "EGLL", "KJFK"))
+	f.mu.Lock()
+	assert.NotEqual(t, flightID, f.flightID, "the next flight gets a new ID")
+	f.mu.Unlock()
+	f.StopFlight()
 }
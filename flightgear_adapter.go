@@ -0,0 +1,265 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFlightGearPort = 5500
+	flightGearProtocol    = "airspace-acars" // --generic protocol name, the file name without .xml
+)
+
+// flightGearProtocolXML is the generic protocol FlightGear sends with. The
+// same file is installed into $FG_ROOT/Protocol by InstallFlightGearProtocol.
+//
+//go:embed flightgear/airspace-acars.xml
+var flightGearProtocolXML []byte
+
+// fgProtocol is the output section of a FlightGear generic protocol file.
+type fgProtocol struct {
+	LineSeparator string    `xml:"generic>output>line_separator"`
+	VarSeparator  string    `xml:"generic>output>var_separator"`
+	Chunks        []fgChunk `xml:"generic>output>chunk"`
+}
+
+type fgChunk struct {
+	Name string `xml:"name"` // FlightData field, see flightDataFields
+	Node string `xml:"node"`
+}
+
+// fgSeparators are the named separators FlightGear accepts besides literals.
+var fgSeparators = map[string]string{
+	"newline":   "\n",
+	"tab":       "\t",
+	"space":     " ",
+	"comma":     ",",
+	"semicolon": ";",
+}
+
+func parseFlightGearProtocol(b []byte) (*fgProtocol, error) {
+	var p fgProtocol
+	if err := xml.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("parse FlightGear protocol: %w", err)
+	}
+	if len(p.Chunks) == 0 {
+		return nil, fmt.Errorf("FlightGear protocol has no output chunks")
+	}
+	for _, c := range p.Chunks {
+		if _, ok := flightDataFields[c.Name]; !ok {
+			return nil, fmt.Errorf("FlightGear chunk %s: unknown field %q", c.Node, c.Name)
+		}
+	}
+	for _, sep := range []*string{&p.LineSeparator, &p.VarSeparator} {
+		if named, ok := fgSeparators[*sep]; ok {
+			*sep = named
+		}
+	}
+	if p.VarSeparator == "" {
+		return nil, fmt.Errorf("FlightGear protocol has no var_separator")
+	}
+	return &p, nil
+}
+
+// fields lists the FlightData fields the protocol fills. Engines are marked
+// present by setEnginesPresentLocked even without a chunk for it.
+func (p *fgProtocol) fields() []string {
+	fields := make([]string, 0, len(p.Chunks)+1)
+	for _, c := range p.Chunks {
+		fields = append(fields, c.Name)
+	}
+	return withImpliedFields(append(fields, "engines.count"))
+}
+
+// FlightGearAdapter receives FlightGear's generic protocol output over UDP,
+// one line per sample with the chunks in protocol order.
+type FlightGearAdapter struct {
+	port int
+
+	mu           sync.Mutex
+	conn         *net.UDPConn
+	protocol     *fgProtocol
+	data         FlightData
+	lastReceived time.Time
+	sink         func(*FlightData)
+}
+
+func NewFlightGearAdapter(port int) SimConnector {
+	if port <= 0 {
+		port = defaultFlightGearPort
+	}
+	return &FlightGearAdapter{port: port}
+}
+
+func (g *FlightGearAdapter) Name() string {
+	return "FlightGear"
+}
+
+func (g *FlightGearAdapter) Connect() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	protocol, err := parseFlightGearProtocol(flightGearProtocolXML)
+	if err != nil {
+		return err
+	}
+
+	// Listen on all interfaces so FlightGear may run on another machine.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: g.port})
+	if err != nil {
+		if isAddrInUse(err) {
+			return fmt.Errorf("UDP port %d is already in use by another program: %w", g.port, err)
+		}
+		return fmt.Errorf("listen on UDP port %d: %w", g.port, err)
+	}
+	g.conn = conn
+	g.protocol = protocol
+	g.data = FlightData{Invalid: fieldsExcept(protocol.fields())}
+	g.lastReceived = time.Time{}
+
+	go g.listenLoop(conn)
+
+	slog.Info("FlightGear UDP listening", "port", g.port)
+	return nil
+}
+
+func (g *FlightGearAdapter) Disconnect() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+	return nil
+}
+
+func (g *FlightGearAdapter) GetFlightData() (*FlightData, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case g.conn == nil:
+		return nil, fmt.Errorf("not connected")
+	case g.lastReceived.IsZero():
+		return nil, fmt.Errorf("no data from FlightGear on UDP port %d — start it with --generic=socket,out,10,127.0.0.1,%d,udp,%s",
+			g.port, g.port, flightGearProtocol)
+	case time.Since(g.lastReceived) > 3*time.Second:
+		return nil, fmt.Errorf("no data from simulator")
+	}
+
+	data := g.data
+	return &data, nil
+}
+
+// SetSampleSink implements SamplePusher; sink gets every sample line.
+func (g *FlightGearAdapter) SetSampleSink(sink func(*FlightData)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sink = sink
+}
+
+// Capabilities lists the fields of the built-in protocol.
+func (g *FlightGearAdapter) Capabilities() ConnectorCapabilities {
+	p, err := parseFlightGearProtocol(flightGearProtocolXML)
+	if err != nil {
+		return ConnectorCapabilities{}
+	}
+	return ConnectorCapabilities{Fields: p.fields()}
+}
+
+func (g *FlightGearAdapter) LastReceived() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.lastReceived
+}
+
+func (g *FlightGearAdapter) listenLoop(conn *net.UDPConn) {
+	buf := make([]byte, 8192)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		g.mu.Lock()
+		received := g.lastReceived
+		for _, line := range strings.Split(string(buf[:n]), g.protocol.LineSeparator) {
+			if err := g.applyLineLocked(line); err != nil {
+				slog.Debug("bad FlightGear sample", "error", err)
+			}
+		}
+		sample, sink := g.data, g.sink
+		fresh := g.lastReceived != received
+		g.mu.Unlock()
+
+		if fresh && sink != nil {
+			sink(&sample)
+		}
+	}
+}
+
+// applyLineLocked stores one sample. Lines with a different number of values
+// come from another protocol version and are rejected whole.
+func (g *FlightGearAdapter) applyLineLocked(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	values := strings.Split(line, g.protocol.VarSeparator)
+	if len(values) != len(g.protocol.Chunks) {
+		return fmt.Errorf("got %d values, protocol has %d chunks — reinstall the %s protocol", len(values), len(g.protocol.Chunks), flightGearProtocol)
+	}
+
+	parsed := make([]float64, len(values))
+	for i, s := range values {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return fmt.Errorf("chunk %s: %w", g.protocol.Chunks[i].Name, err)
+		}
+		parsed[i] = v
+	}
+
+	for i, c := range g.protocol.Chunks {
+		flightDataFields[c.Name](&g.data, parsed[i])
+	}
+	g.setEnginesPresentLocked()
+	g.lastReceived = time.Now()
+	return nil
+}
+
+// setEnginesPresentLocked marks engines as present. FlightGear has no engine
+// count and sends zeros for engines the aircraft lacks, so an engine counts
+// once it has run or turned.
+func (g *FlightGearAdapter) setEnginesPresentLocked() {
+	for i := range g.data.Engines {
+		e := &g.data.Engines[i]
+		e.Exists = e.Exists || e.Running || e.N1 > 0 || e.N2 > 0
+	}
+}
+
+// installFlightGearProtocol writes the protocol file into fgRoot/Protocol.
+func installFlightGearProtocol(fgRoot string) (string, error) {
+	dir := filepath.Join(fgRoot, "Protocol")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not a FlightGear data folder (FG_ROOT): no Protocol folder", fgRoot)
+	}
+	path := filepath.Join(dir, flightGearProtocol+".xml")
+	if err := os.WriteFile(path, flightGearProtocolXML, 0644); err != nil {
+		return "", fmt.Errorf("write FlightGear protocol: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flightGearLine formats a sample the way FlightGear sends it with the built-in
+// protocol; fields not in values are sent as 0.
+func flightGearLine(t *testing.T, values map[string]string) string {
+	t.Helper()
+	p, err := parseFlightGearProtocol(flightGearProtocolXML)
+	require.NoError(t, err)
+
+	out := make([]string, len(p.Chunks))
+	for i, c := range p.Chunks {
+		out[i] = "0"
+		if v, ok := values[c.Name]; ok {
+			out[i] = v
+		}
+	}
+	return strings.Join(out, p.VarSeparator) + p.LineSeparator
+}
+
+func TestFlightGearProtocol(t *testing.T) {
+	p, err := parseFlightGearProtocol(flightGearProtocolXML)
+	require.NoError(t, err)
+	assert.Equal(t, "\n", p.LineSeparator)
+	assert.Equal(t, ",", p.VarSeparator)
+	assert.Equal(t, "position.latitude", p.Chunks[0].Name)
+	assert.Equal(t, "/position/latitude-deg", p.Chunks[0].Node)
+
+	_, err = parseFlightGearProtocol([]byte(`<PropertyList><generic><output>
+		<var_separator>,</var_separator>
+		<chunk><name>position.warp</name><node>/position/warp</node></chunk>
+	</output></generic></PropertyList>`))
+	assert.ErrorContains(t, err, `unknown field "position.warp"`)
+}
+
+func TestFlightGearAdapterReceivesSamples(t *testing.T) {
+	port := freeUDPPort(t)
+	g := NewFlightGearAdapter(port)
+	require.NoError(t, g.Connect())
+	defer g.Disconnect()
+
+	_, err := g.GetFlightData()
+	assert.ErrorContains(t, err, "--generic=socket,out,10,127.0.0.1")
+
+	fg, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	require.NoError(t, err)
+	defer fg.Close()
+
+	_, err = fg.Write([]byte("1,2,3\n")) // from an older protocol file
+	require.NoError(t, err)
+	_, err = fg.Write([]byte(flightGearLine(t, map[string]string{
+		"position.latitude":  "47.2649",
+		"position.longitude": "11.3440",
+		"position.altitude":  "1906.0",
+		"attitude.vs":        "640",
+		"engine1.running":    "1",
+		"engine1.n1":         "45.2",
+		"controls.flaps":     "50.0",
+		"controls.gearDown":  "1",
+		"radios.xpdrState":   "4",
+		"simTime.zuluYear":   "2026",
+	})))
+	require.NoError(t, err)
+
+	var fd *FlightData
+	require.Eventually(t, func() bool {
+		fd, err = g.GetFlightData()
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 47.2649, fd.Position.Latitude)
+	assert.Equal(t, 11.344, fd.Position.Longitude)
+	assert.Equal(t, 1906.0, fd.Position.Altitude)
+	assert.Equal(t, 640.0, fd.Attitude.VS)
+	assert.True(t, fd.Engines[0].Running)
+	assert.True(t, fd.Engines[0].Exists)
+	assert.False(t, fd.Engines[1].Exists, "engines that never turned are absent")
+	assert.Equal(t, 45.2, fd.Engines[0].N1)
+	assert.Equal(t, 50.0, fd.Controls.Flaps)
+	assert.True(t, fd.Controls.GearDown)
+	assert.Equal(t, "active", fd.Radios.XpdrState)
+	assert.Equal(t, 2026.0, fd.SimTime.ZuluYear)
+}
+
+func TestFlightGearAdapterPushesSamples(t *testing.T) {
+	port := freeUDPPort(t)
+	g := NewFlightGearAdapter(port)
+	samples := make(chan *FlightData, 4)
+	g.(SamplePusher).SetSampleSink(func(fd *FlightData) { samples <- fd })
+	require.NoError(t, g.Connect())
+	defer g.Disconnect()
+
+	fg, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	require.NoError(t, err)
+	defer fg.Close()
+	_, err = fg.Write([]byte("1,2,3\n")) // rejected, not pushed
+	require.NoError(t, err)
+	_, err = fg.Write([]byte(flightGearLine(t, map[string]string{"position.latitude": "47.2649"})))
+	require.NoError(t, err)
+
+	select {
+	case fd := <-samples:
+		assert.Equal(t, 47.2649, fd.Position.Latitude)
+	case <-time.After(3 * time.Second):
+		t.Fatal("no sample pushed")
+	}
+	assert.Empty(t, samples)
+}
+
+func TestFlightGearAdapterPortInUse(t *testing.T) {
+	busy, err := net.ListenUDP("udp", &net.UDPAddr{})
+	require.NoError(t, err)
+	defer busy.Close()
+
+	err = NewFlightGearAdapter(busy.LocalAddr().(*net.UDPAddr).Port).Connect()
+	assert.ErrorContains(t, err, "already in use")
+}
+
+func TestInstallFlightGearProtocol(t *testing.T) {
+	fgRoot := t.TempDir()
+	_, err := installFlightGearProtocol(fgRoot)
+	assert.ErrorContains(t, err, "not a FlightGear data folder")
+
+	require.NoError(t, os.Mkdir(filepath.Join(fgRoot, "Protocol"), 0755))
+	path, err := installFlightGearProtocol(fgRoot)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(fgRoot, "Protocol", "airspace-acars.xml"), path)
+
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, flightGearProtocolXML, written)
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultForeFlightPort = 49002 // ForeFlight's GDL-90/XGPS port
+	foreFlightTrafficTTL  = 30 * time.Second
+	foreFlightGroundSpeed = 35.0 // kts; below this the aircraft is assumed on the ground
+)
+
+// foreFlightFields are the FlightData fields the ForeFlight sentences supply.
+// The vertical speed and on-ground state are derived, the rest is sent as is.
+var foreFlightFields = []string{
+	"position.latitude",
+	"position.longitude",
+	"position.altitude",
+	"attitude.headingTrue",
+	"attitude.pitch",
+	"attitude.roll",
+	"attitude.gs",
+	"attitude.vs",
+	"sensors.onGround",
+}
+
+// ForeFlightTraffic is another aircraft reported with an XTRAFFIC sentence.
+type ForeFlightTraffic struct {
+	ID        string    `json:"id"` // ICAO address or the simulator's id
+	Callsign  string    `json:"callsign"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Altitude  float64   `json:"altitude"` // ft
+	VS        float64   `json:"vs"`       // fpm
+	Airborne  bool      `json:"airborne"`
+	Heading   float64   `json:"heading"` // true
+	Speed     float64   `json:"speed"`   // kts
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// ForeFlightAdapter listens for the XGPS, XATT and XTRAFFIC sentences that
+// Aerofly FS, DCS and other simulators broadcast for ForeFlight.
+type ForeFlightAdapter struct {
+	port int
+
+	mu           sync.Mutex
+	conn         *net.UDPConn
+	data         FlightData
+	lastReceived time.Time
+	lastGPS      time.Time
+	hasAttitude  bool // XATT seen; heading then comes from it, not the track
+	simName      string
+	traffic      map[string]ForeFlightTraffic
+	fields       fieldTracker
+	sink         func(*FlightData)
+}
+
+func NewForeFlightAdapter(port int) SimConnector {
+	if port <= 0 {
+		port = defaultForeFlightPort
+	}
+	return &ForeFlightAdapter{port: port}
+}
+
+func (a *ForeFlightAdapter) Name() string {
+	return "ForeFlight"
+}
+
+func (a *ForeFlightAdapter) Connect() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Simulators broadcast the sentences, so listen on all interfaces.
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: a.port})
+	if err != nil {
+		if isAddrInUse(err) {
+			return fmt.Errorf("UDP port %d is already in use — close ForeFlight or other EFB apps on this PC: %w", a.port, err)
+		}
+		return fmt.Errorf("listen on UDP port %d: %w", a.port, err)
+	}
+	a.conn = conn
+	a.data = FlightData{}
+	a.lastReceived = time.Time{}
+	a.lastGPS = time.Time{}
+	a.hasAttitude = false
+	a.fields = fieldTracker{}
+	a.traffic = make(map[string]ForeFlightTraffic)
+
+	go a.listenLoop(conn)
+
+	slog.Info("ForeFlight UDP listening", "port", a.port)
+	return nil
+}
+
+func (a *ForeFlightAdapter) Disconnect() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.conn != nil {
+		a.conn.Close()
+		a.conn = nil
+	}
+	return nil
+}
+
+func (a *ForeFlightAdapter) GetFlightData() (*FlightData, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case a.conn == nil:
+		return nil, fmt.Errorf("not connected")
+	case a.lastGPS.IsZero():
+		return nil, fmt.Errorf("no XGPS position on UDP port %d — enable the ForeFlight/EFB output in the simulator", a.port)
+	case time.Since(a.lastGPS) > 3*time.Second:
+		return nil, fmt.Errorf("no data from simulator")
+	}
+
+	data := a.data
+	return &data, nil
+}
+
+// SetSampleSink implements SamplePusher; sink gets the data after every
+// datagram once an XGPS position has arrived.
+func (a *ForeFlightAdapter) SetSampleSink(sink func(*FlightData)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sink = sink
+}
+
+func (a *ForeFlightAdapter) LastReceived() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastReceived
+}
+
+// Capabilities lists the fields the ForeFlight sentences carry; XATT ones
+// stay invalid until the simulator sends attitude.
+func (a *ForeFlightAdapter) Capabilities() ConnectorCapabilities {
+	return ConnectorCapabilities{Fields: withImpliedFields(foreFlightFields)}
+}
+
+// Traffic returns the other aircraft reported recently.
+func (a *ForeFlightAdapter) Traffic() []ForeFlightTraffic {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	list := make([]ForeFlightTraffic, 0, len(a.traffic))
+	for id, t := range a.traffic {
+		if time.Since(t.LastSeen) > foreFlightTrafficTTL {
+			delete(a.traffic, id)
+			continue
+		}
+		list = append(list, t)
+	}
+	slices.SortFunc(list, func(x, y ForeFlightTraffic) int { return strings.Compare(x.ID, y.ID) })
+	return list
+}
+
+func (a *ForeFlightAdapter) listenLoop(conn *net.UDPConn) {
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		now := time.Now()
+		a.mu.Lock()
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if err := a.applySentenceLocked(strings.TrimSpace(line), now); err != nil {
+				slog.Debug("bad ForeFlight sentence", "sentence", line, "error", err)
+			}
+		}
+		sample, sink := a.data, a.sink
+		hasPosition := !a.lastGPS.IsZero() && now.Sub(a.lastGPS) <= 3*time.Second
+		a.mu.Unlock()
+
+		if hasPosition && sink != nil {
+			sink(&sample)
+		}
+	}
+}
+
+// applySentenceLocked stores one sentence. The message type is followed by
+// the simulator's name, e.g. "XGPSAerofly FS 4,-122.38,37.62,9.1,118.5,0.0".
+func (a *ForeFlightAdapter) applySentenceLocked(line string, now time.Time) error {
+	if line == "" {
+		return nil
+	}
+	head, rest, _ := strings.Cut(line, ",")
+	fields := strings.Split(rest, ",")
+
+	switch {
+	case strings.HasPrefix(head, "XTRAFFIC"):
+		return a.applyTrafficLocked(fields, now)
+	case strings.HasPrefix(head, "XGPS"):
+		v, err := parseForeFlightNumbers(fields, 5)
+		if err != nil {
+			return fmt.Errorf("XGPS: %w", err)
+		}
+		a.applyGPSLocked(v, now)
+		if sim := strings.TrimPrefix(head, "XGPS"); sim != a.simName {
+			slog.Info("ForeFlight data received", "sim", sim)
+			a.simName = sim
+		}
+	case strings.HasPrefix(head, "XATT"):
+		v, err := parseForeFlightNumbers(fields, 3)
+		if err != nil {
+			return fmt.Errorf("XATT: %w", err)
+		}
+		a.data.Attitude.HeadingTrue = v[0]
+		a.data.Attitude.Pitch = v[1]
+		a.data.Attitude.Roll = v[2]
+		a.hasAttitude = true
+		for _, f := range []string{"attitude.headingTrue", "attitude.pitch", "attitude.roll"} {
+			a.fields.mark(f)
+		}
+	default:
+		return fmt.Errorf("unknown sentence %q", head)
+	}
+	a.data.Invalid = a.fields.invalidFields()
+	a.lastReceived = now
+	return nil
+}
+
+// applyGPSLocked stores an XGPS position: longitude, latitude, altitude MSL
+// (m), track (true) and ground speed (m/s).
+func (a *ForeFlightAdapter) applyGPSLocked(v []float64, now time.Time) {
+	altitude := unitConversions["m_to_ft"](v[2])
+
+	// XGPS has no vertical speed; derive it from consecutive altitudes,
+	// smoothed against jitter in the reported altitude.
+	if dt := now.Sub(a.lastGPS).Seconds(); !a.lastGPS.IsZero() && dt > 0 && dt <= 5 {
+		vs := (altitude - a.data.Position.Altitude) / dt * 60
+		a.data.Attitude.VS += 0.3 * (vs - a.data.Attitude.VS)
+	}
+
+	a.data.Position.Longitude = v[0]
+	a.data.Position.Latitude = v[1]
+	a.data.Position.Altitude = altitude
+	a.data.Attitude.GS = unitConversions["mps_to_kts"](v[4])
+	if !a.hasAttitude {
+		a.data.Attitude.HeadingTrue = math.Mod(v[3]+360, 360)
+	}
+	a.data.Sensors.OnGround = a.data.Attitude.GS < foreFlightGroundSpeed && math.Abs(a.data.Attitude.VS) < 200
+	a.lastGPS = now
+	for _, f := range []string{"position.latitude", "position.longitude", "position.altitude", "attitude.headingTrue", "attitude.gs", "attitude.vs", "sensors.onGround"} {
+		a.fields.mark(f)
+	}
+}
+
+// applyTrafficLocked stores an XTRAFFIC report: id, latitude, longitude,
+// altitude (ft), vertical speed (fpm), airborne flag, heading, speed (kts)
+// and callsign.
+func (a *ForeFlightAdapter) applyTrafficLocked(fields []string, now time.Time) error {
+	if len(fields) < 9 {
+		return fmt.Errorf("XTRAFFIC: %d fields, want 9", len(fields))
+	}
+	v, err := parseForeFlightNumbers(fields[1:8], 7)
+	if err != nil {
+		return fmt.Errorf("XTRAFFIC: %w", err)
+	}
+	id := strings.TrimSpace(fields[0])
+	a.traffic[id] = ForeFlightTraffic{
+		ID:        id,
+		Callsign:  strings.TrimSpace(fields[8]),
+		Latitude:  v[0],
+		Longitude: v[1],
+		Altitude:  v[2],
+		VS:        v[3],
+		Airborne:  v[4] != 0,
+		Heading:   v[5],
+		Speed:     v[6],
+		LastSeen:  now,
+	}
+	return nil
+}
+
+// parseForeFlightNumbers parses the first n fields; extra fields some
+// simulators append are ignored.
+func parseForeFlightNumbers(fields []string, n int) ([]float64, error) {
+	if len(fields) < n {
+		return nil, fmt.Errorf("%d fields, want %d", len(fields), n)
+	}
+	v := make([]float64, n)
+	for i := range n {
+		f, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("field %d: invalid number %q", i+1, fields[i])
+		}
+		v[i] = f
+	}
+	return v, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForeFlightSentences(t *testing.T) {
+	a := &ForeFlightAdapter{traffic: make(map[string]ForeFlightTraffic)}
+	now := time.Now()
+
+	require.NoError(t, a.applySentenceLocked("XGPSAerofly FS 4,-9.1359,38.7813,100.0,-5.0,10.0", now))
+	assert.Equal(t, 38.7813, a.data.Position.Latitude)
+	assert.Equal(t, -9.1359, a.data.Position.Longitude)
+	assert.InDelta(t, 328.08, a.data.Position.Altitude, 0.01)
+	assert.InDelta(t, 19.44, a.data.Attitude.GS, 0.01)
+	assert.Equal(t, 355.0, a.data.Attitude.HeadingTrue, "track stands in for heading until XATT arrives")
+	assert.True(t, a.data.Sensors.OnGround, "slow and level is taken as on the ground")
+
+	require.NoError(t, a.applySentenceLocked("XATTAerofly FS 4,182.5,4.1,-1.5,0.0,0.1", now))
+	assert.Equal(t, 182.5, a.data.Attitude.HeadingTrue)
+	assert.Equal(t, 4.1, a.data.Attitude.Pitch)
+	assert.Equal(t, -1.5, a.data.Attitude.Roll)
+
+	// Climbing 5 m/s at 80 m/s ground speed, one sample per second.
+	for i := 1; i <= 20; i++ {
+		alt := 100.0 + 5*float64(i)
+		line := "XGPSAerofly FS 4,-9.1359,38.7813," + strconv.FormatFloat(alt, 'f', 1, 64) + ",180.0,80.0"
+		require.NoError(t, a.applySentenceLocked(line, now.Add(time.Duration(i)*time.Second)))
+	}
+	assert.InDelta(t, 984, a.data.Attitude.VS, 10, "vertical speed is derived from the altitudes")
+	assert.False(t, a.data.Sensors.OnGround)
+	assert.Equal(t, 182.5, a.data.Attitude.HeadingTrue, "the XATT heading wins over the track")
+
+	require.NoError(t, a.applySentenceLocked("XTRAFFICAerofly FS 4,4CA2B1,38.70,-9.20,3500,-700,1,35.0,160,TAP123", now))
+	traffic := a.Traffic()
+	require.Len(t, traffic, 1)
+	assert.Equal(t, ForeFlightTraffic{
+		ID: "4CA2B1", Callsign: "TAP123", Latitude: 38.70, Longitude: -9.20, Altitude: 3500, VS: -700,
+		Airborne: true, Heading: 35, Speed: 160, LastSeen: now,
+	}, traffic[0])
+
+	assert.Error(t, a.applySentenceLocked("XGPSAerofly FS 4,-9.1,38.7", now))
+	assert.Error(t, a.applySentenceLocked("XATTAerofly FS 4,NaN,0,0", now))
+	assert.Error(t, a.applySentenceLocked("GPGGA,123519,4807.038,N", now))
+}
+
+func TestForeFlightAdapterListens(t *testing.T) {
+	port := freeUDPPort(t)
+	a := NewForeFlightAdapter(port)
+	require.NoError(t, a.Connect())
+	defer a.Disconnect()
+
+	_, err := a.GetFlightData()
+	assert.ErrorContains(t, err, "no XGPS position")
+
+	sim, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	require.NoError(t, err)
+	defer sim.Close()
+	_, err = sim.Write([]byte("XATTDCS,90.0,2.0,0.0\nXGPSDCS,41.2,42.0,1000,90.0,120.0\n"))
+	require.NoError(t, err)
+
+	var fd *FlightData
+	require.Eventually(t, func() bool {
+		fd, err = a.GetFlightData()
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 42.0, fd.Position.Latitude)
+	assert.Equal(t, 90.0, fd.Attitude.HeadingTrue)
+}
+
+func TestForeFlightUnavailableFields(t *testing.T) {
+	c := NewForeFlightAdapter(0)
+	caps := c.Capabilities()
+	assert.True(t, caps.Supports("position.latitude"))
+	assert.False(t, caps.Supports("engine1.n1"))
+
+	fds := &FlightDataService{connector: c}
+	missing := fds.GetUnavailableFields()
+	assert.Contains(t, missing, "engine1.n1")
+	assert.Contains(t, missing, "weight.fuelWeight")
+	assert.Contains(t, missing, "aircraftName")
+	assert.NotContains(t, missing, "position.latitude")
+	assert.Len(t, missing, len(allFlightDataFields)-len(foreFlightFields))
+	assert.Empty(t, (&FlightDataService{connector: &MockSimConnector{}}).GetUnavailableFields())
+}
+
+func TestForeFlightSampleValidity(t *testing.T) {
+	a := &ForeFlightAdapter{traffic: make(map[string]ForeFlightTraffic)}
+	require.NoError(t, a.applySentenceLocked("XGPSMSFS,-9.1359,38.7813,100.0,-5.0,10.0", time.Now()))
+	assert.True(t, a.data.Valid("position.latitude"))
+	assert.False(t, a.data.Valid("attitude.pitch"), "no XATT yet")
+	assert.False(t, a.data.Valid("apu.switchOn"))
+
+	require.NoError(t, a.applySentenceLocked("XATTMSFS,182.5,4.1,-1.5", time.Now()))
+	assert.True(t, a.data.Valid("attitude.pitch"))
+	assert.Equal(t, fieldsExcept(foreFlightFields), a.data.Invalid)
+}
@@ -8,6 +8,11 @@ export function mockSettingsService() {
         simType: "auto",
         xplaneHost: "127.0.0.1",
         xplanePort: 49000,
+        xplaneLocalPort: 0,
+        xplaneDatarefs: "",
+        xplaneWebPort: 8086,
+        flightGearPort: 5500,
+        foreFlightPort: 49002,
         apiBaseURL: "https://airspace.ferrlab.com",
         localMode: false,
         chatSound: "default",
@@ -25,6 +30,10 @@ export function mockFlightService() {
     StartFlight: () => Promise.resolve(),
     StopFlight: () => Promise.resolve(),
     FinishFlight: () => Promise.resolve(),
+    GetResumableFlight: () => Promise.resolve(null),
+    ResumeFlight: () => Promise.resolve({ gap: 0, distance: 0, fuelChange: 0, problems: [] }),
+    DiscardResumableFlight: () => Promise.resolve(),
+    GetPendingActions: () => Promise.resolve([]),
   };
 }
 
@@ -4,8 +4,9 @@ import { Button } from "@/components/ui/button";
 import { Badge } from "@/components/ui/badge";
 import { Separator } from "@/components/ui/separator";
 import { Slider } from "@/components/ui/slider";
-import { Plug, Unplug, Plane, Square, CheckCircle2 } from "lucide-react";
+import { Plug, Unplug, Plane, Square, CheckCircle2, History, CloudOff } from "lucide-react";
 import { RecordingControls } from "@/components/recording-controls";
+import { RecordingSessions } from "@/components/recording-sessions";
 import { useFlightData } from "@/hooks/use-flight-data";
 import { useDevMode } from "@/hooks/use-dev-mode";
 import { FlightDataService, FlightService } from "../../bindings/airspace-acars";
@@ -25,17 +26,26 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
   const [connectedAdapter, setConnectedAdapter] = useState("");
   const [connecting, setConnecting] = useState(false);
   const isConnected = connectedAdapter !== "";
-  const [flightState, setFlightState] = useState<"idle" | "active">("idle");
+  const [flightState, setFlightState] = useState<"idle" | "active" | "pending-sync">("idle");
+  // A new flight can start while an earlier one is still waiting for the server.
+  const canStart = flightState === "idle" || flightState === "pending-sync";
   const [booking, setBooking] = useState<any>(null);
   const [startingFlight, setStartingFlight] = useState(false);
   const [endingFlight, setEndingFlight] = useState(false);
   const [onGround, setOnGround] = useState(false);
   const [groundSpeed, setGroundSpeed] = useState(0);
+  const [queuedReports, setQueuedReports] = useState(0);
+  const [queuedTotal, setQueuedTotal] = useState(0);
+  const [pendingActions, setPendingActions] = useState<any[]>([]);
+  const [resumable, setResumable] = useState<any>(null);
+  const [resuming, setResuming] = useState(false);
 
   useEffect(() => {
     FlightDataService.ConnectedAdapter().then(setConnectedAdapter).catch(() => {});
     if (!localMode) {
       FlightService.GetFlightState().then((s) => setFlightState(s as any)).catch(() => {});
+      FlightService.GetResumableFlight().then(setResumable).catch(() => {});
+      FlightService.GetPendingActions().then((a) => setPendingActions(a ?? [])).catch(() => {});
     }
 
     const cancelConn = Events.On("connection-state", (event: any) => {
@@ -44,6 +54,20 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
     const cancelFlight = localMode ? () => {} : Events.On("flight-state", (event: any) => {
       setFlightState(event.data);
     });
+    const cancelResumable = localMode ? () => {} : Events.On("flight-resumable", (event: any) => {
+      setResumable(event.data ?? null);
+    });
+    const cancelOutbox = Events.On("outbox-status", (event: any) => {
+      setQueuedReports(event.data?.flight ?? 0);
+      setQueuedTotal(event.data?.pending ?? 0);
+    });
+    const cancelActions = localMode ? () => {} : Events.On("pending-actions", (event: any) => {
+      setPendingActions(event.data ?? []);
+    });
+    const cancelActionFailed = localMode ? () => {} : Events.On("flight-action-failed", (event: any) => {
+      const d = event.data ?? {};
+      alert(t("acars.actionRefused", { callsign: d.callsign, error: d.error }));
+    });
     const cancelData = Events.On("flight-data", (event: any) => {
       const d = event.data;
       if (d?.sensors) setOnGround(d.sensors.onGround ?? false);
@@ -53,9 +77,13 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
     return () => {
       cancelConn();
       cancelFlight();
+      cancelResumable();
+      cancelOutbox();
+      cancelActions();
+      cancelActionFailed();
       cancelData();
     };
-  }, [localMode]);
+  }, [localMode, t]);
 
   const fetchBooking = useCallback(async () => {
     try {
@@ -68,11 +96,11 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
 
   // Poll booking every 10s when idle and connected (skip in local mode)
   useEffect(() => {
-    if (localMode || !isConnected || flightState === "active") return;
+    if (localMode || !isConnected || !canStart || resumable) return;
     fetchBooking();
     const interval = setInterval(fetchBooking, 10_000);
     return () => clearInterval(interval);
-  }, [localMode, isConnected, flightState, fetchBooking]);
+  }, [localMode, isConnected, canStart, resumable, fetchBooking]);
 
   const handleConnect = async () => {
     setConnecting(true);
@@ -111,6 +139,30 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
     }
   };
 
+  const handleResumeFlight = async () => {
+    setResuming(true);
+    try {
+      await FlightService.ResumeFlight();
+      setResumable(null);
+    } catch (e: any) {
+      alert(translateError(t, "Failed to resume flight: " + e));
+    } finally {
+      setResuming(false);
+    }
+  };
+
+  const handleDiscardFlight = async () => {
+    setResuming(true);
+    try {
+      await FlightService.DiscardResumableFlight();
+      setResumable(null);
+    } catch (e: any) {
+      alert(translateError(t, "Failed to cancel flight: " + e));
+    } finally {
+      setResuming(false);
+    }
+  };
+
   const handleStopFlight = async () => {
     setEndingFlight(true);
     try {
@@ -133,6 +185,16 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
     }
   };
 
+  // The finish waits for the track; the pilot may give up on the rest of it.
+  const handleAbandonReports = async () => {
+    if (!confirm(t("acars.abandonReportsConfirm", { count: queuedTotal }))) return;
+    try {
+      await FlightService.AbandonPendingReports();
+    } catch (e: any) {
+      console.error("Failed to abandon reports:", e);
+    }
+  };
+
   const handleVolumeChange = (v: number) => {
     onVolumeChange(v);
   };
@@ -178,10 +240,81 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
         </div>
       )}
 
+      {/* Flight finish or cancellation waiting for the server */}
+      {!localMode && (flightState === "pending-sync" || pendingActions.length > 0) && (
+        <div className="rounded-lg border border-yellow-500/50 bg-yellow-500/5 p-4 space-y-3">
+          <div className="flex items-center gap-2">
+            <CloudOff className="h-4 w-4 text-yellow-500" />
+            <span className="text-sm font-medium">{t("acars.pendingSync")}</span>
+          </div>
+          <p className="text-xs text-muted-foreground">{t("acars.pendingSyncDesc")}</p>
+          {pendingActions.map((a) => (
+            <div key={a.id} className="text-sm">
+              <span className="font-mono font-medium">
+                {t(a.kind === "finish" ? "acars.pendingFinish" : "acars.pendingStop", { callsign: a.callsign })}
+              </span>
+              <span className="text-xs text-muted-foreground block">
+                {t("acars.pendingAttempts", { count: a.attempts, error: a.lastError })}
+              </span>
+            </div>
+          ))}
+          {queuedTotal > 0 && (
+            <div className="flex items-center gap-2">
+              <Badge variant="outline" className="text-xs">
+                {t("acars.queuedReports", { count: queuedTotal })}
+              </Badge>
+              <Button size="sm" variant="outline" onClick={handleAbandonReports}>
+                {t("acars.sendWithoutReports")}
+              </Button>
+            </div>
+          )}
+        </div>
+      )}
+
       {/* Flight Controls */}
       {!localMode && isConnected && (
         <div className="space-y-4">
-          {flightState === "idle" && booking && (
+          {canStart && resumable && (
+            <div className="rounded-lg border border-yellow-500/50 bg-yellow-500/5 p-4 space-y-3">
+              <div className="flex items-center gap-2">
+                <History className="h-4 w-4 text-yellow-500" />
+                <span className="text-sm font-medium">{t("acars.interruptedFlight")}</span>
+              </div>
+              <div className="grid grid-cols-3 gap-4 text-sm">
+                <div>
+                  <span className="text-xs text-muted-foreground block">{t("acars.callsign")}</span>
+                  <span className="font-mono font-medium">{resumable.callsign || "---"}</span>
+                </div>
+                <div>
+                  <span className="text-xs text-muted-foreground block">{t("acars.departure")}</span>
+                  <span className="font-mono font-medium">{resumable.departure || "---"}</span>
+                </div>
+                <div>
+                  <span className="text-xs text-muted-foreground block">{t("acars.arrival")}</span>
+                  <span className="font-mono font-medium">{resumable.arrival || "---"}</span>
+                </div>
+              </div>
+              <p className="text-xs text-muted-foreground">{t("acars.interruptedFlightDesc")}</p>
+              <div className="flex items-center gap-2">
+                <Button size="sm" onClick={handleResumeFlight} disabled={resuming} className="gap-2">
+                  <History className="h-3 w-3" />
+                  {resuming ? t("acars.resuming") : t("acars.resumeFlight")}
+                </Button>
+                <Button
+                  size="sm"
+                  variant="destructive"
+                  onClick={handleDiscardFlight}
+                  disabled={resuming}
+                  className="gap-2"
+                >
+                  <Square className="h-3 w-3" />
+                  {t("acars.cancel")}
+                </Button>
+              </div>
+            </div>
+          )}
+
+          {canStart && !resumable && booking && (
             <div className="rounded-lg border border-border p-4 space-y-3">
               <div className="flex items-center gap-2">
                 <Plane className="h-4 w-4 text-primary" />
@@ -224,7 +357,7 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
             </div>
           )}
 
-          {flightState === "idle" && !booking && (
+          {canStart && !resumable && !booking && (
             <div className="rounded-lg border border-dashed border-border p-4 text-center">
               <p className="text-sm text-muted-foreground">
                 {t("acars.noBooking")}
@@ -238,7 +371,9 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
                 <span className="h-2 w-2 rounded-full bg-green-500 animate-pulse" />
                 <span className="text-sm font-medium">{t("acars.flightActive")}</span>
                 <Badge variant="outline" className="ml-auto text-xs">
-                  {t("acars.positionReporting")}
+                  {queuedReports > 0
+                    ? t("acars.queuedReports", { count: queuedReports })
+                    : t("acars.positionReporting")}
                 </Badge>
               </div>
               <div className="flex items-center gap-2">
@@ -291,6 +426,7 @@ export function AcarsTab({ localMode = false, volume, onVolumeChange }: AcarsTab
       <Separator />
 
       {devMode && <RecordingControls isRecording={isRecording} isConnected={isConnected} />}
+      {devMode && <RecordingSessions isRecording={isRecording} />}
     </div>
   );
 }
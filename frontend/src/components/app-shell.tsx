@@ -14,7 +14,7 @@ export function AppShell() {
   const [localMode, setLocalMode] = useState(false);
   const { hasUnread } = useUnreadChat(activeTab === "chat", localMode);
 
-  const [flightState, setFlightState] = useState<"idle" | "active">("idle");
+  const [flightState, setFlightState] = useState<"idle" | "active" | "pending-sync">("idle");
   const [volume, setVolume] = useState(() => {
     const stored = localStorage.getItem("acars_volume");
     return stored ? parseInt(stored, 10) : 25;
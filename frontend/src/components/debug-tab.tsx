@@ -16,7 +16,17 @@ function BoolBadge({ value }: { value: boolean }) {
   );
 }
 
-function DataTable({ rows }: { rows: { label: string; value: string | React.ReactNode; unit?: string }[] }) {
+// NotAvailable stands in for values the connector cannot supply, so a zero is
+// not mistaken for a reading.
+function NotAvailable() {
+  const { t } = useTranslation();
+  return <span className="text-muted-foreground">{t("debug.notAvailable")}</span>;
+}
+
+function DataTable({ rows, invalid }: {
+  rows: { field?: string; label: string; value: string | React.ReactNode; unit?: string }[];
+  invalid: Set<string>;
+}) {
   return (
     <div className="rounded-md border border-border">
       <table className="w-full text-sm">
@@ -24,7 +34,9 @@ function DataTable({ rows }: { rows: { label: string; value: string | React.Reac
           {rows.map((r, i) => (
             <tr key={i} className="border-b border-border/50 last:border-0">
               <td className="px-3 py-1 font-mono text-xs text-muted-foreground w-[140px]">{r.label}</td>
-              <td className="px-3 py-1 text-right font-mono text-xs tabular-nums">{r.value}</td>
+              <td className="px-3 py-1 text-right font-mono text-xs tabular-nums">
+                {r.field && invalid.has(r.field) ? <NotAvailable /> : r.value}
+              </td>
               {r.unit && <td className="px-2 py-1 text-xs text-muted-foreground w-[50px]">{r.unit}</td>}
             </tr>
           ))}
@@ -38,6 +50,28 @@ function fmt(v: number, d: number = 2): string {
   return v.toFixed(d);
 }
 
+interface ConnectorStats {
+  adapter: string;
+  push: boolean;
+  samples: number;
+  rateHz: number;
+  maxGapMs: number;
+  latencyMs: number;
+  maxLatencyMs: number;
+  rejected: number;
+  rejectedBy: Record<string, number> | null;
+}
+
+interface RecorderStats {
+  queued: number;
+  written: number;
+  dropped: number;
+  failed: number;
+  batches: number;
+  lagMs: number;
+  maxLagMs: number;
+}
+
 export function DebugTab() {
   const { t } = useTranslation();
   const { flightData } = useFlightData();
@@ -59,9 +93,40 @@ export function DebugTab() {
     setUpdateCount((c) => c + 1);
   }, [flightData]);
 
+  const [stats, setStats] = useState<ConnectorStats | null>(null);
+
+  useEffect(() => {
+    if (!isConnected) {
+      setStats(null);
+      return;
+    }
+    const fetchStats = () => {
+      FlightDataService.GetConnectorStats().then((s: any) => setStats(s)).catch(() => {});
+    };
+    fetchStats();
+    const interval = setInterval(fetchStats, 2000);
+    return () => clearInterval(interval);
+  }, [isConnected]);
+
+  const [recorder, setRecorder] = useState<RecorderStats | null>(null);
+
+  useEffect(() => {
+    const fetchRecorder = () => {
+      FlightDataService.GetRecorderStats().then((s: any) => setRecorder(s)).catch(() => {});
+    };
+    fetchRecorder();
+    const interval = setInterval(fetchRecorder, 2000);
+    return () => clearInterval(interval);
+  }, []);
+
   const [copied, setCopied] = useState(false);
 
   const d = flightData;
+  const invalid = useMemo(() => new Set(d?.invalidFields ?? []), [d]);
+
+  function engineValue(i: number, field: string, value: React.ReactNode) {
+    return invalid.has(`engine${i + 1}.${field}`) ? <NotAvailable /> : value;
+  }
 
   const payloadJson = useMemo(() => {
     if (!d) return null;
@@ -97,12 +162,14 @@ export function DebugTab() {
         throttle: m(e.throttlePos, "%"),
         mixture: m(e.mixturePos, "%"),
         propeller: m(e.propPos, "%"),
+        fuelFlow: m(e.fuelFlow ?? 0, "lbs/h"),
       })),
       sensors: {
         onGround: d.sensors.onGround,
         stallWarning: d.sensors.stallWarning,
         overspeedWarning: d.sensors.overspeedWarning,
         simulationRate: m(d.sensors.simulationRate, "x"),
+        pushback: d.sensors.pushback,
       },
       radios: {
         com1: m(d.radios.com1, "MHz"),
@@ -136,6 +203,8 @@ export function DebugTab() {
         flaps: m(d.controls.flaps, "%"),
         spoilers: m(d.controls.spoilers, "%"),
         gearDown: d.controls.gearDown,
+        flapsDetent: m(d.controls.flapsDetent ?? 0, ""),
+        parkingBrake: d.controls.parkingBrake,
       },
       apu: {
         switchOn: d.apu.switchOn,
@@ -159,10 +228,24 @@ export function DebugTab() {
       weight: {
         total: m(d.weight?.totalWeight ?? 0, "lbs"),
         fuel: m(d.weight?.fuelWeight ?? 0, "lbs"),
+        tanks: Object.fromEntries(["left", "center", "right", "auxiliary"].map((tank, i) =>
+          [tank, m(d.weight?.fuelTanks?.[i] ?? 0, "lbs")])),
+      },
+      environment: {
+        oat: m(d.environment?.oat ?? 0, "C"),
+        windDirection: m(d.environment?.windDirection ?? 0, "deg"),
+        windSpeed: m(d.environment?.windSpeed ?? 0, "kts"),
+        qnh: m(d.environment?.qnh ?? 0, "hPa"),
+        visibility: m(d.environment?.visibility ?? 0, "m"),
+      },
+      dataQuality: {
+        invalidFields: d.invalidFields ?? [],
+        sampleRate: m(stats?.rateHz ?? 0, "Hz"),
+        latency: m(stats?.latencyMs ?? 0, "ms"),
       },
     };
     return JSON.stringify(payload, null, 2);
-  }, [d]);
+  }, [d, stats]);
 
   function handleCopy() {
     if (!payloadJson) return;
@@ -193,43 +276,84 @@ export function DebugTab() {
 
       <Separator />
 
+      {stats && (
+        <div className="space-y-3">
+          <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.connector")}</h3>
+          <DataTable invalid={invalid} rows={[
+            { label: "Adapter", value: stats.adapter },
+            { label: "Mode", value: stats.push ? t("debug.push") : t("debug.polled") },
+            { label: "Samples", value: String(stats.samples) },
+            { label: "Rate", value: fmt(stats.rateHz, 1), unit: "Hz" },
+            { label: "Max Gap", value: fmt(stats.maxGapMs, 0), unit: "ms" },
+            { label: "Latency", value: fmt(stats.latencyMs, 1), unit: "ms" },
+            { label: "Max Latency", value: fmt(stats.maxLatencyMs, 1), unit: "ms" },
+            {
+              label: "Rejected",
+              value: Object.entries(stats.rejectedBy ?? {}).map(([reason, n]) => `${reason} ${n}`).join(", ") || String(stats.rejected),
+            },
+          ]} />
+          {invalid.size > 0 && (
+            <p className="text-xs text-muted-foreground">
+              {t("debug.unsupportedFields", { count: invalid.size })}{" "}
+              <span className="font-mono">{[...invalid].join(", ")}</span>
+            </p>
+          )}
+        </div>
+      )}
+
+      {recorder && recorder.batches + recorder.dropped + recorder.failed > 0 && (
+        <div className="space-y-3">
+          <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.recorder")}</h3>
+          <DataTable invalid={invalid} rows={[
+            { label: "Queued", value: String(recorder.queued) },
+            { label: "Written", value: String(recorder.written) },
+            { label: "Batches", value: String(recorder.batches) },
+            { label: "Dropped", value: String(recorder.dropped) },
+            { label: "Failed", value: String(recorder.failed) },
+            { label: "Lag", value: fmt(recorder.lagMs, 0), unit: "ms" },
+            { label: "Max Lag", value: fmt(recorder.maxLagMs, 0), unit: "ms" },
+          ]} />
+        </div>
+      )}
+
       {!d ? (
         <p className="text-sm text-muted-foreground">{t("debug.waitingForData")}</p>
       ) : (
         <div className="grid grid-cols-2 gap-4">
           <div className="space-y-3">
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.position")}</h3>
-            <DataTable rows={[
-              { label: "Latitude", value: fmt(d.position.latitude, 6), unit: "deg" },
-              { label: "Longitude", value: fmt(d.position.longitude, 6), unit: "deg" },
-              { label: "Altitude", value: fmt(d.position.altitude, 0), unit: "ft" },
-              { label: "AGL", value: fmt(d.position.altitudeAGL, 0), unit: "ft" },
+            <DataTable invalid={invalid} rows={[
+              { field: "position.latitude", label: "Latitude", value: fmt(d.position.latitude, 6), unit: "deg" },
+              { field: "position.longitude", label: "Longitude", value: fmt(d.position.longitude, 6), unit: "deg" },
+              { field: "position.altitude", label: "Altitude", value: fmt(d.position.altitude, 0), unit: "ft" },
+              { field: "position.altitudeAGL", label: "AGL", value: fmt(d.position.altitudeAGL, 0), unit: "ft" },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.attitudeSpeed")}</h3>
-            <DataTable rows={[
-              { label: "Pitch", value: fmt(d.attitude.pitch), unit: "deg" },
-              { label: "Roll", value: fmt(d.attitude.roll), unit: "deg" },
-              { label: "Heading True", value: fmt(d.attitude.headingTrue, 1), unit: "deg" },
-              { label: "Heading Mag", value: fmt(d.attitude.headingMag, 1), unit: "deg" },
-              { label: "VS", value: fmt(d.attitude.vs, 0), unit: "fpm" },
-              { label: "IAS", value: fmt(d.attitude.ias, 1), unit: "kts" },
-              { label: "TAS", value: fmt(d.attitude.tas, 1), unit: "kts" },
-              { label: "GS", value: fmt(d.attitude.gs, 1), unit: "kts" },
+            <DataTable invalid={invalid} rows={[
+              { field: "attitude.pitch", label: "Pitch", value: fmt(d.attitude.pitch), unit: "deg" },
+              { field: "attitude.roll", label: "Roll", value: fmt(d.attitude.roll), unit: "deg" },
+              { field: "attitude.headingTrue", label: "Heading True", value: fmt(d.attitude.headingTrue, 1), unit: "deg" },
+              { field: "attitude.headingMag", label: "Heading Mag", value: fmt(d.attitude.headingMag, 1), unit: "deg" },
+              { field: "attitude.vs", label: "VS", value: fmt(d.attitude.vs, 0), unit: "fpm" },
+              { field: "attitude.ias", label: "IAS", value: fmt(d.attitude.ias, 1), unit: "kts" },
+              { field: "attitude.tas", label: "TAS", value: fmt(d.attitude.tas, 1), unit: "kts" },
+              { field: "attitude.gs", label: "GS", value: fmt(d.attitude.gs, 1), unit: "kts" },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.sensors")}</h3>
-            <DataTable rows={[
-              { label: "On Ground", value: <BoolBadge value={d.sensors.onGround} /> },
-              { label: "Stall Warning", value: <BoolBadge value={d.sensors.stallWarning} /> },
-              { label: "Overspeed", value: <BoolBadge value={d.sensors.overspeedWarning} /> },
+            <DataTable invalid={invalid} rows={[
+              { field: "sensors.onGround", label: "On Ground", value: <BoolBadge value={d.sensors.onGround} /> },
+              { field: "sensors.stallWarning", label: "Stall Warning", value: <BoolBadge value={d.sensors.stallWarning} /> },
+              { field: "sensors.overspeedWarning", label: "Overspeed", value: <BoolBadge value={d.sensors.overspeedWarning} /> },
+              { field: "sensors.pushback", label: "Pushback", value: <BoolBadge value={d.sensors.pushback} /> },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.lights")}</h3>
-            <DataTable rows={[
-              { label: "Beacon", value: <BoolBadge value={d.lights.beacon} /> },
-              { label: "Strobe", value: <BoolBadge value={d.lights.strobe} /> },
-              { label: "Landing", value: <BoolBadge value={d.lights.landing} /> },
+            <DataTable invalid={invalid} rows={[
+              { field: "lights.beacon", label: "Beacon", value: <BoolBadge value={d.lights.beacon} /> },
+              { field: "lights.strobe", label: "Strobe", value: <BoolBadge value={d.lights.strobe} /> },
+              { field: "lights.landing", label: "Landing", value: <BoolBadge value={d.lights.landing} /> },
             ]} />
           </div>
 
@@ -246,18 +370,20 @@ export function DebugTab() {
                     <th className="px-2 py-1 text-right text-[10px] font-medium text-muted-foreground">Thr%</th>
                     <th className="px-2 py-1 text-right text-[10px] font-medium text-muted-foreground">Mix%</th>
                     <th className="px-2 py-1 text-right text-[10px] font-medium text-muted-foreground">Prop%</th>
+                    <th className="px-2 py-1 text-right text-[10px] font-medium text-muted-foreground">FF lbs/h</th>
                   </tr>
                 </thead>
                 <tbody>
                   {d.engines.map((eng, i) => (
                     <tr key={i} className="border-b border-border/50 last:border-0">
                       <td className="px-2 py-1 font-mono text-xs">{i + 1}</td>
-                      <td className="px-2 py-1 text-right"><BoolBadge value={eng.running} /></td>
-                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{fmt(eng.n1, 1)}</td>
-                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{fmt(eng.n2, 1)}</td>
-                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{fmt(eng.throttlePos, 0)}</td>
-                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{fmt(eng.mixturePos, 0)}</td>
-                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{fmt(eng.propPos, 0)}</td>
+                      <td className="px-2 py-1 text-right">{engineValue(i, "running", <BoolBadge value={eng.running} />)}</td>
+                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{engineValue(i, "n1", fmt(eng.n1, 1))}</td>
+                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{engineValue(i, "n2", fmt(eng.n2, 1))}</td>
+                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{engineValue(i, "throttlePos", fmt(eng.throttlePos, 0))}</td>
+                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{engineValue(i, "mixturePos", fmt(eng.mixturePos, 0))}</td>
+                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{engineValue(i, "propPos", fmt(eng.propPos, 0))}</td>
+                      <td className="px-2 py-1 text-right font-mono text-xs tabular-nums">{engineValue(i, "fuelFlow", fmt(eng.fuelFlow ?? 0, 0))}</td>
                     </tr>
                   ))}
                 </tbody>
@@ -265,65 +391,83 @@ export function DebugTab() {
             </div>
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.radios")}</h3>
-            <DataTable rows={[
-              { label: "COM1", value: fmt(d.radios.com1, 3), unit: "MHz" },
-              { label: "COM2", value: fmt(d.radios.com2, 3), unit: "MHz" },
-              { label: "NAV1", value: fmt(d.radios.nav1, 2), unit: "MHz" },
-              { label: "NAV2", value: fmt(d.radios.nav2, 2), unit: "MHz" },
-              { label: "NAV1 OBS", value: fmt(d.radios.nav1OBS, 0), unit: "deg" },
-              { label: "NAV2 OBS", value: fmt(d.radios.nav2OBS, 0), unit: "deg" },
-              { label: "XPDR Code", value: fmt(d.radios.xpdrCode, 0) },
-              { label: "XPDR State", value: d.radios.xpdrState || "—" },
+            <DataTable invalid={invalid} rows={[
+              { field: "radios.com1", label: "COM1", value: fmt(d.radios.com1, 3), unit: "MHz" },
+              { field: "radios.com2", label: "COM2", value: fmt(d.radios.com2, 3), unit: "MHz" },
+              { field: "radios.nav1", label: "NAV1", value: fmt(d.radios.nav1, 2), unit: "MHz" },
+              { field: "radios.nav2", label: "NAV2", value: fmt(d.radios.nav2, 2), unit: "MHz" },
+              { field: "radios.nav1OBS", label: "NAV1 OBS", value: fmt(d.radios.nav1OBS, 0), unit: "deg" },
+              { field: "radios.nav2OBS", label: "NAV2 OBS", value: fmt(d.radios.nav2OBS, 0), unit: "deg" },
+              { field: "radios.xpdrCode", label: "XPDR Code", value: fmt(d.radios.xpdrCode, 0) },
+              { field: "radios.xpdrState", label: "XPDR State", value: d.radios.xpdrState || "—" },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.autopilot")}</h3>
-            <DataTable rows={[
-              { label: "Master", value: <BoolBadge value={d.autopilot.master} /> },
-              { label: "Heading", value: fmt(d.autopilot.heading, 0), unit: "deg" },
-              { label: "Altitude", value: fmt(d.autopilot.altitude, 0), unit: "ft" },
-              { label: "VS", value: fmt(d.autopilot.vs, 0), unit: "fpm" },
-              { label: "Speed", value: fmt(d.autopilot.speed, 0), unit: "kts" },
-              { label: "Approach", value: <BoolBadge value={d.autopilot.approachHold} /> },
-              { label: "NAV Lock", value: <BoolBadge value={d.autopilot.navLock} /> },
+            <DataTable invalid={invalid} rows={[
+              { field: "autopilot.master", label: "Master", value: <BoolBadge value={d.autopilot.master} /> },
+              { field: "autopilot.heading", label: "Heading", value: fmt(d.autopilot.heading, 0), unit: "deg" },
+              { field: "autopilot.altitude", label: "Altitude", value: fmt(d.autopilot.altitude, 0), unit: "ft" },
+              { field: "autopilot.vs", label: "VS", value: fmt(d.autopilot.vs, 0), unit: "fpm" },
+              { field: "autopilot.speed", label: "Speed", value: fmt(d.autopilot.speed, 0), unit: "kts" },
+              { field: "autopilot.approachHold", label: "Approach", value: <BoolBadge value={d.autopilot.approachHold} /> },
+              { field: "autopilot.navLock", label: "NAV Lock", value: <BoolBadge value={d.autopilot.navLock} /> },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.controls")}</h3>
-            <DataTable rows={[
-              { label: "Elevator", value: fmt(d.controls.elevator, 3) },
-              { label: "Aileron", value: fmt(d.controls.aileron, 3) },
-              { label: "Rudder", value: fmt(d.controls.rudder, 3) },
-              { label: "Flaps", value: fmt(d.controls.flaps, 0), unit: "%" },
-              { label: "Spoilers", value: fmt(d.controls.spoilers, 0), unit: "%" },
-              { label: "Gear Down", value: <BoolBadge value={d.controls.gearDown} /> },
+            <DataTable invalid={invalid} rows={[
+              { field: "controls.elevator", label: "Elevator", value: fmt(d.controls.elevator, 3) },
+              { field: "controls.aileron", label: "Aileron", value: fmt(d.controls.aileron, 3) },
+              { field: "controls.rudder", label: "Rudder", value: fmt(d.controls.rudder, 3) },
+              { field: "controls.flaps", label: "Flaps", value: fmt(d.controls.flaps, 0), unit: "%" },
+              { field: "controls.spoilers", label: "Spoilers", value: fmt(d.controls.spoilers, 0), unit: "%" },
+              { field: "controls.gearDown", label: "Gear Down", value: <BoolBadge value={d.controls.gearDown} /> },
+              { field: "controls.flapsDetent", label: "Flaps Detent", value: fmt(d.controls.flapsDetent ?? 0, 0) },
+              { field: "controls.parkingBrake", label: "Parking Brake", value: <BoolBadge value={d.controls.parkingBrake} /> },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.apu")}</h3>
-            <DataTable rows={[
-              { label: "Switch", value: <BoolBadge value={d.apu.switchOn} /> },
-              { label: "RPM", value: fmt(d.apu.rpmPercent, 1), unit: "%" },
-              { label: "Gen Switch", value: <BoolBadge value={d.apu.genSwitch} /> },
-              { label: "Gen Active", value: <BoolBadge value={d.apu.genActive} /> },
+            <DataTable invalid={invalid} rows={[
+              { field: "apu.switchOn", label: "Switch", value: <BoolBadge value={d.apu.switchOn} /> },
+              { field: "apu.rpmPercent", label: "RPM", value: fmt(d.apu.rpmPercent, 1), unit: "%" },
+              { field: "apu.genSwitch", label: "Gen Switch", value: <BoolBadge value={d.apu.genSwitch} /> },
+              { field: "apu.genActive", label: "Gen Active", value: <BoolBadge value={d.apu.genActive} /> },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.doors")}</h3>
-            <DataTable rows={d.doors.map((door, i) => ({
+            <DataTable invalid={invalid} rows={d.doors.map((door, i) => ({
+              field: `door${i + 1}.openRatio`,
               label: `Door ${i}`,
               value: fmt(door.openRatio * 100, 0),
               unit: "%",
             }))} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.weight")}</h3>
-            <DataTable rows={[
-              { label: "Total", value: fmt(d.weight?.totalWeight ?? 0, 0), unit: "lbs" },
-              { label: "Fuel", value: fmt(d.weight?.fuelWeight ?? 0, 0), unit: "lbs" },
+            <DataTable invalid={invalid} rows={[
+              { field: "weight.totalWeight", label: "Total", value: fmt(d.weight?.totalWeight ?? 0, 0), unit: "lbs" },
+              { field: "weight.fuelWeight", label: "Fuel", value: fmt(d.weight?.fuelWeight ?? 0, 0), unit: "lbs" },
+              ...["Left", "Center", "Right", "Aux"].map((tank, i) => ({
+                field: `weight.fuelTank${i + 1}`,
+                label: `Fuel ${tank}`,
+                value: fmt(d.weight?.fuelTanks?.[i] ?? 0, 0),
+                unit: "lbs",
+              })),
+            ]} />
+
+            <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.environment")}</h3>
+            <DataTable invalid={invalid} rows={[
+              { field: "environment.oat", label: "OAT", value: fmt(d.environment?.oat ?? 0, 1), unit: "°C" },
+              { field: "environment.windDirection", label: "Wind Dir", value: fmt(d.environment?.windDirection ?? 0, 0), unit: "deg" },
+              { field: "environment.windSpeed", label: "Wind Speed", value: fmt(d.environment?.windSpeed ?? 0, 0), unit: "kts" },
+              { field: "environment.qnh", label: "QNH", value: fmt(d.environment?.qnh ?? 0, 0), unit: "hPa" },
+              { field: "environment.visibility", label: "Visibility", value: fmt(d.environment?.visibility ?? 0, 0), unit: "m" },
             ]} />
 
             <h3 className="text-xs font-semibold uppercase tracking-wider text-muted-foreground">{t("debug.misc")}</h3>
-            <DataTable rows={[
-              { label: "Aircraft", value: d.aircraftName || "—" },
-              { label: "Altimeter", value: fmt(d.altimeterInHg, 2), unit: "inHg" },
-              { label: "Zulu Time", value: fmt(d.simTime.zuluTime, 0), unit: "sec" },
-              { label: "Local Time", value: fmt(d.simTime.localTime, 0), unit: "sec" },
+            <DataTable invalid={invalid} rows={[
+              { field: "aircraftName", label: "Aircraft", value: d.aircraftName || "—" },
+              { field: "altimeterInHg", label: "Altimeter", value: fmt(d.altimeterInHg, 2), unit: "inHg" },
+              { field: "simTime.zuluTime", label: "Zulu Time", value: fmt(d.simTime.zuluTime, 0), unit: "sec" },
+              { field: "simTime.localTime", label: "Local Time", value: fmt(d.simTime.localTime, 0), unit: "sec" },
             ]} />
           </div>
         </div>
@@ -0,0 +1,126 @@
+import { useState, useEffect, useCallback } from "react";
+import { useTranslation } from "react-i18next";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Download, Trash2 } from "lucide-react";
+import { FlightDataService, SettingsService } from "../../bindings/airspace-acars";
+
+interface RecordingSessionsProps {
+  isRecording: boolean;
+}
+
+export function RecordingSessions({ isRecording }: RecordingSessionsProps) {
+  const { t } = useTranslation();
+  const [sessions, setSessions] = useState<any[]>([]);
+  const [retention, setRetention] = useState("");
+
+  const refresh = useCallback(() => {
+    FlightDataService.ListSessions().then((s) => setSessions(s ?? [])).catch(() => {});
+  }, []);
+
+  useEffect(() => {
+    refresh();
+  }, [isRecording, refresh]);
+
+  useEffect(() => {
+    SettingsService.GetSettings()
+      .then((s) => setRetention(s.recordingRetention ? String(s.recordingRetention) : ""))
+      .catch(() => {});
+  }, []);
+
+  const handleRetentionBlur = async () => {
+    try {
+      const settings = await SettingsService.GetSettings();
+      await SettingsService.UpdateSettings({ ...settings, recordingRetention: parseInt(retention, 10) || 0 });
+    } catch { /* ignore */ }
+  };
+
+  const handleExport = async (id: number) => {
+    try {
+      const filePath = prompt(t("recording.exportPrompt"), `flight_data_${id}.csv`);
+      if (!filePath) return;
+      await FlightDataService.ExportSession(id, filePath);
+      alert(t("recording.exportSuccess"));
+    } catch (e: any) {
+      alert(t("recording.exportFailed", { error: String(e) }));
+    }
+  };
+
+  const handleDelete = async (id: number) => {
+    if (!confirm(t("recording.deleteConfirm"))) return;
+    try {
+      await FlightDataService.DeleteSession(id);
+    } catch (e: any) {
+      alert(t("recording.deleteFailed", { error: String(e) }));
+    }
+    refresh();
+  };
+
+  const formatDuration = (secs: number) => {
+    const h = Math.floor(secs / 3600);
+    const m = Math.floor((secs % 3600) / 60).toString().padStart(2, "0");
+    return `${h}:${m}`;
+  };
+
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center justify-between gap-4">
+        <p className="text-sm font-medium">{t("recording.sessions")}</p>
+        <div className="flex items-center gap-2">
+          <span className="text-xs text-muted-foreground">{t("recording.retention")}</span>
+          <Input
+            value={retention}
+            onChange={(e) => setRetention(e.target.value.replace(/\D/g, ""))}
+            onBlur={handleRetentionBlur}
+            placeholder={t("recording.retentionForever")}
+            className="w-[110px] h-7 font-mono text-xs"
+          />
+        </div>
+      </div>
+      {sessions.length === 0 ? (
+        <p className="text-xs text-muted-foreground">{t("recording.noSessions")}</p>
+      ) : (
+        <div className="rounded-md border border-border divide-y divide-border/50">
+          {sessions.map((s) => (
+            <div key={s.id} className="flex items-center gap-3 px-3 py-1.5 text-xs">
+              <div className="flex-1 min-w-0">
+                <p className="font-medium truncate">
+                  {s.callsign ? `${s.callsign} ${s.departure}–${s.arrival}` : t("recording.untitled")}
+                  {s.aircraft && <span className="text-muted-foreground"> · {s.aircraft}</span>}
+                </p>
+                <p className="text-muted-foreground tabular-nums">
+                  {new Date(s.startTime).toLocaleString()} · {formatDuration(s.duration)} ·{" "}
+                  {t("recording.summary", {
+                    distance: Math.round(s.distance),
+                    altitude: Math.round(s.maxAltitude),
+                    count: s.samples,
+                  })}
+                </p>
+              </div>
+              <Button
+                size="sm"
+                variant="ghost"
+                className="h-7 w-7 p-0"
+                title={t("recording.exportCsv")}
+                onClick={() => handleExport(s.id)}
+                disabled={!s.endTime}
+              >
+                <Download className="h-3 w-3" />
+              </Button>
+              <Button
+                size="sm"
+                variant="ghost"
+                className="h-7 w-7 p-0"
+                title={t("recording.delete")}
+                onClick={() => handleDelete(s.id)}
+                disabled={!s.endTime}
+              >
+                <Trash2 className="h-3 w-3" />
+              </Button>
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
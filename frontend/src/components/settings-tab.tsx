@@ -8,8 +8,8 @@ import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@
 import { Separator } from "@/components/ui/separator";
 import { Button } from "@/components/ui/button";
 import { Badge } from "@/components/ui/badge";
-import { Volume2 } from "lucide-react";
-import { SettingsService, UpdateService, DiscordService } from "../../bindings/airspace-acars";
+import { RefreshCw, Volume2 } from "lucide-react";
+import { SettingsService, UpdateService, DiscordService, FlightDataService } from "../../bindings/airspace-acars";
 import { useDevMode } from "@/hooks/use-dev-mode";
 import { CHAT_SOUNDS, playNotificationPreview, type ChatSoundType } from "@/lib/notification-sounds";
 import { LANGUAGES } from "@/lib/i18n";
@@ -27,6 +27,13 @@ export function SettingsTab({ localMode = false, onLocalModeChange }: SettingsTa
   const [chatSound, setChatSound] = useState<ChatSoundType>("default");
   const [discordPresence, setDiscordPresence] = useState(true);
   const [apiBaseURL, setApiBaseURL] = useState("");
+  const [xplaneHost, setXplaneHost] = useState("");
+  const [xplanePort, setXplanePort] = useState("");
+  const [xplaneLocalPort, setXplaneLocalPort] = useState("");
+  const [xplaneDatarefs, setXplaneDatarefs] = useState("");
+  const [xplaneWebPort, setXplaneWebPort] = useState("");
+  const [flightGearPort, setFlightGearPort] = useState("");
+  const [foreFlightPort, setForeFlightPort] = useState("");
   const [language, setLanguage] = useState(i18n.language);
   const [loaded, setLoaded] = useState(false);
 
@@ -38,6 +45,13 @@ export function SettingsTab({ localMode = false, onLocalModeChange }: SettingsTa
         setChatSound((settings.chatSound as ChatSoundType) || "default");
         setDiscordPresence(settings.discordPresence !== false);
         setApiBaseURL(settings.apiBaseURL);
+        setXplaneHost(settings.xplaneHost);
+        setXplanePort(String(settings.xplanePort || ""));
+        setXplaneLocalPort(settings.xplaneLocalPort ? String(settings.xplaneLocalPort) : "");
+        setXplaneDatarefs(settings.xplaneDatarefs || "");
+        setXplaneWebPort(String(settings.xplaneWebPort || ""));
+        setFlightGearPort(String(settings.flightGearPort || ""));
+        setForeFlightPort(String(settings.foreFlightPort || ""));
         if (settings.language) setLanguage(settings.language);
         if (settings.theme === "light" || settings.theme === "dark") {
           setTheme(settings.theme);
@@ -93,6 +107,45 @@ export function SettingsTab({ localMode = false, onLocalModeChange }: SettingsTa
     } catch { /* ignore */ }
   };
 
+  const handleXplaneBlur = async () => {
+    try {
+      const settings = await SettingsService.GetSettings();
+      await SettingsService.UpdateSettings({
+        ...settings,
+        xplaneHost: xplaneHost.trim(),
+        xplanePort: parseInt(xplanePort, 10) || 0,
+        xplaneLocalPort: parseInt(xplaneLocalPort, 10) || 0,
+        xplaneDatarefs: xplaneDatarefs.trim(),
+        xplaneWebPort: parseInt(xplaneWebPort, 10) || 0,
+      });
+    } catch { /* ignore */ }
+  };
+
+  const handleFlightGearBlur = async () => {
+    try {
+      const settings = await SettingsService.GetSettings();
+      await SettingsService.UpdateSettings({ ...settings, flightGearPort: parseInt(flightGearPort, 10) || 0 });
+    } catch { /* ignore */ }
+  };
+
+  const handleForeFlightBlur = async () => {
+    try {
+      const settings = await SettingsService.GetSettings();
+      await SettingsService.UpdateSettings({ ...settings, foreFlightPort: parseInt(foreFlightPort, 10) || 0 });
+    } catch { /* ignore */ }
+  };
+
+  const handleInstallFlightGearProtocol = async () => {
+    try {
+      const fgRoot = prompt(t("settings.flightGearInstallPrompt"));
+      if (!fgRoot) return;
+      const path = await FlightDataService.InstallFlightGearProtocol(fgRoot.trim());
+      alert(t("settings.flightGearInstalled", { path }));
+    } catch (e: any) {
+      alert(t("settings.flightGearInstallFailed", { error: String(e) }));
+    }
+  };
+
   const handleLocalModeToggle = async (checked: boolean) => {
     try {
       const settings = await SettingsService.GetSettings();
@@ -274,9 +327,147 @@ export function SettingsTab({ localMode = false, onLocalModeChange }: SettingsTa
                 <SelectItem value="auto">{t("settings.simAuto")}</SelectItem>
                 <SelectItem value="simconnect">{t("settings.simSimconnect")}</SelectItem>
                 <SelectItem value="xplane">{t("settings.simXplane")}</SelectItem>
+                <SelectItem value="xplaneweb">{t("settings.simXplaneWeb")}</SelectItem>
+                <SelectItem value="flightgear">{t("settings.simFlightGear")}</SelectItem>
+                <SelectItem value="foreflight">{t("settings.simForeFlight")}</SelectItem>
+                <SelectItem value="replay">{t("settings.simReplay")}</SelectItem>
+                <SelectItem value="synthetic">{t("settings.simSynthetic")}</SelectItem>
               </SelectContent>
             </Select>
           </div>
+          {(simType === "auto" || simType === "xplane" || simType === "xplaneweb") && (
+            <>
+              <div className="flex items-center justify-between gap-4">
+                <div className="shrink-0">
+                  <p className="text-sm font-medium">{t("settings.xplaneHost")}</p>
+                  <p className="text-xs text-muted-foreground">
+                    {t("settings.xplaneHostDesc")}
+                  </p>
+                </div>
+                <Input
+                  value={xplaneHost}
+                  onChange={(e) => setXplaneHost(e.target.value)}
+                  onBlur={handleXplaneBlur}
+                  placeholder="127.0.0.1"
+                  className="max-w-[180px] font-mono text-xs"
+                />
+              </div>
+              {simType !== "xplaneweb" && (
+                <>
+                  <div className="flex items-center justify-between gap-4">
+                    <div className="shrink-0">
+                      <p className="text-sm font-medium">{t("settings.xplanePort")}</p>
+                      <p className="text-xs text-muted-foreground">
+                        {t("settings.xplanePortDesc")}
+                      </p>
+                    </div>
+                    <Input
+                      value={xplanePort}
+                      onChange={(e) => setXplanePort(e.target.value.replace(/\D/g, ""))}
+                      onBlur={handleXplaneBlur}
+                      placeholder="49000"
+                      className="max-w-[180px] font-mono text-xs"
+                    />
+                  </div>
+                  <div className="flex items-center justify-between gap-4">
+                    <div className="shrink-0">
+                      <p className="text-sm font-medium">{t("settings.xplaneLocalPort")}</p>
+                      <p className="text-xs text-muted-foreground">
+                        {t("settings.xplaneLocalPortDesc")}
+                      </p>
+                    </div>
+                    <Input
+                      value={xplaneLocalPort}
+                      onChange={(e) => setXplaneLocalPort(e.target.value.replace(/\D/g, ""))}
+                      onBlur={handleXplaneBlur}
+                      placeholder={t("settings.xplaneLocalPortAuto")}
+                      className="max-w-[180px] font-mono text-xs"
+                    />
+                  </div>
+                </>
+              )}
+              {(simType === "auto" || simType === "xplaneweb") && (
+                <div className="flex items-center justify-between gap-4">
+                  <div className="shrink-0">
+                    <p className="text-sm font-medium">{t("settings.xplaneWebPort")}</p>
+                    <p className="text-xs text-muted-foreground">
+                      {t("settings.xplaneWebPortDesc")}
+                    </p>
+                  </div>
+                  <Input
+                    value={xplaneWebPort}
+                    onChange={(e) => setXplaneWebPort(e.target.value.replace(/\D/g, ""))}
+                    onBlur={handleXplaneBlur}
+                    placeholder="8086"
+                    className="max-w-[180px] font-mono text-xs"
+                  />
+                </div>
+              )}
+              <div className="flex items-center justify-between gap-4">
+                <div className="shrink-0">
+                  <p className="text-sm font-medium">{t("settings.xplaneDatarefs")}</p>
+                  <p className="text-xs text-muted-foreground">
+                    {t("settings.xplaneDatarefsDesc")}
+                  </p>
+                </div>
+                <Input
+                  value={xplaneDatarefs}
+                  onChange={(e) => setXplaneDatarefs(e.target.value)}
+                  onBlur={handleXplaneBlur}
+                  placeholder={t("settings.xplaneDatarefsNone")}
+                  className="max-w-[180px] font-mono text-xs"
+                />
+              </div>
+              {simType !== "xplaneweb" && <XPlaneInstances />}
+            </>
+          )}
+          {simType === "flightgear" && (
+            <>
+              <div className="flex items-center justify-between gap-4">
+                <div className="shrink-0">
+                  <p className="text-sm font-medium">{t("settings.flightGearPort")}</p>
+                  <p className="text-xs text-muted-foreground">
+                    {t("settings.flightGearPortDesc")}
+                  </p>
+                </div>
+                <Input
+                  value={flightGearPort}
+                  onChange={(e) => setFlightGearPort(e.target.value.replace(/\D/g, ""))}
+                  onBlur={handleFlightGearBlur}
+                  placeholder="5500"
+                  className="max-w-[180px] font-mono text-xs"
+                />
+              </div>
+              <div className="flex items-center justify-between gap-4">
+                <div>
+                  <p className="text-sm font-medium">{t("settings.flightGearProtocol")}</p>
+                  <p className="text-xs text-muted-foreground">
+                    {t("settings.flightGearProtocolDesc", { port: flightGearPort || "5500" })}
+                  </p>
+                </div>
+                <Button variant="outline" size="sm" onClick={handleInstallFlightGearProtocol}>
+                  {t("settings.flightGearInstall")}
+                </Button>
+              </div>
+            </>
+          )}
+          {simType === "foreflight" && (
+            <div className="flex items-center justify-between gap-4">
+              <div className="shrink-0">
+                <p className="text-sm font-medium">{t("settings.foreFlightPort")}</p>
+                <p className="text-xs text-muted-foreground">
+                  {t("settings.foreFlightPortDesc")}
+                </p>
+              </div>
+              <Input
+                value={foreFlightPort}
+                onChange={(e) => setForeFlightPort(e.target.value.replace(/\D/g, ""))}
+                onBlur={handleForeFlightBlur}
+                placeholder="49002"
+                className="max-w-[180px] font-mono text-xs"
+              />
+            </div>
+          )}
         </CardContent>
       </Card>
 
@@ -285,6 +476,78 @@ export function SettingsTab({ localMode = false, onLocalModeChange }: SettingsTa
   );
 }
 
+interface XPlaneInstance {
+  host: string;
+  port: number;
+  computerName: string;
+  role: string;
+}
+
+function XPlaneInstances() {
+  const { t } = useTranslation();
+  const [instances, setInstances] = useState<XPlaneInstance[]>([]);
+  const [searching, setSearching] = useState(false);
+  const [connecting, setConnecting] = useState("");
+  const [error, setError] = useState("");
+
+  const search = async () => {
+    setSearching(true);
+    try {
+      setInstances(((await FlightDataService.GetXPlaneInstances()) ?? []) as XPlaneInstance[]);
+    } catch { /* ignore */ }
+    setSearching(false);
+  };
+
+  useEffect(() => { search(); }, []);
+
+  const connect = async (inst: XPlaneInstance) => {
+    const key = `${inst.host}:${inst.port}`;
+    setConnecting(key);
+    setError("");
+    try {
+      await FlightDataService.ConnectXPlaneInstance(inst.host, inst.port);
+    } catch (e: any) {
+      setError(e?.message ?? String(e));
+    }
+    setConnecting("");
+  };
+
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center justify-between">
+        <div>
+          <p className="text-sm font-medium">{t("settings.xplaneDiscovered")}</p>
+          <p className="text-xs text-muted-foreground">{t("settings.xplaneDiscoveredDesc")}</p>
+        </div>
+        <Button variant="outline" size="icon" className="h-9 w-9" onClick={search} disabled={searching}>
+          <RefreshCw className={`h-4 w-4 ${searching ? "animate-spin" : ""}`} />
+        </Button>
+      </div>
+      {instances.length === 0 && !searching && (
+        <p className="text-xs text-muted-foreground">{t("settings.xplaneNoneFound")}</p>
+      )}
+      {instances.map((inst) => {
+        const key = `${inst.host}:${inst.port}`;
+        return (
+          <div key={key} className="flex items-center justify-between gap-4 rounded-md border border-border/50 px-3 py-2">
+            <div className="min-w-0">
+              <p className="truncate text-sm">{inst.computerName || inst.host}</p>
+              <p className="font-mono text-xs text-muted-foreground">{key}</p>
+            </div>
+            <div className="flex items-center gap-2">
+              <Badge variant="secondary">{t(`settings.xplaneRole.${inst.role}`, inst.role)}</Badge>
+              <Button size="sm" onClick={() => connect(inst)} disabled={connecting !== ""}>
+                {connecting === key ? t("settings.xplaneConnecting") : t("settings.xplaneConnect")}
+              </Button>
+            </div>
+          </div>
+        );
+      })}
+      {error && <p className="text-xs text-destructive">{error}</p>}
+    </div>
+  );
+}
+
 type UpdateStatus = "idle" | "checking" | "up-to-date" | "update-available" | "downloading" | "done";
 
 function AboutSection() {
@@ -28,6 +28,7 @@ export interface EngineData {
   throttlePos: number;
   mixturePos: number;
   propPos: number;
+  fuelFlow: number; // lbs/h
 }
 
 export interface SensorData {
@@ -35,6 +36,7 @@ export interface SensorData {
   stallWarning: boolean;
   overspeedWarning: boolean;
   simulationRate: number;
+  pushback: boolean;
 }
 
 export interface RadioData {
@@ -71,6 +73,8 @@ export interface FlightControlData {
   flaps: number;
   spoilers: number;
   gearDown: boolean;
+  flapsDetent: number;
+  parkingBrake: boolean;
 }
 
 export interface SimTimeData {
@@ -95,6 +99,15 @@ export interface DoorData {
 export interface WeightData {
   totalWeight: number;
   fuelWeight: number;
+  fuelTanks: [number, number, number, number]; // lbs: left, center, right, auxiliary
+}
+
+export interface EnvironmentData {
+  oat: number; // °C
+  windDirection: number; // degrees true
+  windSpeed: number; // kts
+  qnh: number; // hPa
+  visibility: number; // m
 }
 
 export interface FlightData {
@@ -111,7 +124,11 @@ export interface FlightData {
   apu: APUData;
   doors: DoorData[];
   aircraftName: string;
+  aircraftIcao: string;
+  tailNumber: string;
   weight: WeightData;
+  environment: EnvironmentData;
+  invalidFields?: string[]; // fields the connector could not supply in this sample
 }
 
 export function useFlightData() {
@@ -5,6 +5,8 @@ const errorMap: Record<string, { key: string; extract?: RegExp }> = {
   "Failed to start flight": { key: "acars.startFlightFailed", extract: /Failed to start flight:\s*(.*)/ },
   "Failed to stop flight": { key: "acars.stopFlightFailed", extract: /Failed to stop flight:\s*(.*)/ },
   "Failed to finish flight": { key: "acars.finishFlightFailed", extract: /Failed to finish flight:\s*(.*)/ },
+  "Failed to resume flight": { key: "acars.resumeFlightFailed", extract: /Failed to resume flight:\s*(.*)/ },
+  "Failed to cancel flight": { key: "acars.discardFlightFailed", extract: /Failed to cancel flight:\s*(.*)/ },
   "Export failed": { key: "recording.exportFailed", extract: /Export failed:\s*(.*)/ },
 };
 
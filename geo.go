@@ -0,0 +1,53 @@
+package main
+
+import "math"
+
+const earthRadiusNM = 3440.065
+
+func toRadians(deg float64) float64 { return deg * math.Pi / 180 }
+func toDegrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// greatCircleNM returns the great-circle distance between two points in nautical miles.
+func greatCircleNM(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := toRadians(lat1), toRadians(lat2)
+	dφ := φ2 - φ1
+	dλ := toRadians(lon2 - lon1)
+	a := math.Sin(dφ/2)*math.Sin(dφ/2) + math.Cos(φ1)*math.Cos(φ2)*math.Sin(dλ/2)*math.Sin(dλ/2)
+	return 2 * earthRadiusNM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// initialBearing returns the true course in degrees from point 1 towards point 2.
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := toRadians(lat1), toRadians(lat2)
+	dλ := toRadians(lon2 - lon1)
+	y := math.Sin(dλ) * math.Cos(φ2)
+	x := math.Cos(φ1)*math.Sin(φ2) - math.Sin(φ1)*math.Cos(φ2)*math.Cos(dλ)
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// destinationPoint moves distNM along a true course from a point.
+func destinationPoint(lat, lon, course, distNM float64) (float64, float64) {
+	φ1, λ1 := toRadians(lat), toRadians(lon)
+	θ := toRadians(course)
+	δ := distNM / earthRadiusNM
+	φ2 := math.Asin(math.Sin(φ1)*math.Cos(δ) + math.Cos(φ1)*math.Sin(δ)*math.Cos(θ))
+	λ2 := λ1 + math.Atan2(math.Sin(θ)*math.Sin(δ)*math.Cos(φ1), math.Cos(δ)-math.Sin(φ1)*math.Sin(φ2))
+	return toDegrees(φ2), math.Mod(toDegrees(λ2)+540, 360) - 180
+}
+
+// intermediatePoint returns the point at fraction f (0..1) along the great
+// circle between two points.
+func intermediatePoint(lat1, lon1, lat2, lon2, f float64) (float64, float64) {
+	d := greatCircleNM(lat1, lon1, lat2, lon2) / earthRadiusNM
+	if d == 0 {
+		return lat1, lon1
+	}
+	φ1, λ1 := toRadians(lat1), toRadians(lon1)
+	φ2, λ2 := toRadians(lat2), toRadians(lon2)
+	a := math.Sin((1-f)*d) / math.Sin(d)
+	b := math.Sin(f*d) / math.Sin(d)
+	x := a*math.Cos(φ1)*math.Cos(λ1) + b*math.Cos(φ2)*math.Cos(λ2)
+	y := a*math.Cos(φ1)*math.Sin(λ1) + b*math.Cos(φ2)*math.Sin(λ2)
+	z := a*math.Sin(φ1) + b*math.Sin(φ2)
+	return toDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))), toDegrees(math.Atan2(y, x))
+}
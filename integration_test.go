@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 
@@ -226,3 +227,50 @@ func TestChatServiceGetMessagesWithPagination(t *testing.T) {
 	require.Len(t, result.Data, 1)
 	assert.Equal(t, "Page 2", result.Data[0].Message)
 }
+
+func TestReplayThroughFlightPipeline(t *testing.T) {
+	var positions, finished int
+	var lastLat float64
+	var mu sync.Mutex
+
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v2/acars/position":
+			var report map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&report)
+			pos := report["position"].(map[string]interface{})
+			lastLat = pos["latitude"].(map[string]interface{})["value"].(float64)
+			positions++
+		case "/api/acars/finish":
+			finished++
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	settings := &SettingsService{settings: Settings{ReplaySource: "flight_data.csv", ReplaySpeed: 1}}
+	fds := NewFlightDataService(nil, settings)
+	flight := NewFlightService(auth, fds, settings)
+
+	adapter, err := fds.ConnectSim("replay")
+	require.NoError(t, err)
+	assert.Equal(t, "Replay", adapter)
+	defer fds.DisconnectSim()
+
+	require.NoError(t, flight.StartFlight("BAW123", "SBGR", "SBRJ"))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return positions > 0
+	}, 5*time.Second, 100*time.Millisecond)
+
+	require.NoError(t, flight.FinishFlight())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, -23.4250, lastLat)
+}
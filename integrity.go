@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	integritySpeedFactor   = 1.5  // allowance over the reported ground speed and vertical speed
+	integritySpeedSlack    = 50.0 // kts on top of the ground speed, for taxiing and gusts
+	integrityDistanceSlack = 0.05 // NM per sample
+	integrityClimbSlack    = 1000 // fpm on top of the vertical speed
+	integrityAltitudeSlack = 50.0 // ft per sample
+	integrityRateTolerance = 0.01 // simulation rates this close to 1 count as real time
+	integrityPauseMin      = 5 * time.Second
+	integrityRefuelMin     = 50.0 // lbs gained while airborne before it counts as refueling
+)
+
+// Integrity event types.
+const (
+	integrityPositionJump     = "positionJump"
+	integrityAltitudeJump     = "altitudeJump"
+	integrityTimeAcceleration = "timeAcceleration"
+	integrityPause            = "pause"
+	integrityInflightRefuel   = "inflightRefuel"
+	integrityInterruption     = "interruption" // the app was closed and the flight resumed
+)
+
+// IntegrityEvent is evidence of something a fair flight does not do: slewing
+// or teleporting, running the simulator faster or slower than real time,
+// pausing, or refueling in the air. An interruption records a gap in the
+// track of a flight resumed after the app was closed.
+type IntegrityEvent struct {
+	Type      string                 `json:"type"`
+	Time      time.Time              `json:"time"` // when it started
+	SimTime   string                 `json:"simTime"`
+	Duration  float64                `json:"duration"` // s of real time, 0 for instant events
+	Latitude  float64                `json:"latitude"`
+	Longitude float64                `json:"longitude"`
+	Evidence  map[string]measurement `json:"evidence"`
+}
+
+// IntegritySummary totals the integrity events of a flight for FinishFlight.
+type IntegritySummary struct {
+	Clean             bool             `json:"clean"` // no events
+	PositionJumps     int              `json:"positionJumps"`
+	AltitudeJumps     int              `json:"altitudeJumps"`
+	TimeAccelerated   float64          `json:"timeAccelerated"` // s of real time at a simulation rate other than 1
+	MaxSimulationRate float64          `json:"maxSimulationRate"`
+	Paused            float64          `json:"paused"`      // s
+	FuelAdded         float64          `json:"fuelAdded"`   // lbs, while airborne
+	Interrupted       float64          `json:"interrupted"` // s the flight was not tracked
+	Events            []IntegrityEvent `json:"events"`
+}
+
+// integrityMonitor detects integrity events from consecutive telemetry
+// samples. Events that last, such as a slew or a pause, are reported when
+// they end so their evidence is complete.
+type integrityMonitor struct {
+	prev   *FlightData
+	prevAt time.Time
+	events []IntegrityEvent
+
+	jump, climb, rate, pause *IntegrityEvent // in progress
+	frozenSince              time.Time       // sim time stopped advancing
+	fuelLow                  float64         // least fuel seen since the last refuel or takeoff
+}
+
+// update feeds a sample and returns the events that ended with it.
+func (im *integrityMonitor) update(fd *FlightData, now time.Time) []IntegrityEvent {
+	prev, prevAt := im.prev, im.prevAt
+	im.prev, im.prevAt = fd, now
+	if prev == nil {
+		im.fuelLow = fd.Weight.FuelWeight
+		return nil
+	}
+
+	var ended []IntegrityEvent
+	end := func(ev **IntegrityEvent) { ended = endIntegrityEvent(ended, ev, now) }
+
+	// Covered at the simulation rate, so time acceleration is not also
+	// reported as jumps.
+	elapsed := now.Sub(prevAt).Hours() * max(fd.Sensors.SimulationRate, 1)
+
+	if fd.Valid("position.latitude") && fd.Valid("position.longitude") {
+		distance := greatCircleNM(prev.Position.Latitude, prev.Position.Longitude, fd.Position.Latitude, fd.Position.Longitude)
+		gs := max(prev.Attitude.GS, fd.Attitude.GS)
+		expected := (gs*integritySpeedFactor+integritySpeedSlack)*elapsed + integrityDistanceSlack
+		if distance > expected {
+			if im.jump == nil {
+				im.jump = newIntegrityEvent(integrityPositionJump, prev, now)
+				im.jump.Evidence["fromLatitude"] = m(prev.Position.Latitude, "deg")
+				im.jump.Evidence["fromLongitude"] = m(prev.Position.Longitude, "deg")
+				im.jump.Evidence["distance"] = m(0.0, "NM")
+				im.jump.Evidence["expected"] = m(0.0, "NM")
+			}
+			ev := im.jump.Evidence
+			ev["toLatitude"] = m(fd.Position.Latitude, "deg")
+			ev["toLongitude"] = m(fd.Position.Longitude, "deg")
+			ev["distance"] = m(ev["distance"].Value.(float64)+distance, "NM")
+			ev["expected"] = m(ev["expected"].Value.(float64)+expected, "NM")
+			ev["groundSpeed"] = m(fd.Attitude.GS, "kts")
+		} else {
+			end(&im.jump)
+		}
+	}
+
+	if fd.Valid("position.altitude") {
+		change := fd.Position.Altitude - prev.Position.Altitude
+		vs := max(math.Abs(prev.Attitude.VS), math.Abs(fd.Attitude.VS))
+		expected := (vs*integritySpeedFactor+integrityClimbSlack)*elapsed*60 + integrityAltitudeSlack
+		if math.Abs(change) > expected {
+			if im.climb == nil {
+				im.climb = newIntegrityEvent(integrityAltitudeJump, prev, now)
+				im.climb.Evidence["fromAltitude"] = m(prev.Position.Altitude, "ft")
+			}
+			im.climb.Evidence["toAltitude"] = m(fd.Position.Altitude, "ft")
+			im.climb.Evidence["verticalSpeed"] = m(fd.Attitude.VS, "fpm")
+		} else {
+			end(&im.climb)
+		}
+	}
+
+	if rate := fd.Sensors.SimulationRate; fd.Valid("sensors.simulationRate") && rate > 0 && math.Abs(rate-1) > integrityRateTolerance {
+		if im.rate == nil {
+			im.rate = newIntegrityEvent(integrityTimeAcceleration, fd, now)
+			im.rate.Evidence["maxRate"] = m(rate, "x")
+			im.rate.Evidence["minRate"] = m(rate, "x")
+		}
+		ev := im.rate.Evidence
+		ev["maxRate"] = m(max(ev["maxRate"].Value.(float64), rate), "x")
+		ev["minRate"] = m(min(ev["minRate"].Value.(float64), rate), "x")
+	} else {
+		end(&im.rate)
+	}
+
+	// Recordings without sim time read 0 throughout.
+	if fd.Valid("simTime.zuluTime") && fd.SimTime.ZuluTime != 0 && fd.SimTime.ZuluTime == prev.SimTime.ZuluTime {
+		if im.frozenSince.IsZero() {
+			im.frozenSince = prevAt
+		}
+		if im.pause == nil && now.Sub(im.frozenSince) >= integrityPauseMin {
+			im.pause = newIntegrityEvent(integrityPause, prev, im.frozenSince)
+		}
+	} else {
+		im.frozenSince = time.Time{}
+		end(&im.pause)
+	}
+
+	if fd.Valid("weight.fuelWeight") {
+		fuel := fd.Weight.FuelWeight
+		switch {
+		case fd.Sensors.OnGround:
+			im.fuelLow = fuel
+		case fuel-im.fuelLow >= integrityRefuelMin:
+			ev := newIntegrityEvent(integrityInflightRefuel, fd, now)
+			ev.Evidence["fuelBefore"] = m(im.fuelLow, "lbs")
+			ev.Evidence["fuelAfter"] = m(fuel, "lbs")
+			ev.Evidence["added"] = m(fuel-im.fuelLow, "lbs")
+			ev.Evidence["altitude"] = m(fd.Position.Altitude, "ft")
+			ended = append(ended, *ev)
+			im.fuelLow = fuel
+		default:
+			im.fuelLow = min(im.fuelLow, fuel)
+		}
+	}
+
+	im.events = append(im.events, ended...)
+	return ended
+}
+
+// finish ends the events still in progress, e.g. when the flight is finished
+// while paused, and returns them.
+func (im *integrityMonitor) finish(now time.Time) []IntegrityEvent {
+	var ended []IntegrityEvent
+	for _, ev := range []**IntegrityEvent{&im.jump, &im.climb, &im.rate, &im.pause} {
+		ended = endIntegrityEvent(ended, ev, now)
+	}
+	im.events = append(im.events, ended...)
+	return ended
+}
+
+// summary totals the events reported so far.
+func (im *integrityMonitor) summary() IntegritySummary {
+	s := IntegritySummary{Clean: len(im.events) == 0, Events: im.events}
+	if s.Events == nil {
+		s.Events = []IntegrityEvent{}
+	}
+	for _, ev := range im.events {
+		switch ev.Type {
+		case integrityPositionJump:
+			s.PositionJumps++
+		case integrityAltitudeJump:
+			s.AltitudeJumps++
+		case integrityTimeAcceleration:
+			s.TimeAccelerated += ev.Duration
+			s.MaxSimulationRate = max(s.MaxSimulationRate, ev.Evidence["maxRate"].Value.(float64))
+		case integrityPause:
+			s.Paused += ev.Duration
+		case integrityInflightRefuel:
+			s.FuelAdded += ev.Evidence["added"].Value.(float64)
+		case integrityInterruption:
+			s.Interrupted += ev.Duration
+		}
+	}
+	return s
+}
+
+func newIntegrityEvent(kind string, fd *FlightData, at time.Time) *IntegrityEvent {
+	return &IntegrityEvent{
+		Type:      kind,
+		Time:      at.UTC(),
+		SimTime:   simZuluTimestamp(fd.SimTime),
+		Latitude:  fd.Position.Latitude,
+		Longitude: fd.Position.Longitude,
+		Evidence:  make(map[string]measurement),
+	}
+}
+
+// endIntegrityEvent appends the event in progress at *ev, if any, to ended
+// with its duration up to now and clears *ev.
+func endIntegrityEvent(ended []IntegrityEvent, ev **IntegrityEvent, now time.Time) []IntegrityEvent {
+	if *ev == nil {
+		return ended
+	}
+	(*ev).Duration = now.Sub((*ev).Time).Seconds()
+	ended = append(ended, **ev)
+	*ev = nil
+	return ended
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// integritySample is the synthetic flight at pos with the sim time the
+// synthetic connector adds.
+func integritySample(flight *syntheticFlight, pos time.Duration) *FlightData {
+	fd := flight.sampleAt(pos)
+	fd.SimTime.ZuluTime = 12*3600 + pos.Seconds()
+	return fd
+}
+
+func TestIntegrityMonitorCleanSyntheticFlight(t *testing.T) {
+	flight := newSyntheticFlight(defaultFlightProfile())
+	start := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	var im integrityMonitor
+	for pos := time.Duration(0); pos <= flight.duration; pos += time.Second {
+		im.update(integritySample(flight, pos), start.Add(pos))
+	}
+	im.finish(start.Add(flight.duration))
+
+	s := im.summary()
+	assert.True(t, s.Clean, "a normal flight raises no events: %+v", s.Events)
+	assert.Empty(t, s.Events)
+}
+
+func TestIntegrityMonitorEvents(t *testing.T) {
+	flight := newSyntheticFlight(defaultFlightProfile())
+	var pos time.Duration // in the flight
+	for _, s := range flight.segments {
+		if s.phase == PhaseCruise {
+			pos = s.start
+		}
+	}
+	// Where the pilot moved the aircraft to, kept by the following samples.
+	var latShift, altShift float64
+	next := func() *FlightData {
+		pos += time.Second
+		fd := integritySample(flight, pos)
+		fd.Position.Latitude += latShift
+		fd.Position.Altitude += altShift
+		return fd
+	}
+
+	var im integrityMonitor
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	step := func(fd *FlightData) []string {
+		now = now.Add(time.Second)
+		var types []string
+		for _, ev := range im.update(fd, now) {
+			types = append(types, ev.Type)
+		}
+		return types
+	}
+
+	assert.Empty(t, step(next()))
+
+	// Teleported across the ocean.
+	latShift = 20
+	assert.Empty(t, step(next()), "reported once the aircraft stops jumping")
+	assert.Equal(t, []string{integrityPositionJump}, step(next()))
+
+	// 16x simulation rate for 10 s; the extra distance is not a jump.
+	for range 10 {
+		pos += 15 * time.Second
+		fd := next()
+		fd.Sensors.SimulationRate = 16
+		assert.Empty(t, step(fd))
+	}
+	assert.Equal(t, []string{integrityTimeAcceleration}, step(next()))
+
+	// Paused for 30 s: the sim time stands still.
+	fd := next()
+	for range 30 {
+		assert.Empty(t, step(fd))
+	}
+	assert.Equal(t, []string{integrityPause}, step(next()))
+
+	// Climbed 5,000 ft in a second.
+	altShift = 5000
+	assert.Empty(t, step(next()))
+	assert.Equal(t, []string{integrityAltitudeJump}, step(next()))
+
+	// Refueled in flight.
+	fd = next()
+	fd.Weight.FuelWeight += 4000
+	assert.Equal(t, []string{integrityInflightRefuel}, step(fd))
+
+	// Started a slew that is still going when the flight is finished.
+	latShift++
+	step(next())
+	ended := im.finish(now)
+	require.Len(t, ended, 1)
+	assert.Equal(t, integrityPositionJump, ended[0].Type)
+
+	s := im.summary()
+	assert.False(t, s.Clean)
+	assert.Equal(t, 2, s.PositionJumps)
+	assert.Equal(t, 1, s.AltitudeJumps)
+	assert.Equal(t, 10.0, s.TimeAccelerated)
+	assert.Equal(t, 16.0, s.MaxSimulationRate)
+	assert.Equal(t, 30.0, s.Paused)
+	assert.InDelta(t, 4000, s.FuelAdded, 50)
+
+	jump := s.Events[0]
+	assert.InDelta(t, 1200, jump.Evidence["distance"].Value.(float64), 10, "20 degrees of latitude")
+	assert.Equal(t, "NM", jump.Evidence["distance"].Unit)
+	assert.Equal(t, 1.0, jump.Duration)
+}
+
+func TestCheckIntegrityReportsEvents(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]interface{}
+	release := make(chan struct{})
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		assert.Equal(t, "/api/acars/integrity", r.URL.Path)
+		var p map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&p)
+		mu.Lock()
+		received = append(received, p)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := &FlightService{auth: auth, state: "active", callsign: "BAW123"}
+	now := time.Now()
+	fd := sampleFlightData()
+	fd.Sensors.OnGround = false
+	f.checkIntegrity(fd, now)
+
+	refueled := *fd
+	refueled.Weight.FuelWeight += 1000
+	f.checkIntegrity(&refueled, now.Add(time.Second))
+	f.checkIntegrity(&refueled, now.Add(2*time.Second))
+	close(release)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "BAW123", received[0]["callsign"])
+	event := received[0]["event"].(map[string]interface{})
+	assert.Equal(t, integrityInflightRefuel, event["type"])
+	added := event["evidence"].(map[string]interface{})["added"].(map[string]interface{})
+	assert.Equal(t, 1000.0, added["value"])
+	assert.Equal(t, "lbs", added["unit"])
+}
+
+func TestFinishFlightIncludesIntegritySummary(t *testing.T) {
+	var payload map[string]interface{}
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := &FlightService{auth: auth, state: "active", callsign: "BAW123"}
+	now := time.Now()
+	fd := sampleFlightData()
+	f.integrity.update(fd, now)
+	fast := *fd
+	fast.Sensors.SimulationRate = 4
+	f.integrity.update(&fast, now.Add(time.Second))
+
+	require.NoError(t, f.FinishFlight())
+
+	summary := payload["integrity"].(map[string]interface{})
+	assert.Equal(t, false, summary["clean"])
+	assert.Equal(t, 4.0, summary["maxSimulationRate"])
+	assert.Len(t, summary["events"], 1, "the acceleration still running is ended by FinishFlight")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	touchdownSampleRate = 50              // Hz while close to the ground
+	defaultTouchdownAGL = 200.0           // ft, start high-rate sampling below this
+	landingTrendWindow  = 3 * time.Second // airborne history used for the sink-rate trend
+	landingGForceWindow = 1 * time.Second // peak G is searched this long after each ground contact
+	landingSettleTime   = 3 * time.Second // continuously on the ground before the report is final
+	landingBounceWindow = 5 * time.Second // airborne longer than this after touchdown is a touch-and-go
+)
+
+// LandingReport describes a single touchdown, captured at the OnGround transition.
+type LandingReport struct {
+	Time          time.Time `json:"time"`
+	SimTime       string    `json:"simTime"`
+	Callsign      string    `json:"callsign"`
+	Departure     string    `json:"departure"`
+	Arrival       string    `json:"arrival"`
+	Aircraft      string    `json:"aircraft"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	VerticalSpeed float64   `json:"verticalSpeed"` // fpm, negative when descending
+	GForce        float64   `json:"gForce"`        // peak G after ground contact
+	Pitch         float64   `json:"pitch"`         // deg
+	Bank          float64   `json:"bank"`          // deg
+	GroundSpeed   float64   `json:"groundSpeed"`   // kts
+	SinkRateTrend float64   `json:"sinkRateTrend"` // fpm/s over the last seconds before touchdown, positive while flaring
+	Bounces       int       `json:"bounces"`
+	SampleRate    float64   `json:"sampleRate"` // Hz achieved before touchdown
+}
+
+type landingSample struct {
+	t  time.Time
+	vs float64
+}
+
+// landingAnalyzer turns a stream of samples into LandingReports. It keeps a
+// short airborne history for the touchdown values and sink-rate trend, then
+// follows the aircraft on the ground to catch the peak G load and bounces.
+type landingAnalyzer struct {
+	history        []landingSample
+	report         *LandingReport
+	lastContact    time.Time // most recent air→ground transition
+	lastLiftoff    time.Time // most recent ground→air transition after touchdown
+	wasOnGround    bool
+	seen           bool
+	lastAirborneVS float64
+}
+
+// active reports whether a touchdown is being analyzed and high-rate samples
+// are still needed.
+func (a *landingAnalyzer) active() bool {
+	return a.report != nil
+}
+
+// update feeds a sample and returns a finished LandingReport once the
+// aircraft has settled on the ground (or left again for a touch-and-go).
+func (a *landingAnalyzer) update(fd *FlightData, now time.Time) *LandingReport {
+	onGround := fd.Sensors.OnGround
+	wasOnGround := a.wasOnGround
+	first := !a.seen
+	a.wasOnGround = onGround
+	a.seen = true
+
+	if !onGround {
+		if a.report != nil {
+			if wasOnGround {
+				a.lastLiftoff = now
+			}
+			if now.Sub(a.lastLiftoff) > landingBounceWindow {
+				return a.finish()
+			}
+			return nil
+		}
+
+		a.lastAirborneVS = fd.Attitude.VS
+		a.history = append(a.history, landingSample{t: now, vs: fd.Attitude.VS})
+		cutoff := now.Add(-landingTrendWindow)
+		i := 0
+		for i < len(a.history) && a.history[i].t.Before(cutoff) {
+			i++
+		}
+		a.history = a.history[i:]
+		return nil
+	}
+
+	if !wasOnGround && !first {
+		a.lastContact = now
+		if a.report == nil {
+			a.report = &LandingReport{
+				Time:          now.UTC(),
+				SimTime:       simZuluTimestamp(fd.SimTime),
+				Aircraft:      fd.AircraftName,
+				Latitude:      fd.Position.Latitude,
+				Longitude:     fd.Position.Longitude,
+				VerticalSpeed: a.lastAirborneVS,
+				GForce:        fd.Attitude.GForce,
+				Pitch:         fd.Attitude.Pitch,
+				Bank:          fd.Attitude.Roll,
+				GroundSpeed:   fd.Attitude.GS,
+				SinkRateTrend: sinkRateTrend(a.history),
+				SampleRate:    sampleRate(a.history),
+			}
+		} else {
+			a.report.Bounces++
+		}
+	}
+
+	if a.report == nil {
+		return nil
+	}
+
+	if now.Sub(a.lastContact) <= landingGForceWindow && fd.Attitude.GForce > a.report.GForce {
+		a.report.GForce = fd.Attitude.GForce
+	}
+
+	if now.Sub(a.lastContact) >= landingSettleTime {
+		return a.finish()
+	}
+	return nil
+}
+
+func (a *landingAnalyzer) finish() *LandingReport {
+	r := a.report
+	a.report = nil
+	a.history = nil
+	a.lastLiftoff = time.Time{}
+	return r
+}
+
+// sinkRateTrend is the least-squares slope of VS over the samples, in fpm/s.
+func sinkRateTrend(samples []landingSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	t0 := samples[0].t
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.t.Sub(t0).Seconds()
+		sumX += x
+		sumY += s.vs
+		sumXY += x * s.vs
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	den := n*sumXX - sumX*sumX
+	if den == 0 {
+		return 0
+	}
+	slope := (n*sumXY - sumX*sumY) / den
+	return math.Round(slope*10) / 10
+}
+
+// sampleRate is the average number of samples per second in the history.
+func sampleRate(samples []landingSample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	span := samples[len(samples)-1].t.Sub(samples[0].t).Seconds()
+	if span <= 0 {
+		return 0
+	}
+	return math.Round(float64(len(samples)-1)/span*10) / 10
+}
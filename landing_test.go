@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// landingSampleData builds an airborne or on-ground sample for the analyzer.
+func landingSampleData(onGround bool, vs, g float64) *FlightData {
+	fd := phaseSample(onGround, 130, vs, 0, true)
+	fd.Attitude.GForce = g
+	fd.Attitude.Pitch = 4.5
+	fd.Attitude.Roll = -1.2
+	return fd
+}
+
+// feedLanding feeds samples at touchdownSampleRate for d and returns the
+// first finished report, if any.
+func feedLanding(a *landingAnalyzer, fd *FlightData, now *time.Time, d time.Duration) *LandingReport {
+	var report *LandingReport
+	step := time.Second / touchdownSampleRate
+	for end := now.Add(d); now.Before(end); *now = now.Add(step) {
+		if r := a.update(fd, *now); r != nil && report == nil {
+			report = r
+		}
+	}
+	return report
+}
+
+func TestLandingAnalyzerTouchdown(t *testing.T) {
+	var a landingAnalyzer
+	now := time.Now()
+	step := time.Second / touchdownSampleRate
+
+	// Flare: sink rate reduces from 700 to 200 fpm over the final 3 seconds.
+	for i := range 150 {
+		vs := -700 + float64(i)*500/150
+		a.update(landingSampleData(false, vs, 1.0), now)
+		now = now.Add(step)
+	}
+	assert.False(t, a.active())
+
+	report := feedLanding(&a, landingSampleData(true, 0, 1.35), &now, 200*time.Millisecond)
+	assert.Nil(t, report, "report is not final right after contact")
+	assert.True(t, a.active())
+
+	report = feedLanding(&a, landingSampleData(true, 0, 1.0), &now, landingSettleTime)
+	require.NotNil(t, report)
+
+	assert.InDelta(t, -203, report.VerticalSpeed, 1)
+	assert.Equal(t, 1.35, report.GForce)
+	assert.Equal(t, 4.5, report.Pitch)
+	assert.Equal(t, -1.2, report.Bank)
+	assert.Equal(t, 130.0, report.GroundSpeed)
+	assert.Greater(t, report.SinkRateTrend, 100.0, "flare should show a decreasing sink rate")
+	assert.InDelta(t, touchdownSampleRate, report.SampleRate, 1)
+	assert.Equal(t, 0, report.Bounces)
+	assert.False(t, a.active())
+}
+
+func TestLandingAnalyzerBounces(t *testing.T) {
+	var a landingAnalyzer
+	now := time.Now()
+
+	feedLanding(&a, landingSampleData(false, -400, 1.0), &now, time.Second)
+	feedLanding(&a, landingSampleData(true, 0, 1.8), &now, 500*time.Millisecond)
+	feedLanding(&a, landingSampleData(false, 200, 1.0), &now, time.Second)
+	feedLanding(&a, landingSampleData(true, 0, 1.2), &now, 500*time.Millisecond)
+	feedLanding(&a, landingSampleData(false, 100, 1.0), &now, 500*time.Millisecond)
+
+	report := feedLanding(&a, landingSampleData(true, 0, 1.0), &now, landingSettleTime+time.Second)
+	require.NotNil(t, report)
+	assert.Equal(t, 2, report.Bounces)
+	assert.Equal(t, -400.0, report.VerticalSpeed, "first touchdown is reported")
+	assert.Equal(t, 1.8, report.GForce)
+}
+
+func TestLandingAnalyzerTouchAndGo(t *testing.T) {
+	var a landingAnalyzer
+	now := time.Now()
+
+	feedLanding(&a, landingSampleData(false, -300, 1.0), &now, time.Second)
+	feedLanding(&a, landingSampleData(true, 0, 1.1), &now, time.Second)
+
+	report := feedLanding(&a, landingSampleData(false, 800, 1.0), &now, landingBounceWindow+time.Second)
+	require.NotNil(t, report, "leaving the ground for good finishes the report")
+	assert.Equal(t, 0, report.Bounces)
+	assert.False(t, a.active())
+}
+
+func TestLandingAnalyzerStartsOnGround(t *testing.T) {
+	var a landingAnalyzer
+	now := time.Now()
+
+	report := feedLanding(&a, landingSampleData(true, 0, 1.0), &now, 10*time.Second)
+	assert.Nil(t, report, "being on the ground at start is not a touchdown")
+	assert.False(t, a.active())
+}
+
+func TestSinkRateTrend(t *testing.T) {
+	now := time.Now()
+	samples := []landingSample{
+		{t: now, vs: -600},
+		{t: now.Add(time.Second), vs: -500},
+		{t: now.Add(2 * time.Second), vs: -400},
+	}
+	assert.Equal(t, 100.0, sinkRateTrend(samples))
+	assert.Equal(t, 0.0, sinkRateTrend(samples[:1]))
+	assert.Equal(t, 1.0, sampleRate(samples))
+}
+
+func TestLandingReportPersistence(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	fds := NewFlightDataService(db, nil)
+	require.NoError(t, fds.saveLandingReport(&LandingReport{Callsign: "BAW1", VerticalSpeed: -150}))
+	require.NoError(t, fds.saveLandingReport(&LandingReport{Callsign: "BAW2", VerticalSpeed: -320, Bounces: 1}))
+
+	reports, err := fds.GetLandingReports()
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "BAW2", reports[0].Callsign, "newest first")
+	assert.Equal(t, 1, reports[0].Bounces)
+	assert.Equal(t, -150.0, reports[1].VerticalSpeed)
+}
+
+// rateMockConnector records SetUpdateRate calls.
+type rateMockConnector struct {
+	MockSimConnector
+	mu    sync.Mutex
+	rates []int
+}
+
+func (r *rateMockConnector) SetUpdateRate(hz int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rates = append(r.rates, hz)
+}
+
+func (r *rateMockConnector) lastRate() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.rates) == 0 {
+		return 0
+	}
+	return r.rates[len(r.rates)-1]
+}
+
+func TestMonitorLoopRaisesSampleRateNearGround(t *testing.T) {
+	fd := sampleFlightData()
+	fd.Sensors.OnGround = false
+	fd.Position.AltitudeAGL = 80
+
+	mock := &rateMockConnector{MockSimConnector: MockSimConnector{data: fd, name: "TestSim"}}
+	fds := &FlightDataService{connector: mock, simActive: true}
+	startTestStream(t, fds)
+	f := &FlightService{auth: &AuthService{}, flightData: fds, state: "active"}
+
+	stopCh := make(chan struct{})
+	go f.monitorLoop(stopCh)
+
+	require.Eventually(t, func() bool {
+		return mock.lastRate() == touchdownSampleRate
+	}, 3*time.Second, 50*time.Millisecond)
+
+	close(stopCh)
+	require.Eventually(t, func() bool {
+		return mock.lastRate() == 1
+	}, time.Second, 10*time.Millisecond, "rate is restored when the flight ends")
+}
+
+func TestTouchdownSampleAGLSetting(t *testing.T) {
+	f := &FlightService{}
+	assert.Equal(t, defaultTouchdownAGL, f.touchdownSampleAGL())
+
+	f.settings = &SettingsService{settings: Settings{TouchdownSampleAGL: 50}}
+	assert.Equal(t, 50.0, f.touchdownSampleAGL())
+}
+
+func TestFinishFlightIncludesLanding(t *testing.T) {
+	var payload map[string]interface{}
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := &FlightService{auth: auth, state: "active", callsign: "BAW123"}
+	f.landing = &LandingReport{VerticalSpeed: -180, GForce: 1.2, Bounces: 1}
+
+	require.NoError(t, f.FinishFlight())
+
+	landing, ok := payload["landing"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, -180.0, landing["verticalSpeed"])
+	assert.Equal(t, 1.0, landing["bounces"])
+	assert.Nil(t, f.GetLandingReport(), "cleared when the flight ends")
+}
@@ -24,6 +24,14 @@ func init() {
 	application.RegisterEvent[bool]("recording-state")
 	application.RegisterEvent[string]("connection-state")
 	application.RegisterEvent[string]("flight-state")
+	application.RegisterEvent[string]("flight-phase")
+	application.RegisterEvent[*LandingReport]("landing-report")
+	application.RegisterEvent[int64]("telemetry-rejected")
+	application.RegisterEvent[IntegrityEvent]("integrity-event")
+	application.RegisterEvent[OutboxStatus]("outbox-status")
+	application.RegisterEvent[*ResumableFlight]("flight-resumable")
+	application.RegisterEvent[[]PendingAction]("pending-actions")
+	application.RegisterEvent[map[string]string]("flight-action-failed")
 }
 
 func main() {
@@ -42,8 +50,9 @@ func main() {
 
 	settingsService := NewSettingsService()
 	authService := &AuthService{httpClient: &http.Client{Timeout: 30 * time.Second}, settings: settingsService}
-	flightDataService := NewFlightDataService(db)
-	flightService := NewFlightService(authService, flightDataService)
+	flightDataService := NewFlightDataService(db, settingsService)
+	defer flightDataService.shutdown()
+	flightService := NewFlightService(authService, flightDataService, settingsService)
 	chatService := NewChatService(authService)
 	audioService := NewAudioService(authService)
 	updateService := &UpdateService{}
@@ -133,6 +142,8 @@ func main() {
 	})
 
 	discordService.Start()
+	flightDataService.Start()
+	flightService.Start()
 
 	go func() {
 		time.Sleep(time.Second)
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the schema migrations, migrations/NNNN_name.sql, applied
+// in order of NNNN. A released migration is never edited; a change to the
+// schema is a new file.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	var migrations []migration
+	for _, e := range entries {
+		number, name, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.sql", e.Name())
+		}
+		data, err := migrationFiles.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", e.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %d missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate brings the schema of the database at dbPath up to date, each
+// migration in a transaction of its own. A database that has tables is
+// copied next to it before the first migration runs.
+func migrate(db *sql.DB, dbPath string) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	latest := len(migrations)
+
+	versioned, err := hasTable(db, "schema_version")
+	if err != nil {
+		return err
+	}
+	var current int
+	legacy := false
+	if versioned {
+		err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&current)
+	} else {
+		current, legacy, err = unversionedSchema(db)
+	}
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this app supports (%d)", current, latest)
+	}
+
+	if (current > 0 || legacy) && current < latest {
+		backup, err := backupDB(db, dbPath, current)
+		if err != nil {
+			return err
+		}
+		slog.Info("backed up database before migrating", "path", backup, "from", current, "to", latest)
+	}
+
+	if !versioned {
+		if err := adoptSchema(db, migrations, current, legacy); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range migrations[current:] {
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+		slog.Info("migrated database", "version", m.version, "name", m.name)
+	}
+	return convertLegacyFlightData(db)
+}
+
+// unversionedSchema works out which migrations a database created before
+// schema_version existed already has, from the tables and columns each one
+// added. legacy is the column-per-field flight_data of the first releases.
+func unversionedSchema(db *sql.DB) (version int, legacy bool, err error) {
+	checks := []struct {
+		version int
+		table   string
+		column  string // "" checks for the table alone
+	}{
+		{7, "recording_sessions", ""},
+		{6, "position_outbox", "sequence"},
+		{5, "pending_actions", ""},
+		{4, "active_flight", ""},
+		{3, "position_outbox", ""},
+		{2, "landings", ""},
+		{1, "flight_data", ""},
+	}
+	for _, c := range checks {
+		var found bool
+		if c.column == "" {
+			found, err = hasTable(db, c.table)
+		} else {
+			found, err = hasColumn(db, c.table, c.column)
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if !found {
+			continue
+		}
+		if c.version == 1 {
+			if legacy, err = hasColumn(db, "flight_data", "altitude"); err != nil || legacy {
+				return 0, legacy, err
+			}
+		}
+		return c.version, false, nil
+	}
+	return 0, false, nil
+}
+
+// adoptSchema creates schema_version for a database that predates it and
+// records the migrations it already has. The legacy flight_data table is kept
+// as flight_data_legacy for convertLegacyFlightData.
+func adoptSchema(db *sql.DB, migrations []migration, current int, legacy bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("adopt schema: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`CREATE TABLE schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_version table: %w", err)
+	}
+	if legacy {
+		if _, err := tx.Exec(`ALTER TABLE flight_data RENAME TO flight_data_legacy`); err != nil {
+			return fmt.Errorf("keep legacy flight data: %w", err)
+		}
+	}
+	now := time.Now().UTC().Format(outboxTimeLayout)
+	for _, m := range migrations[:current] {
+		if _, err := tx.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`, m.version, m.name, now); err != nil {
+			return fmt.Errorf("adopt schema: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// legacyFields are the FlightData fields the legacy flight_data has columns
+// for; the converted samples mark the others invalid.
+var legacyFields = []string{
+	"position.latitude", "position.longitude", "position.altitude",
+	"attitude.headingTrue", "attitude.ias", "attitude.gs", "attitude.vs",
+	"sensors.onGround",
+}
+
+// convertLegacyFlightData turns the samples of flight_data_legacy into JSON
+// samples of a recording session of their own, left open for the recorder
+// to summarise as migration 7 does, and drops the table.
+func convertLegacyFlightData(db *sql.DB) error {
+	found, err := hasTable(db, "flight_data_legacy")
+	if err != nil || !found {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("convert legacy flight data: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT strftime('%Y-%m-%d %H:%M:%f', timestamp), COALESCE(latitude, 0), COALESCE(longitude, 0),
+		COALESCE(altitude, 0), COALESCE(heading, 0), COALESCE(ias, 0), COALESCE(gs, 0), COALESCE(vs, 0), COALESCE(on_ground, 0)
+		FROM flight_data_legacy ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("read legacy flight data: %w", err)
+	}
+	type legacySample struct {
+		ts   string
+		data FlightData
+	}
+	invalid := fieldsExcept(legacyFields)
+	var samples []legacySample
+	for rows.Next() {
+		s := legacySample{data: FlightData{Invalid: invalid}}
+		d := &s.data
+		if err := rows.Scan(&s.ts, &d.Position.Latitude, &d.Position.Longitude, &d.Position.Altitude,
+			&d.Attitude.HeadingTrue, &d.Attitude.IAS, &d.Attitude.GS, &d.Attitude.VS, &d.Sensors.OnGround); err != nil {
+			rows.Close()
+			return fmt.Errorf("read legacy flight data: %w", err)
+		}
+		samples = append(samples, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read legacy flight data: %w", err)
+	}
+
+	if len(samples) > 0 {
+		res, err := tx.Exec(`INSERT INTO recording_sessions (started_at)
+			SELECT strftime('%Y-%m-%d %H:%M:%S', MIN(timestamp)) FROM flight_data_legacy`)
+		if err != nil {
+			return fmt.Errorf("convert legacy flight data: %w", err)
+		}
+		session, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("convert legacy flight data: %w", err)
+		}
+		for _, s := range samples {
+			data, err := json.Marshal(&s.data)
+			if err != nil {
+				return fmt.Errorf("marshal flight data: %w", err)
+			}
+			if _, err := tx.Exec(`INSERT INTO flight_data (session_id, timestamp, data) VALUES (?, ?, ?)`, session, s.ts, string(data)); err != nil {
+				return fmt.Errorf("convert legacy flight data: %w", err)
+			}
+		}
+	}
+	if _, err := tx.Exec(`DROP TABLE flight_data_legacy`); err != nil {
+		return fmt.Errorf("convert legacy flight data: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("convert legacy flight data: %w", err)
+	}
+	slog.Info("converted flight data of an old schema into a recording session", "samples", len(samples))
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+	}
+	_, err = tx.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().UTC().Format(outboxTimeLayout))
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migration %d %s: %w", m.version, m.name, err)
+	}
+	return nil
+}
+
+// backupDB copies the database to a file next to dbPath named after its
+// schema version and returns the file's path.
+func backupDB(db *sql.DB, dbPath string, version int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d-%s.bak", dbPath, version, time.Now().Format("20060102-150405"))
+	if _, err := db.Exec(`VACUUM INTO ?`, backup); err != nil {
+		return "", fmt.Errorf("back up database: %w", err)
+	}
+	return backup, nil
+}
+
+func hasTable(db *sql.DB, table string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+	return n > 0, err
+}
+
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	return n > 0, err
+}
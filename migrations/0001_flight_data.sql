@@ -0,0 +1,6 @@
+-- Recorded simulator samples, one JSON FlightData per row.
+CREATE TABLE flight_data (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
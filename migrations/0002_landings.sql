@@ -0,0 +1,6 @@
+-- Touchdown reports, one JSON LandingReport per row.
+CREATE TABLE landings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
@@ -0,0 +1,10 @@
+-- Position reports waiting to be sent, and recently sent ones. seq orders the
+-- queue across flights and restarts.
+CREATE TABLE position_outbox (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	flight TEXT NOT NULL,
+	queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	sent_at DATETIME,
+	data TEXT NOT NULL
+);
+CREATE INDEX position_outbox_unsent ON position_outbox (flight) WHERE sent_at IS NULL;
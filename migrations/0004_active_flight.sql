@@ -0,0 +1,6 @@
+-- The active flight, a single row that exists while a flight is active.
+CREATE TABLE active_flight (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
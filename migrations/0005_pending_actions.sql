@@ -0,0 +1,12 @@
+-- Flight finishes and stops the server has not acknowledged yet.
+CREATE TABLE pending_actions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	flight TEXT NOT NULL,
+	callsign TEXT NOT NULL,
+	created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt TEXT NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	data TEXT NOT NULL
+);
@@ -0,0 +1,3 @@
+-- The number a position report carries, counted per flight.
+ALTER TABLE position_outbox ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX position_outbox_sequence ON position_outbox (flight, sequence);
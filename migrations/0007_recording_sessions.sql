@@ -0,0 +1,26 @@
+-- Recordings, each the samples taken between a start and a stop of recording,
+-- with a summary of what was flown.
+CREATE TABLE recording_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	flight_id TEXT NOT NULL DEFAULT '',
+	callsign TEXT NOT NULL DEFAULT '',
+	departure TEXT NOT NULL DEFAULT '',
+	arrival TEXT NOT NULL DEFAULT '',
+	aircraft TEXT NOT NULL DEFAULT '',
+	started_at TEXT NOT NULL,
+	ended_at TEXT,
+	samples INTEGER NOT NULL DEFAULT 0,
+	distance REAL NOT NULL DEFAULT 0,
+	max_altitude REAL NOT NULL DEFAULT 0,
+	max_gs REAL NOT NULL DEFAULT 0,
+	fuel_used REAL NOT NULL DEFAULT 0
+);
+ALTER TABLE flight_data ADD COLUMN session_id INTEGER;
+CREATE INDEX flight_data_session ON flight_data (session_id, id);
+
+-- Samples recorded before sessions existed become one session of their own,
+-- left open for the recorder to summarise.
+INSERT INTO recording_sessions (started_at)
+	SELECT strftime('%Y-%m-%d %H:%M:%S', MIN(timestamp)) FROM flight_data
+	HAVING COUNT(*) > 0;
+UPDATE flight_data SET session_id = (SELECT MAX(id) FROM recording_sessions);
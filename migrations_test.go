@@ -0,0 +1,203 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSchemaFixture creates a database from testdata/schemas/<name>.sql, a
+// schema an earlier release created, and returns its path.
+func createSchemaFixture(t *testing.T, name string) string {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", "schemas", name+".sql"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "flight_data.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(string(script))
+	require.NoError(t, err)
+	return path
+}
+
+// schemaOf describes the tables, columns and indexes of db.
+func schemaOf(t *testing.T, db *sql.DB) map[string][]string {
+	t.Helper()
+	rows, err := db.Query(`SELECT type, name, tbl_name FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' ORDER BY name`)
+	require.NoError(t, err)
+	type object struct{ kind, name, table string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		require.NoError(t, rows.Scan(&o.kind, &o.name, &o.table))
+		objects = append(objects, o)
+	}
+	rows.Close()
+
+	schema := map[string][]string{}
+	for _, o := range objects {
+		if o.kind == "index" {
+			schema[o.table] = append(schema[o.table], "index "+o.name)
+			continue
+		}
+		cols, err := db.Query(`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info(?) ORDER BY name`, o.name)
+		require.NoError(t, err)
+		for cols.Next() {
+			var name, typ, dflt string
+			var notNull, pk int
+			require.NoError(t, cols.Scan(&name, &typ, &notNull, &dflt, &pk))
+			schema[o.name] = append(schema[o.name], fmt.Sprintf("%s %s default=%q notnull=%d pk=%d", name, typ, dflt, notNull, pk))
+		}
+		cols.Close()
+	}
+	return schema
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow(query).Scan(&n))
+	return n
+}
+
+func TestMigrateHistoricalSchemas(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	latest := len(migrations)
+
+	fresh, err := openDB(filepath.Join(t.TempDir(), "fresh.db"))
+	require.NoError(t, err)
+	want := schemaOf(t, fresh)
+	fresh.Close()
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "schemas", "*.sql"))
+	require.NoError(t, err)
+	require.Len(t, fixtures, latest+1, "a fixture for every schema")
+
+	for version, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".sql")
+		t.Run(name, func(t *testing.T) {
+			path := createSchemaFixture(t, name)
+			db, err := openDB(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			assert.Equal(t, latest, countRows(t, db, `SELECT MAX(version) FROM schema_version`))
+			assert.Equal(t, latest, countRows(t, db, `SELECT COUNT(*) FROM schema_version`))
+			assert.Equal(t, want, schemaOf(t, db))
+
+			backups, _ := filepath.Glob(path + ".v*.bak")
+			if version < latest {
+				require.Len(t, backups, 1, "backed up before migrating")
+				assert.Contains(t, backups[0], fmt.Sprintf(".v%d-", version))
+			} else {
+				assert.Empty(t, backups, "nothing to migrate")
+			}
+
+			assert.Equal(t, 2, countRows(t, db, `SELECT COUNT(*) FROM flight_data WHERE session_id IS NOT NULL`))
+			assert.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM recording_sessions`))
+			if version >= 3 {
+				o := newPositionOutbox(db)
+				n, err := o.pending("BAW123-1")
+				require.NoError(t, err)
+				assert.Equal(t, 1, n, "queued reports survive")
+				_, err = o.enqueue("BAW123-1", 2, map[string]interface{}{})
+				require.NoError(t, err)
+			}
+			if version >= 5 {
+				actions, err := newPendingActions(db).list()
+				require.NoError(t, err)
+				assert.Len(t, actions, 1)
+			}
+			db.Close()
+
+			// Opening it again changes nothing.
+			db, err = openDB(path)
+			require.NoError(t, err)
+			assert.Equal(t, latest, countRows(t, db, `SELECT COUNT(*) FROM schema_version`))
+			again, _ := filepath.Glob(path + ".v*.bak")
+			assert.Equal(t, backups, again)
+		})
+	}
+}
+
+func TestConvertLegacyFlightData(t *testing.T) {
+	path := createSchemaFixture(t, "00_column_per_field")
+	db, err := openDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	found, err := hasTable(db, "flight_data_legacy")
+	require.NoError(t, err)
+	assert.False(t, found, "converted and dropped")
+
+	store := newSessionStore(db)
+	n, err := store.recover()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "the session is summarised like any interrupted one")
+	sessions, err := store.list()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 2, sessions[0].Samples)
+
+	var times []string
+	var samples []*FlightData
+	require.NoError(t, store.samples(sessions[0].ID, func(ts string, d *FlightData) error {
+		times = append(times, ts)
+		samples = append(samples, d)
+		return nil
+	}))
+	require.Len(t, samples, 2)
+	assert.Equal(t, []string{"2024-03-01T10:00:00Z", "2024-03-01T10:00:01Z"}, times)
+	first, second := samples[0], samples[1]
+	assert.Equal(t, 51.47, first.Position.Latitude)
+	assert.Equal(t, -0.4543, first.Position.Longitude)
+	assert.Equal(t, -0.4544, second.Position.Longitude)
+	assert.Equal(t, 83.0, first.Position.Altitude)
+	assert.Equal(t, 270.0, first.Attitude.HeadingTrue)
+	assert.Zero(t, first.Attitude.GS)
+	assert.Equal(t, 2.0, second.Attitude.GS)
+	assert.True(t, second.Sensors.OnGround)
+	assert.True(t, second.Valid("attitude.gs"))
+	assert.False(t, second.Valid("attitude.headingMag"), "fields the old schema lacks are invalid")
+	assert.False(t, second.Valid("engine1.n1"))
+}
+
+func TestMigrateFromNewerApp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight_data.db")
+	db, err := openDB(path)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (1000, 'future', '2030-01-01 00:00:00')`)
+	require.NoError(t, err)
+	db.Close()
+
+	_, err = openDB(path)
+	assert.ErrorContains(t, err, "newer than this app supports")
+}
+
+func TestMigrationRollsBack(t *testing.T) {
+	path := createSchemaFixture(t, "06_position_sequence")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	// A table in the way of migration 7 makes it fail halfway.
+	_, err = db.Exec(`CREATE TABLE flight_data_session (id INTEGER)`)
+	require.NoError(t, err)
+
+	require.Error(t, migrate(db, path))
+	assert.Equal(t, 6, countRows(t, db, `SELECT MAX(version) FROM schema_version`))
+	found, err := hasTable(db, "recording_sessions")
+	require.NoError(t, err)
+	assert.False(t, found, "the failed migration left nothing behind")
+	found, err = hasColumn(db, "flight_data", "session_id")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -18,6 +18,9 @@ func (m *MockSimConnector) Connect() error           { return nil }
 func (m *MockSimConnector) Disconnect() error        { return nil }
 func (m *MockSimConnector) Name() string             { return m.name }
 func (m *MockSimConnector) LastReceived() time.Time  { return m.lastReceived }
+func (m *MockSimConnector) Capabilities() ConnectorCapabilities {
+	return ConnectorCapabilities{Fields: allFlightDataFields}
+}
 func (m *MockSimConnector) GetFlightData() (*FlightData, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -62,6 +65,10 @@ func (r *ReconnectableMockConnector) LastReceived() time.Time {
 	return r.lastReceived
 }
 
+func (r *ReconnectableMockConnector) Capabilities() ConnectorCapabilities {
+	return ConnectorCapabilities{Fields: allFlightDataFields}
+}
+
 func (r *ReconnectableMockConnector) GetFlightData() (*FlightData, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -120,8 +127,8 @@ func sampleFlightData() *FlightData {
 			GForce:      1.0,
 		},
 		Engines: [4]EngineData{
-			{Exists: true, Running: true, N1: 22.5, N2: 60.0, ThrottlePos: 0, MixturePos: 100, PropPos: 0},
-			{Exists: true, Running: true, N1: 22.5, N2: 60.0, ThrottlePos: 0, MixturePos: 100, PropPos: 0},
+			{Exists: true, Running: true, N1: 22.5, N2: 60.0, ThrottlePos: 0, MixturePos: 100, PropPos: 0, FuelFlow: 1100},
+			{Exists: true, Running: true, N1: 22.5, N2: 60.0, ThrottlePos: 0, MixturePos: 100, PropPos: 0, FuelFlow: 1100},
 		},
 		Sensors: SensorData{
 			OnGround:       true,
@@ -144,7 +151,16 @@ func sampleFlightData() *FlightData {
 		Weight: WeightData{
 			TotalWeight: 130000,
 			FuelWeight:  40000,
+			FuelTanks:   [4]float64{12000, 16000, 12000, 0},
+		},
+		Environment: EnvironmentData{
+			OAT:           14,
+			WindDirection: 250,
+			WindSpeed:     12,
+			QNH:           1018,
+			Visibility:    9999,
 		},
+		Controls: FlightControlData{ParkingBrake: true},
 		SimTime: SimTimeData{
 			ZuluTime:  43200, // 12:00:00
 			ZuluDay:   15,
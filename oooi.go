@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// OOOIEvent records when one of the Out/Off/On/In events happened, both in
+// real UTC and in simulator zulu time.
+type OOOIEvent struct {
+	Time    time.Time `json:"time"`
+	SimTime string    `json:"simTime"`
+}
+
+// OOOITimes holds the four ACARS block/flight times of a flight.
+// Events that have not happened yet are nil.
+type OOOITimes struct {
+	Out *OOOIEvent `json:"out"`
+	Off *OOOIEvent `json:"off"`
+	On  *OOOIEvent `json:"on"`
+	In  *OOOIEvent `json:"in"`
+}
+
+// oooiRecord is a newly detected event, ready to be reported.
+type oooiRecord struct {
+	Name  string
+	Event OOOIEvent
+}
+
+// oooiTracker detects OOOI events from consecutive telemetry samples.
+type oooiTracker struct {
+	times       OOOITimes
+	wasOnGround bool
+	brakeSet    bool
+	seen        bool
+}
+
+// update feeds a sample and returns the events detected with it.
+//
+//   - Out: parking brake released on the ground, or first movement on the
+//     ground with an engine running
+//   - Off: first transition from ground to air
+//   - On:  last transition from air to ground (cleared by a touch-and-go)
+//   - In:  stopped after landing with engines off or a door open
+func (t *oooiTracker) update(fd *FlightData, now time.Time) []oooiRecord {
+	onGround := fd.Sensors.OnGround
+	wasOnGround := t.wasOnGround || !t.seen
+	brakeReleased := t.brakeSet && !fd.Controls.ParkingBrake
+	t.wasOnGround = onGround
+	t.brakeSet = fd.Controls.ParkingBrake
+	t.seen = true
+
+	event := func() *OOOIEvent {
+		return &OOOIEvent{Time: now.UTC().Truncate(time.Second), SimTime: simZuluTimestamp(fd.SimTime)}
+	}
+
+	var records []oooiRecord
+	record := func(name string, ev *OOOIEvent) {
+		records = append(records, oooiRecord{Name: name, Event: *ev})
+	}
+
+	if t.times.In != nil {
+		return nil
+	}
+
+	if t.times.Out == nil && onGround && (brakeReleased || fd.Attitude.GS >= phaseMovingGS && anyEngineRunning(fd)) {
+		t.times.Out = event()
+		record("out", t.times.Out)
+	}
+
+	if !onGround && wasOnGround && t.times.Off == nil {
+		if t.times.Out == nil {
+			// Movement was never seen on the ground (e.g. engines not reported);
+			// the aircraft must have left the gate by now.
+			t.times.Out = event()
+			record("out", t.times.Out)
+		}
+		t.times.Off = event()
+		record("off", t.times.Off)
+	}
+
+	if t.times.Off != nil {
+		if !onGround && t.times.On != nil {
+			t.times.On = nil // touch-and-go or bounce, wait for the final touchdown
+		}
+		if onGround && !wasOnGround {
+			t.times.On = event()
+			record("on", t.times.On)
+		}
+	}
+
+	if t.times.On != nil && onGround && fd.Attitude.GS < phaseMovingGS &&
+		(!anyEngineRunning(fd) || anyDoorOpen(fd)) {
+		t.times.In = event()
+		record("in", t.times.In)
+	}
+
+	return records
+}
+
+// simZuluTimestamp formats the simulator zulu date and time as RFC 3339.
+// When the simulator does not provide a date only the time of day is returned.
+func simZuluTimestamp(st SimTimeData) string {
+	sec := int(st.ZuluTime)
+	if st.ZuluYear < 1 || st.ZuluMonth < 1 || st.ZuluDay < 1 {
+		return fmt.Sprintf("%02d:%02d:%02dZ", sec/3600%24, (sec%3600)/60, sec%60)
+	}
+	t := time.Date(int(st.ZuluYear), time.Month(st.ZuluMonth), int(st.ZuluDay), 0, 0, 0, 0, time.UTC)
+	return t.Add(time.Duration(sec) * time.Second).Format(time.RFC3339)
+}
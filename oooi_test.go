@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOOOITrackerFullFlight(t *testing.T) {
+	var tr oooiTracker
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	step := func(fd *FlightData) []string {
+		now = now.Add(time.Minute)
+		var names []string
+		for _, r := range tr.update(fd, now) {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+
+	assert.Empty(t, step(phaseSample(true, 0, 0, 0, false)), "parked at gate")
+	assert.Empty(t, step(phaseSample(true, 2, 0, 0, false)), "pushback without engines is not gate-out")
+	assert.Equal(t, []string{"out"}, step(phaseSample(true, 12, 0, 0, true)))
+	assert.Empty(t, step(phaseSample(true, 120, 0, 0, true)))
+	assert.Equal(t, []string{"off"}, step(phaseSample(false, 160, 1500, 50, true)))
+	assert.Empty(t, step(phaseSample(false, 450, 0, 35000, true)))
+	assert.Equal(t, []string{"on"}, step(phaseSample(true, 130, 0, 0, true)))
+	assert.Empty(t, step(phaseSample(true, 15, 0, 0, true)))
+	assert.Empty(t, step(phaseSample(true, 0, 0, 0, true)), "stopped with engines running is not gate-in")
+	assert.Equal(t, []string{"in"}, step(phaseSample(true, 0, 0, 0, false)))
+	assert.Empty(t, step(phaseSample(true, 10, 0, 0, true)), "no events after gate-in")
+
+	require.NotNil(t, tr.times.Out)
+	require.NotNil(t, tr.times.In)
+	assert.True(t, tr.times.Out.Time.Before(tr.times.Off.Time))
+	assert.True(t, tr.times.On.Time.Before(tr.times.In.Time))
+}
+
+func TestOOOITrackerTouchAndGo(t *testing.T) {
+	var tr oooiTracker
+	now := time.Now()
+
+	tr.update(phaseSample(true, 12, 0, 0, true), now)
+	tr.update(phaseSample(false, 160, 1500, 50, true), now.Add(time.Minute))
+	tr.update(phaseSample(true, 120, 0, 0, true), now.Add(2*time.Minute))
+	firstOn := tr.times.On
+	require.NotNil(t, firstOn)
+
+	tr.update(phaseSample(false, 130, 800, 20, true), now.Add(3*time.Minute))
+	assert.Nil(t, tr.times.On, "touch-and-go clears wheels-on")
+
+	records := tr.update(phaseSample(true, 120, 0, 0, true), now.Add(4*time.Minute))
+	require.Len(t, records, 1)
+	assert.Equal(t, "on", records[0].Name)
+	assert.True(t, tr.times.On.Time.After(firstOn.Time))
+}
+
+func TestOOOITrackerAirborneWithoutOut(t *testing.T) {
+	var tr oooiTracker
+	now := time.Now()
+
+	tr.update(phaseSample(true, 0, 0, 0, false), now)
+	records := tr.update(phaseSample(false, 160, 1500, 50, false), now.Add(time.Second))
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "out", records[0].Name)
+	assert.Equal(t, "off", records[1].Name)
+}
+
+func TestOOOITrackerParkingBrakeRelease(t *testing.T) {
+	var tr oooiTracker
+	now := time.Now()
+
+	parked := phaseSample(true, 0, 0, 0, false)
+	parked.Controls.ParkingBrake = true
+	assert.Empty(t, tr.update(parked, now))
+	assert.Empty(t, tr.update(parked, now.Add(time.Minute)), "brake still set")
+
+	records := tr.update(phaseSample(true, 0, 0, 0, false), now.Add(2*time.Minute))
+	require.Len(t, records, 1, "releasing the brake is gate-out, before any movement")
+	assert.Equal(t, "out", records[0].Name)
+	assert.Empty(t, tr.update(phaseSample(true, 12, 0, 0, true), now.Add(3*time.Minute)))
+}
+
+func TestSimZuluTimestamp(t *testing.T) {
+	st := SimTimeData{ZuluTime: 43200 + 61, ZuluDay: 15, ZuluMonth: 6, ZuluYear: 2025}
+	assert.Equal(t, "2025-06-15T12:01:01Z", simZuluTimestamp(st))
+
+	assert.Equal(t, "12:01:01Z", simZuluTimestamp(SimTimeData{ZuluTime: 43261}))
+}
+
+func TestFinishFlightIncludesOOOI(t *testing.T) {
+	var payload map[string]interface{}
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/acars/finish", r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	out := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	f := &FlightService{
+		auth:      auth,
+		state:     "active",
+		callsign:  "BAW123",
+		departure: "EGLL",
+		arrival:   "KJFK",
+	}
+	f.oooi.times.Out = &OOOIEvent{Time: out, SimTime: "2025-06-15T12:00:00Z"}
+
+	require.NoError(t, f.FinishFlight())
+
+	require.Contains(t, payload, "oooi")
+	oooi := payload["oooi"].(map[string]interface{})
+	require.NotNil(t, oooi["out"])
+	assert.Equal(t, "2025-06-15T12:00:00Z", oooi["out"].(map[string]interface{})["simTime"])
+	assert.Nil(t, oooi["in"])
+	assert.Equal(t, "idle", f.GetFlightState())
+}
+
+func TestCheckOOOIReportsEvents(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]string
+	release := make(chan struct{})
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		assert.Equal(t, "/api/acars/oooi", r.URL.Path)
+		var p map[string]string
+		json.NewDecoder(r.Body).Decode(&p)
+		mu.Lock()
+		received = append(received, p)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := &FlightService{auth: auth, state: "active", callsign: "BAW123"}
+	at := time.Date(2025, 6, 15, 12, 0, 5, 0, time.UTC)
+	fd := sampleFlightData()
+	f.checkOOOI(fd, at)
+
+	// The event is sent in the background; the server holding the request
+	// does not hold up the samples after it.
+	fd.Attitude.GS = 10
+	f.checkOOOI(fd, at)
+	f.checkOOOI(fd, at.Add(time.Second))
+	assert.NotNil(t, f.GetOOOITimes().Out)
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "out", received[0]["event"])
+	assert.Equal(t, "BAW123", received[0]["callsign"])
+	assert.Equal(t, "2025-06-15T12:00:05Z", received[0]["timestamp"], "stamped with the sample's time")
+	assert.Equal(t, "2025-06-15T12:00:00Z", received[0]["simTimestamp"])
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	actionPollInterval = 5 * time.Second
+	actionRetryMin     = 5 * time.Second // first retry of a failed action, doubled with every attempt
+	actionRetryMax     = 5 * time.Minute
+)
+
+// Flight actions that end a flight and must reach the server.
+const (
+	actionFinish = "finish"
+	actionStop   = "stop"
+)
+
+var actionPaths = map[string]string{
+	actionFinish: "/api/acars/finish",
+	actionStop:   "/api/acars/stop",
+}
+
+// errActionRefused wraps the reason the server gave for refusing an action;
+// sending it again would not help.
+var errActionRefused = errors.New("refused by the server")
+
+// PendingAction is a finish or stop of a flight that has not reached the
+// server yet.
+type PendingAction struct {
+	ID          int64  `json:"id"`
+	Kind        string `json:"kind"` // "finish" or "stop"
+	Callsign    string `json:"callsign"`
+	Created     string `json:"created"` // RFC 3339
+	Attempts    int    `json:"attempts"`
+	NextAttempt string `json:"nextAttempt"` // RFC 3339
+	LastError   string `json:"lastError"`
+
+	flight  string
+	next    time.Time
+	payload json.RawMessage
+}
+
+// pendingActions is the durable queue of flight actions the server could not
+// be reached for, retried with backoff until it acknowledges them.
+type pendingActions struct {
+	db *sql.DB
+}
+
+func newPendingActions(db *sql.DB) *pendingActions {
+	if db == nil {
+		return nil
+	}
+	return &pendingActions{db: db}
+}
+
+// add queues an action of flight after its first attempt failed with cause.
+func (p *pendingActions) add(kind, flight, callsign string, payload interface{}, cause error) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("marshal action: %w", err)
+	}
+	next := time.Now().Add(actionBackoff(1)).UTC().Format(outboxTimeLayout)
+	res, err := p.db.Exec(`INSERT INTO pending_actions (kind, flight, callsign, attempts, next_attempt, last_error, data)
+		VALUES (?, ?, ?, 1, ?, ?, ?)`, kind, flight, callsign, next, cause.Error(), string(data))
+	if err != nil {
+		return 0, fmt.Errorf("queue action: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// list returns the queued actions, oldest first.
+func (p *pendingActions) list() ([]PendingAction, error) {
+	rows, err := p.db.Query(`SELECT id, kind, flight, callsign, created_at, attempts, next_attempt, last_error, data
+		FROM pending_actions ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("read pending actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []PendingAction{}
+	for rows.Next() {
+		var a PendingAction
+		var created, next, data string
+		if err := rows.Scan(&a.ID, &a.Kind, &a.flight, &a.Callsign, &created, &a.Attempts, &next, &a.LastError, &data); err != nil {
+			return nil, fmt.Errorf("read pending actions: %w", err)
+		}
+		a.Created = rfc3339(created)
+		a.NextAttempt = rfc3339(next)
+		a.next, _ = time.Parse(outboxTimeLayout, next)
+		a.payload = json.RawMessage(data)
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+// retry records another failed attempt of action id and schedules the next.
+func (p *pendingActions) retry(id int64, attempts int, cause error) error {
+	next := time.Now().Add(actionBackoff(attempts)).UTC().Format(outboxTimeLayout)
+	_, err := p.db.Exec(`UPDATE pending_actions SET attempts = ?, next_attempt = ?, last_error = ? WHERE id = ?`,
+		attempts, next, cause.Error(), id)
+	if err != nil {
+		return fmt.Errorf("reschedule action: %w", err)
+	}
+	return nil
+}
+
+// wait schedules action id again after d without counting an attempt.
+func (p *pendingActions) wait(id int64, d time.Duration, cause error) error {
+	next := time.Now().Add(d).UTC().Format(outboxTimeLayout)
+	_, err := p.db.Exec(`UPDATE pending_actions SET next_attempt = ?, last_error = ? WHERE id = ?`,
+		next, cause.Error(), id)
+	if err != nil {
+		return fmt.Errorf("reschedule action: %w", err)
+	}
+	return nil
+}
+
+func (p *pendingActions) remove(id int64) error {
+	if _, err := p.db.Exec(`DELETE FROM pending_actions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("remove action: %w", err)
+	}
+	return nil
+}
+
+// actionBackoff is the wait after the given number of failed attempts.
+func actionBackoff(attempts int) time.Duration {
+	d := actionRetryMin
+	for i := 1; i < attempts && d < actionRetryMax; i++ {
+		d *= 2
+	}
+	return min(d, actionRetryMax)
+}
+
+// rfc3339 converts a database timestamp to RFC 3339, or "" if it is not one.
+func rfc3339(ts string) string {
+	t, err := time.Parse(outboxTimeLayout, ts)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// sendAction posts a flight action, keyed so the server applies it once however
+// often it is sent. The finish of flight waits for the flight's queued
+// position reports; sendAction does not send them, as FinishFlight calls it
+// with mu held and a backlog can take thousands of requests. The error wraps
+// errActionRefused when the server refused the action; any other error means
+// it may be sent again.
+func (f *FlightService) sendAction(kind, flight string, payload interface{}) error {
+	if kind == actionFinish && f.outbox != nil {
+		n, err := f.outbox.pending(flight)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%d %w", n, errReportsPending)
+		}
+	}
+
+	body, status, err := f.auth.doIdempotentRequest("POST", actionPaths[kind], payload, idempotencyKey(flight, kind))
+	if err != nil {
+		return err
+	}
+	if retryableStatus(status) {
+		return fmt.Errorf("server returned %d", status)
+	}
+	if status >= 400 {
+		var errResp map[string]interface{}
+		json.Unmarshal(body, &errResp)
+		if msg, ok := errResp["error"].(string); ok {
+			return fmt.Errorf("%w: %s", errActionRefused, msg)
+		}
+		return fmt.Errorf("%w: server returned %d", errActionRefused, status)
+	}
+	return nil
+}
+
+// queueAction stores an action whose first attempt failed with cause for
+// actionLoop to retry, and reports whether it was stored.
+func (f *FlightService) queueAction(kind, flight, callsign string, payload interface{}, cause error) bool {
+	if f.actions == nil {
+		return false
+	}
+	if _, err := f.actions.add(kind, flight, callsign, payload, cause); err != nil {
+		slog.Error("failed to queue flight action", "kind", kind, "error", err)
+		return false
+	}
+	if actions, err := f.actions.list(); err == nil {
+		f.emitPendingActions(actions)
+	}
+	return true
+}
+
+// actionLoop retries the queued flight actions.
+func (f *FlightService) actionLoop() {
+	ticker := time.NewTicker(actionPollInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		f.processActions(now)
+	}
+}
+
+// processActions sends the queued actions that are due, oldest first. Once
+// none are left the flight is no longer pending sync.
+func (f *FlightService) processActions(now time.Time) {
+	actions, err := f.actions.list()
+	if err != nil {
+		slog.Warn("failed to read pending flight actions", "error", err)
+		return
+	}
+	if len(actions) == 0 {
+		return
+	}
+
+	for _, a := range actions {
+		if a.next.After(now) {
+			continue
+		}
+		// The track goes before the finish.
+		if a.Kind == actionFinish && f.outbox != nil {
+			if _, err := f.drainOutbox(); err != nil {
+				slog.Debug("queued position reports still unsent", "error", err)
+			}
+		}
+		err := f.sendAction(a.Kind, a.flight, a.payload)
+		switch {
+		case err == nil:
+			slog.Info("queued flight action sent", "kind", a.Kind, "callsign", a.Callsign, "attempts", a.Attempts+1)
+		case errors.Is(err, errActionRefused):
+			slog.Error("server refused queued flight action", "kind", a.Kind, "callsign", a.Callsign, "error", err)
+			if f.app != nil {
+				f.app.Event.Emit("flight-action-failed", map[string]string{
+					"kind":     a.Kind,
+					"callsign": a.Callsign,
+					"error":    err.Error(),
+				})
+			}
+		case errors.Is(err, errReportsPending):
+			// Not a failed attempt: the finish goes as soon as the track
+			// is up.
+			slog.Debug("queued finish waits for position reports", "callsign", a.Callsign, "error", err)
+			if err := f.actions.wait(a.ID, actionPollInterval, err); err != nil {
+				slog.Warn("failed to reschedule flight action", "error", err)
+			}
+			continue
+		default:
+			slog.Debug("flight action still unsent", "kind", a.Kind, "attempts", a.Attempts+1, "error", err)
+			if err := f.actions.retry(a.ID, a.Attempts+1, err); err != nil {
+				slog.Warn("failed to reschedule flight action", "error", err)
+			}
+			continue
+		}
+		if err := f.actions.remove(a.ID); err != nil {
+			slog.Warn("failed to remove flight action", "error", err)
+		}
+	}
+
+	remaining, err := f.actions.list()
+	if err != nil {
+		return
+	}
+	if len(remaining) == 0 {
+		f.mu.Lock()
+		if f.state == flightPendingSync {
+			f.state = "idle"
+			if f.app != nil {
+				f.app.Event.Emit("flight-state", f.state)
+			}
+		}
+		f.mu.Unlock()
+	}
+	f.emitPendingActions(remaining)
+}
+
+// GetPendingActions returns the flight finishes and stops the server has not
+// acknowledged yet.
+func (f *FlightService) GetPendingActions() ([]PendingAction, error) {
+	if f.actions == nil {
+		return []PendingAction{}, nil
+	}
+	return f.actions.list()
+}
+
+func (f *FlightService) emitPendingActions(actions []PendingAction) {
+	if f.app != nil {
+		f.app.Event.Emit("pending-actions", actions)
+	}
+}
+
+// idempotencyKey is the Idempotency-Key of an action of a flight.
+func idempotencyKey(flight, action string) string {
+	return flight + ":" + action
+}
+
+// pendingStart is a start of a flight that may have reached the server but
+// was not acknowledged. Trying again with the same booking reuses its flight
+// ID.
+type pendingStart struct {
+	flightID, callsign, departure, arrival string
+}
+
+func (p pendingStart) same(o pendingStart) bool {
+	return p.callsign == o.callsign && p.departure == o.departure && p.arrival == o.arrival
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionBackoff(t *testing.T) {
+	assert.Equal(t, actionRetryMin, actionBackoff(1))
+	assert.Equal(t, 2*actionRetryMin, actionBackoff(2))
+	assert.Equal(t, 8*actionRetryMin, actionBackoff(4))
+	assert.Equal(t, actionRetryMax, actionBackoff(100))
+}
+
+func TestPendingActionsStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := openDB(path)
+	require.NoError(t, err)
+	p := newPendingActions(db)
+
+	id, err := p.add(actionFinish, "BAW123-1", "BAW123", map[string]string{"callsign": "BAW123"}, errors.New("server returned 502"))
+	require.NoError(t, err)
+	require.NoError(t, p.retry(id, 3, errors.New("connection refused")))
+	db.Close()
+
+	db, err = openDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	actions, err := newPendingActions(db).list()
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	a := actions[0]
+	assert.Equal(t, actionFinish, a.Kind)
+	assert.Equal(t, "BAW123-1", a.flight)
+	assert.Equal(t, 3, a.Attempts)
+	assert.Equal(t, "connection refused", a.LastError)
+	assert.JSONEq(t, `{"callsign": "BAW123"}`, string(a.payload))
+	assert.WithinDuration(t, time.Now().Add(actionBackoff(3)), a.next, 2*time.Second)
+	_, err = time.Parse(time.RFC3339, a.Created)
+	assert.NoError(t, err)
+}
+
+// newActionTestFlight is an active flight whose server answers with status.
+func newActionTestFlight(t *testing.T, status *atomic.Int32, body string) *FlightService {
+	t.Helper()
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte(body))
+	})
+	t.Cleanup(server.Close)
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return &FlightService{auth: auth, outbox: newPositionOutbox(db), actions: newPendingActions(db),
+		state: "active", flightID: "BAW123-1", callsign: "BAW123"}
+}
+
+func TestStopFlightQueuedWhileUnreachable(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	f := newActionTestFlight(t, &status, "")
+
+	require.NoError(t, f.StopFlight())
+	assert.Equal(t, flightPendingSync, f.GetFlightState())
+
+	// Still down: retried later, with a longer wait.
+	f.processActions(time.Now().Add(actionRetryMin))
+	actions, _ := f.GetPendingActions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, 2, actions[0].Attempts)
+	assert.Equal(t, "server returned 503", actions[0].LastError)
+
+	status.Store(http.StatusOK)
+	f.processActions(time.Now().Add(actionRetryMin))
+	actions, _ = f.GetPendingActions()
+	assert.Len(t, actions, 1, "waits for its backoff")
+	f.processActions(time.Now().Add(actionBackoff(2)))
+	actions, _ = f.GetPendingActions()
+	assert.Empty(t, actions)
+	assert.Equal(t, "idle", f.GetFlightState())
+}
+
+func TestStartFlightWhilePendingSync(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	f := newActionTestFlight(t, &status, "")
+	f.flightData = &FlightDataService{connector: &MockSimConnector{data: sampleFlightData(), name: "TestSim"}, simActive: true}
+
+	require.NoError(t, f.StopFlight())
+	assert.Equal(t, flightPendingSync, f.GetFlightState())
+
+	status.Store(http.StatusOK)
+	require.NoError(t, f.StartFlight("AFR1", "LFPG", "EGLL"), "an unsent stop does not hold up the next flight")
+	assert.Equal(t, "active", f.GetFlightState())
+	actions, _ := f.GetPendingActions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, "BAW123", actions[0].Callsign)
+
+	require.NoError(t, f.StopFlight())
+	assert.Equal(t, flightPendingSync, f.GetFlightState(), "the first flight is still waiting")
+
+	f.processActions(time.Now().Add(actionRetryMin))
+	actions, _ = f.GetPendingActions()
+	assert.Empty(t, actions)
+	assert.Equal(t, "idle", f.GetFlightState())
+}
+
+func TestFinishFlightRefused(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusUnprocessableEntity)
+	f := newActionTestFlight(t, &status, `{"error": "flight too short"}`)
+
+	err := f.FinishFlight()
+	assert.ErrorContains(t, err, "flight too short")
+	assert.Equal(t, "active", f.GetFlightState(), "the pilot can still fly on")
+	actions, _ := f.GetPendingActions()
+	assert.Empty(t, actions)
+
+	// A queued finish the server refuses later is dropped.
+	status.Store(http.StatusBadGateway)
+	require.NoError(t, f.FinishFlight())
+	status.Store(http.StatusUnprocessableEntity)
+	f.processActions(time.Now().Add(actionRetryMin))
+	actions, _ = f.GetPendingActions()
+	assert.Empty(t, actions)
+	assert.Equal(t, "idle", f.GetFlightState())
+}
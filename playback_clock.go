@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// playbackClock tracks a position in a recorded or generated flight. The
+// position advances with wall time multiplied by the speed, and can be paused
+// and moved. It never runs past the configured duration.
+type playbackClock struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	duration time.Duration
+	speed    float64
+	paused   bool
+	base     time.Duration // position at anchor
+	anchor   time.Time
+}
+
+func newPlaybackClock(duration time.Duration, speed float64) *playbackClock {
+	if speed <= 0 {
+		speed = 1
+	}
+	c := &playbackClock{now: time.Now, duration: duration, speed: speed}
+	c.anchor = c.now()
+	return c
+}
+
+// position returns the current playback position.
+func (c *playbackClock) position() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.positionLocked()
+}
+
+func (c *playbackClock) positionLocked() time.Duration {
+	pos := c.base
+	if !c.paused {
+		pos += time.Duration(float64(c.now().Sub(c.anchor)) * c.speed)
+	}
+	if pos > c.duration {
+		pos = c.duration
+	}
+	return pos
+}
+
+// rebaseLocked freezes the current position as the new starting point, so
+// speed and pause changes only affect time from now on.
+func (c *playbackClock) rebaseLocked() {
+	c.base = c.positionLocked()
+	c.anchor = c.now()
+}
+
+func (c *playbackClock) setPaused(paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rebaseLocked()
+	c.paused = paused
+}
+
+func (c *playbackClock) isPaused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+func (c *playbackClock) setSpeed(speed float64) {
+	if speed <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rebaseLocked()
+	c.speed = speed
+}
+
+func (c *playbackClock) getSpeed() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.speed
+}
+
+// seek moves the position, clamped to the recording.
+func (c *playbackClock) seek(pos time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > c.duration {
+		pos = c.duration
+	}
+	c.base = pos
+	c.anchor = c.now()
+}
+
+// playbackController is implemented by connectors that play a flight on a
+// playbackClock instead of reading a live simulator.
+type playbackController interface {
+	Pause()
+	Resume()
+	Seek(pos time.Duration)
+	SetSpeed(speed float64)
+	Status() ReplayStatus
+}
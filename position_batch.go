@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPositionBatchSize = 100       // reports per batch upload
+	batchRenegotiateAfter    = time.Hour // a tenant without batch uploads is asked again after this
+)
+
+// Batch upload support of the tenant server.
+const (
+	batchUnknown     = iota // not asked yet
+	batchUntested           // no capability endpoint; the first batch upload tells
+	batchSupported          // batches of up to max reports
+	batchUnsupported        // one report per request
+)
+
+// acarsCapabilities is the answer of the tenant's capability endpoint.
+type acarsCapabilities struct {
+	PositionBatch    bool `json:"positionBatch"`
+	PositionBatchMax int  `json:"positionBatchMax"` // reports per batch, 0 for no limit
+}
+
+// positionBatching tracks whether the tenant server takes position reports
+// in batches. It is decided once per tenant, from the capability endpoint
+// or, for servers that predate it, from how the batch endpoint answers.
+type positionBatching struct {
+	mu      sync.Mutex
+	tenant  string
+	state   int
+	max     int
+	decided time.Time
+}
+
+// limit returns the number of reports to send per request to the tenant of
+// auth, at most want; 1 means one report per request. It asks the server the
+// first time.
+func (b *positionBatching) limit(auth *AuthService, want int) int {
+	if want <= 1 {
+		return 1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tenant := auth.tenantURL()
+	if tenant != b.tenant || (b.state == batchUnsupported && time.Since(b.decided) > batchRenegotiateAfter) {
+		b.tenant, b.state, b.max = tenant, batchUnknown, 0
+	}
+	if b.state == batchUnknown {
+		b.negotiate(auth)
+	}
+
+	switch b.state {
+	case batchSupported, batchUntested:
+		if b.max > 0 {
+			return min(want, b.max)
+		}
+		return want
+	default:
+		return 1
+	}
+}
+
+func (b *positionBatching) negotiate(auth *AuthService) {
+	body, status, err := auth.doRequest("GET", "/api/v2/acars/capabilities", nil)
+	switch {
+	case err != nil:
+		slog.Debug("failed to read server capabilities", "error", err)
+		return
+	case status == http.StatusNotFound:
+		b.decide(batchUntested, 0)
+		return
+	case status != http.StatusOK:
+		slog.Debug("failed to read server capabilities", "status", status)
+		return
+	}
+
+	var caps acarsCapabilities
+	if err := json.Unmarshal(body, &caps); err != nil {
+		slog.Warn("invalid server capabilities", "error", err)
+		b.decide(batchUnsupported, 0)
+		return
+	}
+	if caps.PositionBatch {
+		b.decide(batchSupported, caps.PositionBatchMax)
+	} else {
+		b.decide(batchUnsupported, 0)
+	}
+}
+
+func (b *positionBatching) decide(state, maxReports int) {
+	b.state, b.max, b.decided = state, maxReports, time.Now()
+	if state == batchSupported || state == batchUnsupported {
+		slog.Info("position report batching", "supported", state == batchSupported, "max", maxReports, "tenant", b.tenant)
+	}
+}
+
+// result records how the server answered a batch of n reports and reports
+// whether the batch must be sent again because the server does not take
+// batches, or not this many. Until a batch has been taken, a server that
+// refuses one is assumed not to know the endpoint: older servers answer
+// unknown routes with 400 or 403 as well as 404.
+func (b *positionBatching) result(n, status int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case status == http.StatusNotFound, status == http.StatusMethodNotAllowed,
+		status == http.StatusUnsupportedMediaType, status == http.StatusNotImplemented:
+		b.decide(batchUnsupported, 0)
+		return true
+	case status == http.StatusRequestEntityTooLarge:
+		if n/2 <= 1 {
+			b.decide(batchUnsupported, 0)
+		} else {
+			b.max = n / 2
+		}
+		return true
+	case status >= 400 && status < 500 && !retryableStatus(status) && b.state == batchUntested:
+		b.decide(batchUnsupported, 0)
+		return true
+	case status < 300 && b.state == batchUntested:
+		b.decide(batchSupported, b.max)
+	}
+	return false
+}
+
+// errBatchRefused is the server refusing a batch although it takes batches,
+// most likely over one report in it. The reports are sent again one by one.
+var errBatchRefused = errors.New("position report batch refused")
+
+// sendPositionBatch posts entries as one gzip-compressed request. It returns
+// whether the server took them; when it does not take batches, or not this
+// many, it returns false without an error so they can be sent again. A batch
+// the server refuses once batching is confirmed fails with errBatchRefused.
+func (f *FlightService) sendPositionBatch(entries []outboxEntry) (bool, error) {
+	reports := make([]json.RawMessage, len(entries))
+	for i, e := range entries {
+		reports[i] = e.report
+	}
+	body, status, err := f.auth.doGzipRequest("POST", "/api/v2/acars/position/batch", map[string]interface{}{
+		"reports": reports,
+	})
+	if err != nil {
+		return false, err
+	}
+	if f.batching.result(len(entries), status) {
+		return false, nil
+	}
+	switch {
+	case retryableStatus(status):
+		return false, fmt.Errorf("server returned %d", status)
+	case status >= 400:
+		slog.Warn("server refused position report batch, sending the reports one by one", "status", status, "reports", len(entries))
+		return false, errBatchRefused
+	default:
+		f.noteGaps(body)
+	}
+	return true, nil
+}
+
+// positionBatchSize is the batch size setting.
+func (f *FlightService) positionBatchSize() int {
+	if f.settings != nil {
+		if n := f.settings.GetSettings().PositionBatchSize; n > 0 {
+			return n
+		}
+	}
+	return defaultPositionBatchSize
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchServer records the reports of single and batch uploads.
+type batchServer struct {
+	capabilities int    // status of the capability endpoint
+	batchStatus  []int  // status of successive batch uploads, then 200
+	caps         string // capability answer
+	refuse       int    // a report refused on its own and in any batch
+	batches      [][]int
+	singles      []int
+}
+
+func (s *batchServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/acars/capabilities":
+			w.WriteHeader(s.capabilities)
+			w.Write([]byte(s.caps))
+		case "/api/v2/acars/position/batch":
+			if len(s.batchStatus) > 0 {
+				status := s.batchStatus[0]
+				s.batchStatus = s.batchStatus[1:]
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+			}
+			assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			var payload struct {
+				Reports []struct{ N int } `json:"reports"`
+			}
+			require.NoError(t, json.NewDecoder(zr).Decode(&payload))
+			var batch []int
+			for _, report := range payload.Reports {
+				if s.refuse != 0 && report.N == s.refuse {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					return
+				}
+				batch = append(batch, report.N)
+			}
+			s.batches = append(s.batches, batch)
+		case "/api/v2/acars/position":
+			var report struct{ N int }
+			json.NewDecoder(r.Body).Decode(&report)
+			if s.refuse != 0 && report.N == s.refuse {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			s.singles = append(s.singles, report.N)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func newBatchTestFlight(t *testing.T, s *batchServer, queued int) *FlightService {
+	t.Helper()
+	auth, server := newTestAuthService(s.handle(t))
+	t.Cleanup(server.Close)
+	f := &FlightService{auth: auth, outbox: newTestOutbox(t)}
+	for n := 1; n <= queued; n++ {
+		_, err := f.outbox.enqueue("flight", int64(n), map[string]interface{}{"n": n})
+		require.NoError(t, err)
+	}
+	return f
+}
+
+func TestDrainOutboxInBatches(t *testing.T) {
+	s := &batchServer{capabilities: http.StatusOK, caps: `{"positionBatch": true, "positionBatchMax": 2}`}
+	f := newBatchTestFlight(t, s, 5)
+
+	sent, err := f.drainOutbox()
+	require.NoError(t, err)
+	assert.Equal(t, 5, sent)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}}, s.batches, "capped at the server's maximum")
+	assert.Equal(t, []int{5}, s.singles, "a lone report is sent on its own")
+}
+
+func TestDrainOutboxBatchNegotiation(t *testing.T) {
+	t.Run("server without batch uploads", func(t *testing.T) {
+		s := &batchServer{capabilities: http.StatusNotFound, batchStatus: []int{http.StatusNotFound}}
+		f := newBatchTestFlight(t, s, 3)
+
+		sent, err := f.drainOutbox()
+		require.NoError(t, err)
+		assert.Equal(t, 3, sent)
+		assert.Empty(t, s.batches)
+		assert.Equal(t, []int{1, 2, 3}, s.singles)
+
+		f.outbox.enqueue("flight", 4, map[string]interface{}{"n": 4})
+		f.outbox.enqueue("flight", 5, map[string]interface{}{"n": 5})
+		_, err = f.drainOutbox()
+		require.NoError(t, err)
+		assert.Empty(t, s.batchStatus)
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, s.singles, "the batch endpoint is not tried again")
+	})
+
+	t.Run("old server refusing unknown routes with 400", func(t *testing.T) {
+		s := &batchServer{capabilities: http.StatusNotFound, batchStatus: []int{http.StatusBadRequest}}
+		f := newBatchTestFlight(t, s, 3)
+
+		sent, err := f.drainOutbox()
+		require.NoError(t, err)
+		assert.Equal(t, 3, sent)
+		assert.Empty(t, s.batches)
+		assert.Equal(t, []int{1, 2, 3}, s.singles, "the reports are sent one by one, not dropped")
+		n, _ := f.outbox.pending("")
+		assert.Zero(t, n)
+	})
+
+	t.Run("refused once batching is confirmed", func(t *testing.T) {
+		s := &batchServer{capabilities: http.StatusOK, caps: `{"positionBatch": true}`, batchStatus: []int{http.StatusUnprocessableEntity}}
+		f := newBatchTestFlight(t, s, 3)
+
+		sent, err := f.drainOutbox()
+		require.NoError(t, err)
+		assert.Equal(t, 3, sent)
+		assert.Empty(t, s.batches)
+		assert.Equal(t, []int{1, 2, 3}, s.singles, "the reports of a refused batch are sent one by one")
+	})
+
+	t.Run("one bad report in a batch", func(t *testing.T) {
+		s := &batchServer{capabilities: http.StatusOK, caps: `{"positionBatch": true}`, refuse: 2}
+		f := newBatchTestFlight(t, s, 4)
+
+		sent, err := f.drainOutbox()
+		require.NoError(t, err)
+		assert.Equal(t, 4, sent)
+		assert.Empty(t, s.batches)
+		assert.Equal(t, []int{1, 3, 4}, s.singles, "only the bad report is dropped")
+		n, _ := f.outbox.pending("")
+		assert.Zero(t, n)
+
+		f.outbox.enqueue("flight", 5, map[string]interface{}{"n": 5})
+		f.outbox.enqueue("flight", 6, map[string]interface{}{"n": 6})
+		_, err = f.drainOutbox()
+		require.NoError(t, err)
+		assert.Equal(t, [][]int{{5, 6}}, s.batches, "later backlogs still go up in batches")
+	})
+
+	t.Run("batch endpoint without capability endpoint", func(t *testing.T) {
+		s := &batchServer{capabilities: http.StatusNotFound, batchStatus: []int{http.StatusRequestEntityTooLarge}}
+		f := newBatchTestFlight(t, s, 6)
+
+		sent, err := f.drainOutbox()
+		require.NoError(t, err)
+		assert.Equal(t, 6, sent)
+		assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}}, s.batches, "halved after the server found the batch too large")
+		assert.Empty(t, s.singles)
+	})
+
+	t.Run("batching disabled by the server", func(t *testing.T) {
+		s := &batchServer{capabilities: http.StatusOK, caps: `{"positionBatch": false}`}
+		f := newBatchTestFlight(t, s, 2)
+
+		_, err := f.drainOutbox()
+		require.NoError(t, err)
+		assert.Empty(t, s.batches)
+		assert.Equal(t, []int{1, 2}, s.singles)
+	})
+}
+
+func TestDoGzipRequest(t *testing.T) {
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		var payload map[string]string
+		require.NoError(t, json.NewDecoder(zr).Decode(&payload))
+		assert.Equal(t, "value", payload["key"])
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer server.Close()
+
+	_, status, err := auth.doGzipRequest("POST", "/api/test", map[string]string{"key": "value"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, status)
+}
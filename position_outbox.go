@@ -0,0 +1,185 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	outboxBatchSize        = 50               // reports read from the outbox per drain step
+	defaultOutboxRetention = 7 * 24.0         // hours sent reports, and those of old flights, are kept
+	outboxRetryInterval    = 30 * time.Second // drain interval while no flight is active
+	outboxTimeLayout       = "2006-01-02 15:04:05"
+)
+
+// positionOutbox is the durable queue of position reports. Every report is
+// written to the position_outbox table before it is sent, so neither a long
+// outage nor a crash loses it. Reports are sent in sequence order and kept
+// for the retention period once the server has them, so the ones it reports
+// missing can be sent again.
+type positionOutbox struct {
+	db       *sql.DB
+	draining sync.Mutex      // held by the one drain in progress
+	gaps     []sequenceRange // reports the server asked for during the drain
+}
+
+// sequenceRange is a range of position report sequence numbers of a flight,
+// both ends included.
+type sequenceRange struct {
+	FlightID string `json:"flightId"`
+	From     int64  `json:"from"`
+	To       int64  `json:"to"`
+}
+
+// outboxEntry is a queued report.
+type outboxEntry struct {
+	seq    int64
+	flight string
+	report json.RawMessage
+}
+
+// OutboxStatus is the state of the position report outbox, for the UI.
+type OutboxStatus struct {
+	Pending int    `json:"pending"` // unsent reports of all flights
+	Flight  int    `json:"flight"`  // unsent reports of the active flight
+	Oldest  string `json:"oldest"`  // RFC 3339 time the oldest unsent report was queued
+}
+
+func newPositionOutbox(db *sql.DB) *positionOutbox {
+	if db == nil {
+		return nil
+	}
+	return &positionOutbox{db: db}
+}
+
+// enqueue stores the report of flight with the given sequence number and
+// returns its position in the queue.
+func (o *positionOutbox) enqueue(flight string, sequence int64, report interface{}) (int64, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return 0, fmt.Errorf("marshal report: %w", err)
+	}
+	res, err := o.db.Exec(`INSERT INTO position_outbox (flight, sequence, data) VALUES (?, ?, ?)`, flight, sequence, string(data))
+	if err != nil {
+		return 0, fmt.Errorf("queue report: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// next returns up to limit unsent reports of all flights, oldest first.
+func (o *positionOutbox) next(limit int) ([]outboxEntry, error) {
+	rows, err := o.db.Query(`SELECT seq, flight, data FROM position_outbox WHERE sent_at IS NULL ORDER BY seq LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("read outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []outboxEntry
+	for rows.Next() {
+		var e outboxEntry
+		var data string
+		if err := rows.Scan(&e.seq, &e.flight, &data); err != nil {
+			return nil, fmt.Errorf("read outbox: %w", err)
+		}
+		e.report = json.RawMessage(data)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// markSent records that the server has the reports with the given sequence
+// numbers.
+func (o *positionOutbox) markSent(seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(seqs))
+	for i, seq := range seqs {
+		args[i] = seq
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(seqs)), ",")
+	_, err := o.db.Exec(`UPDATE position_outbox SET sent_at = CURRENT_TIMESTAMP WHERE seq IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("mark reports sent: %w", err)
+	}
+	return nil
+}
+
+// pending counts the unsent reports of flight, or of all flights when flight
+// is empty.
+func (o *positionOutbox) pending(flight string) (int, error) {
+	var n int
+	var err error
+	if flight == "" {
+		err = o.db.QueryRow(`SELECT COUNT(*) FROM position_outbox WHERE sent_at IS NULL`).Scan(&n)
+	} else {
+		err = o.db.QueryRow(`SELECT COUNT(*) FROM position_outbox WHERE sent_at IS NULL AND flight = ?`, flight).Scan(&n)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("count outbox: %w", err)
+	}
+	return n, nil
+}
+
+// status summarizes the outbox; flight is the active flight, if any.
+func (o *positionOutbox) status(flight string) (OutboxStatus, error) {
+	var s OutboxStatus
+	var oldest sql.NullString
+	err := o.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(flight = ?), 0), MIN(queued_at) FROM position_outbox WHERE sent_at IS NULL`, flight).
+		Scan(&s.Pending, &s.Flight, &oldest)
+	if err != nil {
+		return s, fmt.Errorf("read outbox status: %w", err)
+	}
+	if t, err := time.Parse(outboxTimeLayout, oldest.String); err == nil {
+		s.Oldest = t.UTC().Format(time.RFC3339)
+	}
+	return s, nil
+}
+
+// lastSequence returns the highest sequence number queued for flight, 0 if
+// none.
+func (o *positionOutbox) lastSequence(flight string) (int64, error) {
+	var n int64
+	err := o.db.QueryRow(`SELECT COALESCE(MAX(sequence), 0) FROM position_outbox WHERE flight = ?`, flight).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("read outbox: %w", err)
+	}
+	return n, nil
+}
+
+// requeue marks the sent reports of r unsent so they are sent again, and
+// returns how many there were; fewer than requested were pruned or never
+// recorded.
+func (o *positionOutbox) requeue(r sequenceRange) (int64, error) {
+	res, err := o.db.Exec(`UPDATE position_outbox SET sent_at = NULL
+		WHERE flight = ? AND sequence BETWEEN ? AND ? AND sent_at IS NOT NULL`, r.FlightID, r.From, r.To)
+	if err != nil {
+		return 0, fmt.Errorf("requeue reports: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// abandon drops the unsent reports of flight and returns how many there were.
+func (o *positionOutbox) abandon(flight string) (int64, error) {
+	res, err := o.db.Exec(`DELETE FROM position_outbox WHERE sent_at IS NULL AND flight = ?`, flight)
+	if err != nil {
+		return 0, fmt.Errorf("abandon reports: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// prune deletes the sent reports older than retention, and the unsent ones
+// older than that of flights other than active: the server is not going to
+// take those anymore.
+func (o *positionOutbox) prune(retention time.Duration, active string) (int64, error) {
+	cutoff := time.Now().Add(-retention).UTC().Format(outboxTimeLayout)
+	res, err := o.db.Exec(`DELETE FROM position_outbox WHERE queued_at < ? AND (sent_at IS NOT NULL OR flight != ?)`, cutoff, active)
+	if err != nil {
+		return 0, fmt.Errorf("prune outbox: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOutbox(t *testing.T) *positionOutbox {
+	t.Helper()
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return newPositionOutbox(db)
+}
+
+func TestPositionOutboxQueue(t *testing.T) {
+	o := newTestOutbox(t)
+
+	first, err := o.enqueue("A", 1, map[string]interface{}{"n": 1})
+	require.NoError(t, err)
+	second, _ := o.enqueue("B", 1, map[string]interface{}{"n": 2})
+	third, _ := o.enqueue("A", 2, map[string]interface{}{"n": 3})
+	assert.Less(t, first, second)
+	assert.Less(t, second, third)
+
+	entries, err := o.next(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, first, entries[0].seq)
+	assert.Equal(t, "B", entries[1].flight)
+	assert.JSONEq(t, `{"n": 2}`, string(entries[1].report))
+
+	require.NoError(t, o.markSent([]int64{first, second}))
+	entries, _ = o.next(10)
+	require.Len(t, entries, 1)
+	assert.Equal(t, third, entries[0].seq)
+
+	n, _ := o.pending("A")
+	assert.Equal(t, 1, n)
+	n, _ = o.pending("B")
+	assert.Zero(t, n)
+
+	status, err := o.status("A")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Pending)
+	assert.Equal(t, 1, status.Flight)
+	_, err = time.Parse(time.RFC3339, status.Oldest)
+	assert.NoError(t, err)
+
+	dropped, err := o.abandon("A")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dropped)
+	status, _ = o.status("A")
+	assert.Equal(t, OutboxStatus{}, status)
+}
+
+func TestPositionOutboxSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := openDB(path)
+	require.NoError(t, err)
+	_, err = newPositionOutbox(db).enqueue("A", 1, map[string]interface{}{"n": 1})
+	require.NoError(t, err)
+	db.Close()
+
+	db, err = openDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	entries, err := newPositionOutbox(db).next(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "A", entries[0].flight)
+}
+
+func TestPositionOutboxPrune(t *testing.T) {
+	o := newTestOutbox(t)
+	sent, _ := o.enqueue("old", 1, map[string]interface{}{})
+	o.enqueue("old", 2, map[string]interface{}{})
+	o.enqueue("active", 1, map[string]interface{}{})
+	require.NoError(t, o.markSent([]int64{sent}))
+	_, err := o.db.Exec(`UPDATE position_outbox SET queued_at = datetime('now', '-2 days')`)
+	require.NoError(t, err)
+	o.enqueue("old", 3, map[string]interface{}{})
+
+	deleted, err := o.prune(24*time.Hour, "active")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "the sent report and the stale one of a past flight")
+
+	n, _ := o.pending("active")
+	assert.Equal(t, 1, n, "the active flight keeps its backlog")
+	n, _ = o.pending("old")
+	assert.Equal(t, 1, n, "recent reports are kept")
+}
+
+func TestDrainOutboxBackfillsGaps(t *testing.T) {
+	var received []int64
+	auth, server := newTestAuthService(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/acars/position" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var report struct{ Sequence int64 }
+		json.NewDecoder(r.Body).Decode(&report)
+		received = append(received, report.Sequence)
+		if report.Sequence == 6 {
+			w.Write([]byte(`{"missing": [{"flightId": "F", "from": 2, "to": 3}, {"flightId": "F", "from": 9, "to": 9}]}`))
+		}
+	})
+	defer server.Close()
+
+	f := &FlightService{auth: auth, outbox: newTestOutbox(t), state: "active", flightID: "F"}
+	for range 5 {
+		_, err := f.queuePositionReport("F", f.nextSequence(), map[string]interface{}{})
+		require.NoError(t, err)
+	}
+	_, err := f.queuePositionReport("F", f.nextSequence(), map[string]interface{}{})
+	require.NoError(t, err)
+	n, _ := f.outbox.pending("F")
+	assert.Equal(t, 2, n, "the reports the server missed are queued again")
+
+	_, err = f.queuePositionReport("F", f.nextSequence(), map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 2, 3, 7}, received)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	recorderQueueSize     = 1024        // samples waiting for the writer; more are dropped
+	recorderBatchSize     = 100         // samples committed together at most
+	recorderFlushInterval = time.Second // longest a sample waits for its commit
+	recorderTimeLayout    = "2006-01-02 15:04:05.000"
+	recorderSampleBuffer  = 16 // samples the recording subscription holds for recordLoop
+
+	defaultRecordingRate = 1 // Hz
+	maxRecordingRate     = touchdownSampleRate
+)
+
+// recordingRate is the rate samples are recorded at, from the settings.
+func recordingRate(s Settings) int {
+	switch {
+	case s.RecordingRate <= 0:
+		return defaultRecordingRate
+	case s.RecordingRate > maxRecordingRate:
+		return maxRecordingRate
+	}
+	return s.RecordingRate
+}
+
+// RecorderStats describes how recorded samples reach the database.
+type RecorderStats struct {
+	Queued   int     `json:"queued"`   // waiting for the writer
+	Written  int64   `json:"written"`  // since the app started
+	Dropped  int64   `json:"dropped"`  // because the queue was full
+	Failed   int64   `json:"failed"`   // lost to database errors
+	Batches  int64   `json:"batches"`  // commits
+	LagMs    float64 `json:"lagMs"`    // from the arrival of the oldest sample of the last batch until its commit
+	MaxLagMs float64 `json:"maxLagMs"` // since the app started
+}
+
+// recordedSample is a sample of a recording session waiting to be written.
+type recordedSample struct {
+	session int64
+	at      time.Time
+	data    *FlightData
+}
+
+// sampleWriter stores recorded samples from a goroutine of its own, so a slow
+// disk holds up the writer rather than the data stream. Samples are committed
+// in batches of up to batchSize, or after interval, whichever comes first.
+type sampleWriter struct {
+	db        *sql.DB
+	batchSize int
+	interval  time.Duration
+	ch        chan recordedSample
+	flushCh   chan chan struct{}
+	done      chan struct{}
+
+	mu    sync.Mutex
+	stats RecorderStats
+}
+
+func newSampleWriter(db *sql.DB, queueSize, batchSize int, interval time.Duration) *sampleWriter {
+	if db == nil {
+		return nil
+	}
+	w := &sampleWriter{
+		db:        db,
+		batchSize: batchSize,
+		interval:  interval,
+		ch:        make(chan recordedSample, queueSize),
+		flushCh:   make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// write queues a sample and reports whether there was room for it.
+func (w *sampleWriter) write(s recordedSample) bool {
+	select {
+	case w.ch <- s:
+		return true
+	default:
+		w.mu.Lock()
+		w.stats.Dropped++
+		w.mu.Unlock()
+		return false
+	}
+}
+
+// flush returns once the samples queued before it are committed.
+func (w *sampleWriter) flush() {
+	reply := make(chan struct{})
+	select {
+	case w.flushCh <- reply:
+		<-reply
+	case <-w.done:
+	}
+}
+
+// close commits the queued samples and stops the writer.
+func (w *sampleWriter) close() {
+	close(w.ch)
+	<-w.done
+}
+
+func (w *sampleWriter) getStats() RecorderStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	stats.Queued = len(w.ch)
+	return stats
+}
+
+func (w *sampleWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	batch := make([]recordedSample, 0, w.batchSize)
+	for {
+		select {
+		case s, ok := <-w.ch:
+			if !ok {
+				w.commit(batch)
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) >= w.batchSize {
+				w.commit(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.commit(batch)
+			batch = batch[:0]
+		case reply := <-w.flushCh:
+			// Whatever was queued before the flush is in the channel by now.
+			for n := len(w.ch); n > 0; n-- {
+				batch = append(batch, <-w.ch)
+				if len(batch) >= w.batchSize {
+					w.commit(batch)
+					batch = batch[:0]
+				}
+			}
+			w.commit(batch)
+			batch = batch[:0]
+			close(reply)
+		}
+	}
+}
+
+// commit writes batch in one transaction.
+func (w *sampleWriter) commit(batch []recordedSample) {
+	if len(batch) == 0 {
+		return
+	}
+	err := w.insert(batch)
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		slog.Error("failed to write recorded samples", "count", len(batch), "error", err)
+		w.stats.Failed += int64(len(batch))
+		return
+	}
+	w.stats.Written += int64(len(batch))
+	w.stats.Batches++
+	lag := float64(now.Sub(batch[0].at)) / float64(time.Millisecond)
+	w.stats.LagMs = lag
+	w.stats.MaxLagMs = max(w.stats.MaxLagMs, lag)
+}
+
+func (w *sampleWriter) insert(batch []recordedSample) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO flight_data (session_id, timestamp, data) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, s := range batch {
+		data, err := json.Marshal(s.data)
+		if err != nil {
+			return fmt.Errorf("marshal flight data: %w", err)
+		}
+		if _, err := stmt.Exec(s.session, s.at.UTC().Format(recorderTimeLayout), string(data)); err != nil {
+			return fmt.Errorf("insert flight data: %w", err)
+		}
+	}
+	return tx.Commit()
+}
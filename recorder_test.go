@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleWriterBatches(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	var mode string
+	require.NoError(t, db.QueryRow(`PRAGMA journal_mode`).Scan(&mode))
+	assert.Equal(t, "wal", mode)
+
+	w := newSampleWriter(db, 16, 4, 200*time.Millisecond)
+	defer w.close()
+	fd := sampleFlightData()
+	for range 5 {
+		require.True(t, w.write(recordedSample{session: 1, at: time.Now(), data: fd}))
+	}
+
+	// A full batch is committed at once, the rest when the interval is up.
+	assert.Eventually(t, func() bool { return w.getStats().Written == 5 }, time.Second, 5*time.Millisecond)
+	stats := w.getStats()
+	assert.Equal(t, int64(2), stats.Batches)
+	assert.Positive(t, stats.MaxLagMs)
+	assert.Equal(t, 5, countRows(t, db, `SELECT COUNT(*) FROM flight_data WHERE session_id = 1`))
+
+	// flush returns once everything queued before it is committed.
+	slow := newSampleWriter(db, 16, 100, time.Hour)
+	defer slow.close()
+	for range 3 {
+		slow.write(recordedSample{session: 2, at: time.Now(), data: fd})
+	}
+	slow.flush()
+	assert.Equal(t, 3, countRows(t, db, `SELECT COUNT(*) FROM flight_data WHERE session_id = 2`))
+}
+
+func TestSampleWriterDropsWhenFull(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// While another connection holds the write lock the writer cannot commit,
+	// so its queue fills up.
+	lock, err := db.Begin()
+	require.NoError(t, err)
+	_, err = lock.Exec(`INSERT INTO recording_sessions (started_at) VALUES ('2025-06-15 12:00:00')`)
+	require.NoError(t, err)
+
+	w := newSampleWriter(db, 2, 1, time.Hour)
+	fd := sampleFlightData()
+	accepted := 0
+	for range 10 {
+		if w.write(recordedSample{session: 1, at: time.Now(), data: fd}) {
+			accepted++
+		}
+	}
+	stats := w.getStats()
+	assert.Equal(t, int64(10-accepted), stats.Dropped)
+	assert.Positive(t, stats.Dropped)
+
+	require.NoError(t, lock.Rollback())
+	w.close()
+	stats = w.getStats()
+	assert.Equal(t, int64(accepted), stats.Written)
+	assert.Zero(t, stats.Queued)
+}
+
+func TestRecordingKeepsUpWithStream(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	fds := NewFlightDataService(db, nil)
+	defer fds.shutdown()
+	fds.connector = &MockSimConnector{name: "TestSim"}
+
+	require.NoError(t, fds.StartRecording())
+	for range 250 {
+		fds.record(telemetrySample{data: sampleFlightData(), at: time.Now()})
+	}
+	fds.StopRecording()
+
+	assert.Equal(t, 250, countRows(t, db, `SELECT COUNT(*) FROM flight_data`), "stopping writes the queued samples")
+	stats := fds.GetRecorderStats()
+	assert.Equal(t, int64(250), stats.Written)
+	assert.Zero(t, stats.Dropped)
+	sessions, err := fds.ListSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 250, sessions[0].Samples)
+}
+
+func TestRecordingRate(t *testing.T) {
+	for _, tc := range []struct {
+		rate, want int
+	}{
+		{0, 1},
+		{10, 9},
+		{50, 45},
+	} {
+		t.Run(fmt.Sprintf("%dHz", tc.rate), func(t *testing.T) {
+			db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+			require.NoError(t, err)
+			defer db.Close()
+			settings := &SettingsService{filePath: filepath.Join(t.TempDir(), "settings.json"), settings: Settings{RecordingRate: tc.rate}}
+			fds := NewFlightDataService(db, settings)
+			defer fds.shutdown()
+			fds.connector = &MockSimConnector{name: "TestSim"}
+
+			// 45 samples at 50 Hz span 880 ms, whatever the UI consumes.
+			require.NoError(t, fds.StartRecording())
+			start := time.Now()
+			for i := range 45 {
+				fds.telemetry.publishAt(sampleFlightData(), start.Add(time.Duration(i)*20*time.Millisecond))
+				time.Sleep(time.Millisecond)
+			}
+			fds.StopRecording()
+
+			assert.Equal(t, tc.want, countRows(t, db, `SELECT COUNT(*) FROM flight_data`))
+			assert.Zero(t, fds.GetRecorderStats().Dropped)
+		})
+	}
+	assert.Equal(t, maxRecordingRate, recordingRate(Settings{RecordingRate: 1000}))
+}
+
+// BenchmarkRecording records a few seconds of flight at each recording rate,
+// with the samples published as the simulator would send them. publish-µs is
+// what recording costs the stream per sample; max-lag-ms is how far the
+// database fell behind.
+func BenchmarkRecording(b *testing.B) {
+	const flight = 3 * time.Second
+	for _, hz := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("%dHz", hz), func(b *testing.B) {
+			db, err := openDB(filepath.Join(b.TempDir(), "bench.db"))
+			require.NoError(b, err)
+			defer db.Close()
+			settings := &SettingsService{filePath: filepath.Join(b.TempDir(), "settings.json"), settings: Settings{RecordingRate: hz}}
+			fds := NewFlightDataService(db, settings)
+			defer fds.shutdown()
+			fds.connector = &MockSimConnector{name: "BenchSim"}
+
+			samples := int(flight.Seconds()) * hz
+			var publishing time.Duration
+			b.ResetTimer()
+			for range b.N {
+				require.NoError(b, fds.StartRecording())
+				ticker := time.NewTicker(time.Second / time.Duration(hz))
+				for range samples {
+					<-ticker.C
+					start := time.Now()
+					fds.telemetry.publishAt(sampleFlightData(), start)
+					publishing += time.Since(start)
+				}
+				ticker.Stop()
+				fds.StopRecording()
+			}
+			b.StopTimer()
+
+			stats := fds.GetRecorderStats()
+			b.ReportMetric(float64(stats.Written)/float64(b.N), "samples/op")
+			b.ReportMetric(float64(publishing.Microseconds())/float64(b.N*samples), "publish-µs")
+			b.ReportMetric(float64(stats.Written)/float64(max(stats.Batches, 1)), "samples/batch")
+			b.ReportMetric(float64(stats.Dropped)/float64(b.N), "dropped/op")
+			b.ReportMetric(stats.MaxLagMs, "max-lag-ms")
+		})
+	}
+}
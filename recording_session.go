@@ -0,0 +1,354 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const sessionTrackPoints = 500 // most track points GetSession returns
+
+// RecordingSession is one recording, the samples taken between StartRecording
+// and StopRecording, with the flight it belongs to and a summary of what was
+// flown.
+type RecordingSession struct {
+	ID          int64        `json:"id"`
+	FlightID    string       `json:"flightId"` // "" when recorded outside a flight
+	Callsign    string       `json:"callsign"`
+	Departure   string       `json:"departure"`
+	Arrival     string       `json:"arrival"`
+	Aircraft    string       `json:"aircraft"`
+	StartTime   string       `json:"startTime"` // RFC 3339
+	EndTime     string       `json:"endTime"`   // RFC 3339, "" while recording
+	Samples     int          `json:"samples"`
+	Duration    float64      `json:"duration"`    // s
+	Distance    float64      `json:"distance"`    // NM
+	MaxAltitude float64      `json:"maxAltitude"` // ft
+	MaxGS       float64      `json:"maxGs"`       // kts
+	FuelUsed    float64      `json:"fuelUsed"`    // lbs, negative when the aircraft was refueled
+	Track       []TrackPoint `json:"track,omitempty"`
+}
+
+// TrackPoint is a point of the path of a session, for the UI's map.
+type TrackPoint struct {
+	Time      string  `json:"time"` // RFC 3339
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+// sessionFlight is the flight a recording is labelled with.
+type sessionFlight struct {
+	flightID, callsign, departure, arrival string
+}
+
+// sessionStats accumulates the summary of a session sample by sample.
+type sessionStats struct {
+	samples       int
+	aircraft      string
+	distance      float64
+	maxAltitude   float64
+	maxGS         float64
+	startFuel     float64
+	fuel          float64
+	lastLatitude  float64
+	lastLongitude float64
+}
+
+func (s *sessionStats) add(d *FlightData) {
+	if s.samples == 0 {
+		s.startFuel = d.Weight.FuelWeight
+	} else {
+		s.distance += greatCircleNM(s.lastLatitude, s.lastLongitude, d.Position.Latitude, d.Position.Longitude)
+	}
+	s.samples++
+	if s.aircraft == "" {
+		s.aircraft = d.AircraftICAO
+		if s.aircraft == "" {
+			s.aircraft = d.AircraftName
+		}
+	}
+	s.maxAltitude = max(s.maxAltitude, d.Position.Altitude)
+	s.maxGS = max(s.maxGS, d.Attitude.GS)
+	s.fuel = d.Weight.FuelWeight
+	s.lastLatitude, s.lastLongitude = d.Position.Latitude, d.Position.Longitude
+}
+
+// apply copies the summary into session.
+func (s *sessionStats) apply(session *RecordingSession) {
+	session.Samples = s.samples
+	if s.aircraft != "" {
+		session.Aircraft = s.aircraft
+	}
+	session.Distance = s.distance
+	session.MaxAltitude = s.maxAltitude
+	session.MaxGS = s.maxGS
+	session.FuelUsed = s.startFuel - s.fuel
+}
+
+// sessionStore keeps the recording sessions in the recording_sessions table;
+// their samples are the flight_data rows with their session_id.
+type sessionStore struct {
+	db *sql.DB
+}
+
+func newSessionStore(db *sql.DB) *sessionStore {
+	if db == nil {
+		return nil
+	}
+	return &sessionStore{db: db}
+}
+
+// create starts a session of flight at the given time and returns its ID.
+func (s *sessionStore) create(flight sessionFlight, at time.Time) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO recording_sessions (flight_id, callsign, departure, arrival, started_at) VALUES (?, ?, ?, ?, ?)`,
+		flight.flightID, flight.callsign, flight.departure, flight.arrival, at.UTC().Format(outboxTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("create session: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// label sets the flight of session id, for a flight started while recording.
+func (s *sessionStore) label(id int64, flight sessionFlight) error {
+	_, err := s.db.Exec(`UPDATE recording_sessions SET flight_id = ?, callsign = ?, departure = ?, arrival = ? WHERE id = ?`,
+		flight.flightID, flight.callsign, flight.departure, flight.arrival, id)
+	if err != nil {
+		return fmt.Errorf("label session: %w", err)
+	}
+	return nil
+}
+
+// finish stores the summary of session id, which ended at the given time.
+func (s *sessionStore) finish(id int64, stats *sessionStats, at time.Time) error {
+	var session RecordingSession
+	stats.apply(&session)
+	_, err := s.db.Exec(`UPDATE recording_sessions SET aircraft = ?, ended_at = ?, samples = ?, distance = ?,
+		max_altitude = ?, max_gs = ?, fuel_used = ? WHERE id = ?`,
+		session.Aircraft, at.UTC().Format(outboxTimeLayout), session.Samples, session.Distance,
+		session.MaxAltitude, session.MaxGS, session.FuelUsed, id)
+	if err != nil {
+		return fmt.Errorf("finish session: %w", err)
+	}
+	return nil
+}
+
+const sessionColumns = `id, flight_id, callsign, departure, arrival, aircraft, started_at, COALESCE(ended_at, ''),
+	samples, distance, max_altitude, max_gs, fuel_used`
+
+func scanSession(row interface{ Scan(...any) error }) (RecordingSession, error) {
+	var s RecordingSession
+	var started, ended string
+	err := row.Scan(&s.ID, &s.FlightID, &s.Callsign, &s.Departure, &s.Arrival, &s.Aircraft, &started, &ended,
+		&s.Samples, &s.Distance, &s.MaxAltitude, &s.MaxGS, &s.FuelUsed)
+	if err != nil {
+		return s, err
+	}
+	s.StartTime = rfc3339(started)
+	if ended != "" {
+		s.EndTime = rfc3339(ended)
+		start, _ := time.Parse(outboxTimeLayout, started)
+		end, _ := time.Parse(outboxTimeLayout, ended)
+		s.Duration = end.Sub(start).Seconds()
+	}
+	return s, nil
+}
+
+// list returns the sessions, newest first.
+func (s *sessionStore) list() ([]RecordingSession, error) {
+	rows, err := s.db.Query(`SELECT ` + sessionColumns + ` FROM recording_sessions ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("read sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []RecordingSession{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("read sessions: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *sessionStore) get(id int64) (RecordingSession, error) {
+	session, err := scanSession(s.db.QueryRow(`SELECT `+sessionColumns+` FROM recording_sessions WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return session, fmt.Errorf("no recording session %d", id)
+	}
+	if err != nil {
+		return session, fmt.Errorf("read session: %w", err)
+	}
+	return session, nil
+}
+
+// latest returns the ID of the newest session, or 0 when there is none.
+func (s *sessionStore) latest() (int64, error) {
+	var id int64
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM recording_sessions`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("read sessions: %w", err)
+	}
+	return id, nil
+}
+
+// samples calls fn with every sample of session id in recording order.
+func (s *sessionStore) samples(id int64, fn func(ts string, d *FlightData) error) error {
+	rows, err := s.db.Query(`SELECT timestamp, data FROM flight_data WHERE session_id = ? ORDER BY id`, id)
+	if err != nil {
+		return fmt.Errorf("query data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts, dataJSON string
+		if err := rows.Scan(&ts, &dataJSON); err != nil {
+			return fmt.Errorf("scan row: %w", err)
+		}
+		var d FlightData
+		if err := json.Unmarshal([]byte(dataJSON), &d); err != nil {
+			return fmt.Errorf("unmarshal row: %w", err)
+		}
+		if err := fn(ts, &d); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// track returns the path of session id, thinned to at most
+// sessionTrackPoints points.
+func (s *sessionStore) track(id int64, samples int) ([]TrackPoint, error) {
+	step := max(1, (samples+sessionTrackPoints-1)/sessionTrackPoints)
+	track := []TrackPoint{}
+	i := 0
+	err := s.samples(id, func(ts string, d *FlightData) error {
+		if i%step == 0 || i == samples-1 {
+			point := TrackPoint{Latitude: d.Position.Latitude, Longitude: d.Position.Longitude, Altitude: d.Position.Altitude}
+			if t, err := parseReplayTimestamp(ts); err == nil {
+				point.Time = t.UTC().Format(time.RFC3339)
+			}
+			track = append(track, point)
+		}
+		i++
+		return nil
+	})
+	return track, err
+}
+
+// export writes the samples of session id to a CSV file at path.
+func (s *sessionStore) export(id int64, path string) error {
+	if _, err := s.get(id); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write(csvHeader())
+	err = s.samples(id, func(ts string, d *FlightData) error {
+		record := []string{ts}
+		for _, c := range flightDataCSVColumns {
+			record = append(record, c.format(d))
+		}
+		return w.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	return file.Close()
+}
+
+// remove deletes session id and its samples.
+func (s *sessionStore) remove(id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM flight_data WHERE session_id = ?`, id); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM recording_sessions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return tx.Commit()
+}
+
+// prune deletes the sessions that ended more than age ago and returns how
+// many it deleted.
+func (s *sessionStore) prune(age time.Duration) (int, error) {
+	cutoff := time.Now().Add(-age).UTC().Format(outboxTimeLayout)
+	rows, err := s.db.Query(`SELECT id FROM recording_sessions WHERE ended_at IS NOT NULL AND ended_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("read sessions: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("read sessions: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := s.remove(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// recover ends the sessions a previous run left open, e.g. when it crashed
+// while recording, summarising them from their samples. A session ends with
+// its last sample.
+func (s *sessionStore) recover() (int, error) {
+	rows, err := s.db.Query(`SELECT id, started_at FROM recording_sessions WHERE ended_at IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("read sessions: %w", err)
+	}
+	open := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var started string
+		if err := rows.Scan(&id, &started); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("read sessions: %w", err)
+		}
+		open[id] = started
+	}
+	rows.Close()
+
+	for id, started := range open {
+		var stats sessionStats
+		end, _ := time.Parse(outboxTimeLayout, started)
+		err := s.samples(id, func(ts string, d *FlightData) error {
+			stats.add(d)
+			if t, err := parseReplayTimestamp(ts); err == nil {
+				end = t
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		if err := s.finish(id, &stats, end); err != nil {
+			return 0, err
+		}
+	}
+	return len(open), nil
+}
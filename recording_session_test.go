@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingSessions(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	fds := NewFlightDataService(db, nil)
+	fds.connector = &MockSimConnector{name: "TestSim"}
+
+	fds.setFlight(sessionFlight{"f1", "BAW123", "EGLL", "KJFK"})
+	require.NoError(t, fds.StartRecording())
+	for i := range 3 {
+		fd := sampleFlightData()
+		fd.AircraftICAO = "B738"
+		fd.Position.Latitude += float64(i)
+		fd.Position.Altitude = float64(1000 * i)
+		fd.Weight.FuelWeight -= float64(100 * i)
+		fds.record(telemetrySample{data: fd, at: time.Now()})
+	}
+	require.Error(t, fds.DeleteSession(fds.GetRecordingInfo()["session"].(int64)), "still recording")
+
+	live, err := fds.ListSessions()
+	require.NoError(t, err)
+	require.Len(t, live, 1)
+	assert.Equal(t, 3, live[0].Samples)
+	assert.Empty(t, live[0].EndTime)
+	fds.StopRecording()
+
+	sessions, err := fds.ListSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	s := sessions[0]
+	assert.Equal(t, "f1", s.FlightID)
+	assert.Equal(t, "BAW123", s.Callsign)
+	assert.Equal(t, "KJFK", s.Arrival)
+	assert.Equal(t, "B738", s.Aircraft)
+	assert.Equal(t, 3, s.Samples)
+	assert.InDelta(t, 120, s.Distance, 0.5)
+	assert.Equal(t, 2000.0, s.MaxAltitude)
+	assert.Equal(t, 200.0, s.FuelUsed)
+	assert.NotEmpty(t, s.EndTime)
+
+	detail, err := fds.GetSession(s.ID)
+	require.NoError(t, err)
+	assert.Len(t, detail.Track, 3)
+
+	// A second recording outside a flight is a session of its own.
+	fds.setFlight(sessionFlight{})
+	require.NoError(t, fds.StartRecording())
+	fds.record(telemetrySample{data: sampleFlightData(), at: time.Now()})
+	fds.StopRecording()
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	require.NoError(t, fds.ExportSession(s.ID, path))
+	require.NoError(t, fds.ExportSession(s.ID, path), "exporting keeps the session")
+	frames, err := loadReplayCSV(path)
+	require.NoError(t, err)
+	assert.Len(t, frames, 3)
+
+	require.NoError(t, fds.DeleteSession(s.ID))
+	sessions, _ = fds.ListSessions()
+	require.Len(t, sessions, 1)
+	assert.Empty(t, sessions[0].Callsign)
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM flight_data`).Scan(&n))
+	assert.Equal(t, 1, n, "the deleted session's samples are gone")
+	assert.Error(t, fds.ExportSession(s.ID, path))
+}
+
+func TestRecoverRecordingSessions(t *testing.T) {
+	// Samples recorded before sessions existed become an open session.
+	path := createSchemaFixture(t, "06_position_sequence")
+
+	db, err := openDB(path)
+	require.NoError(t, err)
+	defer db.Close()
+	store := newSessionStore(db)
+	n, err := store.recover()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	sessions, err := store.list()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1, "earlier samples become one session")
+	assert.Equal(t, 2, sessions[0].Samples)
+	assert.Equal(t, "2025-06-15T12:00:00Z", sessions[0].StartTime)
+	assert.Equal(t, 1.0, sessions[0].Duration)
+	assert.Equal(t, 120.0, sessions[0].MaxAltitude)
+
+	n, err = store.prune(365 * 24 * time.Hour * 100)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	n, err = store.prune(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	sessions, _ = store.list()
+	assert.Empty(t, sessions)
+}
+
+func TestExportCSVWithoutRecording(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	assert.ErrorContains(t, NewFlightDataService(db, nil).ExportCSV(path), "nothing recorded")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
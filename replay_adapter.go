@@ -0,0 +1,266 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReplayAdapter plays back a recorded flight, either the latest recording
+// session or an ExportCSV file, as if it came from a live simulator.
+type ReplayAdapter struct {
+	db     *sql.DB
+	source string // CSV file path; empty replays the latest recording session
+	speed  float64
+
+	mu           sync.Mutex
+	frames       []replayFrame
+	clock        *playbackClock
+	lastReceived time.Time
+}
+
+type replayFrame struct {
+	offset time.Duration // from the first frame
+	data   FlightData
+}
+
+// ReplayStatus describes the playback position for the frontend.
+type ReplayStatus struct {
+	Source   string  `json:"source"`
+	Position float64 `json:"position"` // seconds
+	Duration float64 `json:"duration"` // seconds
+	Speed    float64 `json:"speed"`
+	Paused   bool    `json:"paused"`
+	Frames   int     `json:"frames"`
+}
+
+func NewReplayAdapter(db *sql.DB, source string, speed float64) *ReplayAdapter {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &ReplayAdapter{db: db, source: source, speed: speed}
+}
+
+func (r *ReplayAdapter) Name() string {
+	return "Replay"
+}
+
+// Capabilities claims every field: each recorded sample lists the fields its
+// simulator could not supply.
+func (r *ReplayAdapter) Capabilities() ConnectorCapabilities {
+	return ConnectorCapabilities{Fields: allFlightDataFields}
+}
+
+func (r *ReplayAdapter) Connect() error {
+	var frames []replayFrame
+	var err error
+	if r.source != "" {
+		frames, err = loadReplayCSV(r.source)
+	} else {
+		frames, err = loadReplayDB(r.db)
+	}
+	if err != nil {
+		return err
+	}
+	if len(frames) == 0 {
+		return fmt.Errorf("recording is empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = frames
+	r.clock = newPlaybackClock(frames[len(frames)-1].offset, r.speed)
+
+	slog.Info("replay loaded", "source", r.sourceName(), "frames", len(frames), "duration", r.clock.duration)
+	return nil
+}
+
+func (r *ReplayAdapter) Disconnect() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = nil
+	r.clock = nil
+	return nil
+}
+
+// GetFlightData returns the last recorded frame at or before the playback position.
+func (r *ReplayAdapter) GetFlightData() (*FlightData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.clock == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	pos := r.clock.position()
+	i := sort.Search(len(r.frames), func(i int) bool { return r.frames[i].offset > pos })
+	if i > 0 {
+		i--
+	}
+
+	r.lastReceived = time.Now()
+	data := r.frames[i].data
+	return &data, nil
+}
+
+func (r *ReplayAdapter) LastReceived() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastReceived
+}
+
+func (r *ReplayAdapter) Pause()  { r.withClock(func(c *playbackClock) { c.setPaused(true) }) }
+func (r *ReplayAdapter) Resume() { r.withClock(func(c *playbackClock) { c.setPaused(false) }) }
+
+// Seek moves playback to pos from the start of the recording.
+func (r *ReplayAdapter) Seek(pos time.Duration) {
+	r.withClock(func(c *playbackClock) { c.seek(pos) })
+}
+
+// SetSpeed changes the playback speed multiplier (1 = real time).
+func (r *ReplayAdapter) SetSpeed(speed float64) {
+	r.withClock(func(c *playbackClock) { c.setSpeed(speed) })
+}
+
+func (r *ReplayAdapter) Status() ReplayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := ReplayStatus{Source: r.sourceName(), Speed: r.speed, Frames: len(r.frames)}
+	if r.clock != nil {
+		status.Position = r.clock.position().Seconds()
+		status.Duration = r.clock.duration.Seconds()
+		status.Speed = r.clock.getSpeed()
+		status.Paused = r.clock.isPaused()
+	}
+	return status
+}
+
+func (r *ReplayAdapter) withClock(fn func(c *playbackClock)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.clock != nil {
+		fn(r.clock)
+	}
+}
+
+func (r *ReplayAdapter) sourceName() string {
+	if r.source == "" {
+		return "recording"
+	}
+	return r.source
+}
+
+// replayTimestampLayouts covers SQLite CURRENT_TIMESTAMP and the RFC 3339
+// form the driver returns when scanning DATETIME columns into strings.
+var replayTimestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+func parseReplayTimestamp(s string) (time.Time, error) {
+	for _, layout := range replayTimestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", s)
+}
+
+// loadReplayDB reads the samples of the latest recording session.
+func loadReplayDB(db *sql.DB) ([]replayFrame, error) {
+	sessions := newSessionStore(db)
+	if sessions == nil {
+		return nil, fmt.Errorf("no database")
+	}
+	id, err := sessions.latest()
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []replayFrame
+	var start time.Time
+	err = sessions.samples(id, func(ts string, d *FlightData) error {
+		t, err := parseReplayTimestamp(ts)
+		if err != nil {
+			return err
+		}
+		if len(frames) == 0 {
+			start = t
+		}
+		frames = append(frames, replayFrame{offset: t.Sub(start), data: *d})
+		return nil
+	})
+	return frames, err
+}
+
+// loadReplayCSV reads a file written by ExportCSV. Columns are matched by
+// header name; rows longer than an older, shorter header fall back to the
+// current column order.
+func loadReplayCSV(path string) ([]replayFrame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open replay file: %w", err)
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if len(header) == 0 || header[0] != "timestamp" {
+		return nil, fmt.Errorf("not an ACARS CSV export: first column must be timestamp")
+	}
+
+	columns := make(map[string]csvColumn, len(flightDataCSVColumns))
+	for _, c := range flightDataCSVColumns {
+		columns[c.name] = c
+	}
+	canonical := csvHeader()
+
+	var frames []replayFrame
+	var start time.Time
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		names := header
+		if len(record) > len(names) && len(canonical) >= len(record) && slices.Equal(canonical[:len(names)], names) {
+			names = canonical[:len(record)]
+		}
+
+		t, err := parseReplayTimestamp(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		d := FlightData{Sensors: SensorData{SimulationRate: 1}}
+		for i := 1; i < len(record) && i < len(names); i++ {
+			c, ok := columns[names[i]]
+			if !ok {
+				continue
+			}
+			if err := c.parse(&d, record[i]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+
+		if len(frames) == 0 {
+			start = t
+		}
+		frames = append(frames, replayFrame{offset: t.Sub(start), data: d})
+	}
+	return frames, nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced time source for playbackClock.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newFakeClock() *fakeClock               { return &fakeClock{t: time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)} }
+func (c *fakeClock) attach(p *playbackClock) { p.now = c.now; p.anchor = c.t }
+
+// newTestSession starts an empty recording session.
+func newTestSession(t *testing.T, db *sql.DB) int64 {
+	t.Helper()
+	id, err := newSessionStore(db).create(sessionFlight{}, time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	return id
+}
+
+// insertReplayRow stores a sample of session with an explicit timestamp.
+func insertReplayRow(t *testing.T, db *sql.DB, session int64, ts string, fd *FlightData) {
+	t.Helper()
+	data, err := json.Marshal(fd)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO flight_data (session_id, timestamp, data) VALUES (?, ?, ?)`, session, ts, string(data))
+	require.NoError(t, err)
+}
+
+func TestPlaybackClock(t *testing.T) {
+	fc := newFakeClock()
+	c := newPlaybackClock(time.Minute, 1)
+	fc.attach(c)
+
+	fc.advance(10 * time.Second)
+	assert.Equal(t, 10*time.Second, c.position())
+
+	c.setSpeed(4)
+	fc.advance(5 * time.Second)
+	assert.Equal(t, 30*time.Second, c.position())
+
+	c.setPaused(true)
+	fc.advance(time.Hour)
+	assert.Equal(t, 30*time.Second, c.position(), "paused clock does not move")
+
+	c.seek(5 * time.Second)
+	assert.Equal(t, 5*time.Second, c.position())
+
+	c.setPaused(false)
+	fc.advance(time.Hour)
+	assert.Equal(t, time.Minute, c.position(), "position is clamped to the duration")
+
+	c.seek(-time.Second)
+	assert.Equal(t, time.Duration(0), c.position())
+}
+
+func TestLoadReplayCSVRepoSample(t *testing.T) {
+	frames, err := loadReplayCSV("flight_data.csv")
+	require.NoError(t, err)
+	require.Len(t, frames, 6)
+
+	first := frames[0].data
+	assert.Equal(t, -23.4250, first.Position.Latitude)
+	assert.Equal(t, -46.4489, first.Position.Longitude)
+	assert.True(t, first.Sensors.OnGround)
+	assert.Equal(t, 29.7958, first.Altimeter)
+	assert.True(t, first.Controls.GearDown, "columns beyond the short header use the current order")
+	assert.True(t, first.Lights.Beacon)
+	assert.Equal(t, 1.0, first.Sensors.SimulationRate)
+
+	assert.Equal(t, time.Duration(0), frames[0].offset)
+	assert.Equal(t, time.Second, frames[1].offset)
+}
+
+func TestLoadReplayCSVRoundTrip(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	fd := sampleFlightData()
+	fd.Controls.Flaps = 15
+	fd.Autopilot.Master = true
+	session := newTestSession(t, db)
+	insertReplayRow(t, db, session, "2025-06-15 12:00:00", fd)
+	insertReplayRow(t, db, session, "2025-06-15 12:00:02", fd)
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	fds := NewFlightDataService(db, nil)
+	require.NoError(t, fds.ExportCSV(path))
+
+	frames, err := loadReplayCSV(path)
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+	assert.Equal(t, 2*time.Second, frames[1].offset)
+	assert.Equal(t, fd.Position.Latitude, frames[0].data.Position.Latitude)
+	assert.Equal(t, 15.0, frames[0].data.Controls.Flaps)
+	assert.True(t, frames[0].data.Autopilot.Master)
+	assert.Equal(t, fd.Engines[0].FuelFlow, frames[0].data.Engines[0].FuelFlow)
+	assert.Equal(t, fd.Weight.FuelTanks, frames[0].data.Weight.FuelTanks)
+	assert.Equal(t, fd.Environment, frames[0].data.Environment)
+	assert.True(t, frames[0].data.Controls.ParkingBrake)
+}
+
+func TestLoadReplayCSVRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.csv")
+	require.NoError(t, os.WriteFile(path, []byte("a,b\n1,2\n"), 0o644))
+
+	_, err := loadReplayCSV(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timestamp")
+}
+
+func TestReplayAdapterFromDB(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	older := newTestSession(t, db)
+	insertReplayRow(t, db, older, "2025-06-14 12:00:00", sampleFlightData())
+	session := newTestSession(t, db)
+	for i, gs := range []float64{0, 5, 10, 15} {
+		fd := sampleFlightData()
+		fd.Attitude.GS = gs
+		insertReplayRow(t, db, session, time.Date(2025, 6, 15, 12, 0, i*10, 0, time.UTC).Format("2006-01-02 15:04:05"), fd)
+	}
+
+	r := NewReplayAdapter(db, "", 2)
+	_, err = r.GetFlightData()
+	require.Error(t, err, "not connected yet")
+
+	require.NoError(t, r.Connect())
+	fc := newFakeClock()
+	fc.attach(r.clock)
+
+	gs := func() float64 {
+		d, err := r.GetFlightData()
+		require.NoError(t, err)
+		return d.Attitude.GS
+	}
+
+	assert.Equal(t, 0.0, gs())
+	fc.advance(5 * time.Second) // 10s of recording at 2x
+	assert.Equal(t, 5.0, gs())
+	assert.False(t, r.LastReceived().IsZero())
+
+	r.Pause()
+	fc.advance(time.Minute)
+	assert.Equal(t, 5.0, gs())
+
+	r.Seek(25 * time.Second)
+	assert.Equal(t, 10.0, gs())
+
+	r.Resume()
+	r.SetSpeed(1)
+	fc.advance(time.Hour)
+	assert.Equal(t, 15.0, gs(), "holds the last frame at the end")
+
+	status := r.Status()
+	assert.Equal(t, 30.0, status.Position)
+	assert.Equal(t, 30.0, status.Duration)
+	assert.Equal(t, 4, status.Frames)
+	assert.Equal(t, "recording", status.Source)
+
+	require.NoError(t, r.Disconnect())
+	_, err = r.GetFlightData()
+	require.Error(t, err)
+}
+
+func TestReplayAdapterEmptyRecording(t *testing.T) {
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = NewReplayAdapter(db, "", 1).Connect()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "empty")
+}
+
+func TestFlightDataServiceReplayControls(t *testing.T) {
+	fds := &FlightDataService{connector: &MockSimConnector{name: "TestSim"}}
+	require.Error(t, fds.ReplayPause(), "not a replay")
+
+	r := NewReplayAdapter(nil, "flight_data.csv", 1)
+	require.NoError(t, r.Connect())
+	fds.connector = r
+
+	require.NoError(t, fds.ReplayPause())
+	require.NoError(t, fds.ReplaySeek(3))
+	require.Error(t, fds.ReplaySetSpeed(0))
+	require.NoError(t, fds.ReplaySetSpeed(8))
+
+	status, err := fds.GetReplayStatus()
+	require.NoError(t, err)
+	assert.True(t, status.Paused)
+	assert.Equal(t, 3.0, status.Position)
+	assert.Equal(t, 8.0, status.Speed)
+	assert.Equal(t, "flight_data.csv", status.Source)
+}
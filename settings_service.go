@@ -10,14 +10,39 @@ import (
 
 type Settings struct {
 	Theme           string `json:"theme"`
-	SimType         string `json:"simType"`
+	SimType         string `json:"simType"` // auto, simconnect, xplane, xplaneweb, flightgear, foreflight, replay or synthetic
 	XPlaneHost      string `json:"xplaneHost"`
 	XPlanePort      int    `json:"xplanePort"`
+	XPlaneLocalPort int    `json:"xplaneLocalPort"` // UDP port X-Plane replies to; 0 picks a free one
+	XPlaneComputer  string `json:"xplaneComputer"`  // discovered instance picked by the pilot
+	XPlaneDatarefs  string `json:"xplaneDatarefs"`  // JSON dataref overrides for add-on aircraft
+	XPlaneWebPort   int    `json:"xplaneWebPort"`   // X-Plane 12 web API, usually 8086
+	FlightGearPort  int    `json:"flightGearPort"`  // UDP port FlightGear's generic output is sent to
+	ForeFlightPort  int    `json:"foreFlightPort"`  // UDP port of XGPS/XATT broadcasts, usually 49002
 	APIBaseURL      string `json:"apiBaseURL"`
 	LocalMode       bool   `json:"localMode"`
 	ChatSound       string `json:"chatSound"`
 	DiscordPresence bool   `json:"discordPresence"`
 	Language        string `json:"language"`
+
+	TouchdownSampleAGL float64 `json:"touchdownSampleAGL"` // ft, high-rate touchdown sampling below this
+	SpikeWindow        float64 `json:"spikeWindow"`        // s a jump in position or altitude must persist before it is believed
+	OutboxRetention    float64 `json:"outboxRetention"`    // hours sent position reports are kept, and unsent ones of past flights
+	PositionBatchSize  int     `json:"positionBatchSize"`  // queued position reports sent per request; 1 disables batching
+
+	DeadReckoning      bool    `json:"deadReckoning"`      // report only when the aircraft departs from its last reported state
+	DRHeadingThreshold float64 `json:"drHeadingThreshold"` // deg
+	DRSpeedThreshold   float64 `json:"drSpeedThreshold"`   // kts of ground speed
+	DRVSThreshold      float64 `json:"drVSThreshold"`      // fpm
+	DRMaxInterval      float64 `json:"drMaxInterval"`      // s between reports at most
+
+	RecordingRetention float64 `json:"recordingRetention"` // days recording sessions are kept; 0 keeps them until deleted
+	RecordingRate      int     `json:"recordingRate"`      // Hz samples are recorded at, up to 50
+
+	ReplaySource string  `json:"replaySource"` // CSV export to replay; empty replays the latest recording session
+	ReplaySpeed  float64 `json:"replaySpeed"`  // replay and synthetic playback multiplier, 1 = real time
+
+	SyntheticProfile string `json:"syntheticProfile"` // FlightProfile JSON for the synthetic connector; empty uses the built-in flight
 }
 
 type SettingsService struct {
@@ -35,12 +60,26 @@ func NewSettingsService() *SettingsService {
 		settings: Settings{
 			Theme:           "dark",
 			SimType:         "auto",
-			XPlaneHost:      "127.0.0.1",
-			XPlanePort:      49000,
+			XPlaneHost:      defaultXPlaneHost,
+			XPlanePort:      defaultXPlanePort,
+			XPlaneWebPort:   defaultXPlaneWebPort,
+			FlightGearPort:  defaultFlightGearPort,
+			ForeFlightPort:  defaultForeFlightPort,
 			APIBaseURL:      "https://airspace.ferrlab.com",
 			ChatSound:       "default",
 			DiscordPresence: true,
 			Language:        "en",
+
+			TouchdownSampleAGL: defaultTouchdownAGL,
+			SpikeWindow:        defaultSpikeWindow,
+			OutboxRetention:    defaultOutboxRetention,
+			PositionBatchSize:  defaultPositionBatchSize,
+			DRHeadingThreshold: defaultDRHeading,
+			DRSpeedThreshold:   defaultDRSpeed,
+			DRVSThreshold:      defaultDRVS,
+			DRMaxInterval:      defaultDRMaxInterval,
+			RecordingRate:      defaultRecordingRate,
+			ReplaySpeed:        1,
 		},
 	}
 	s.load()
@@ -1,6 +1,10 @@
 package main
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"time"
+)
 
 // FlightData holds real-time telemetry from the flight simulator.
 type FlightData struct {
@@ -17,7 +21,17 @@ type FlightData struct {
 	APU          APUData           `json:"apu"`
 	Doors        [5]DoorData       `json:"doors"`
 	AircraftName string            `json:"aircraftName"`
+	AircraftICAO string            `json:"aircraftIcao"`
+	TailNumber   string            `json:"tailNumber"`
 	Weight       WeightData        `json:"weight"`
+	Environment  EnvironmentData   `json:"environment"`
+	Invalid      []string          `json:"invalidFields,omitempty"` // fields without a value in this sample
+}
+
+// Valid reports whether field (see allFlightDataFields) holds a value from the
+// simulator rather than a zero it cannot supply.
+func (d *FlightData) Valid(field string) bool {
+	return !slices.Contains(d.Invalid, field)
 }
 
 type PositionData struct {
@@ -47,6 +61,7 @@ type EngineData struct {
 	ThrottlePos float64 `json:"throttlePos"`
 	MixturePos  float64 `json:"mixturePos"`
 	PropPos     float64 `json:"propPos"`
+	FuelFlow    float64 `json:"fuelFlow"` // lbs/h
 }
 
 type SensorData struct {
@@ -54,6 +69,7 @@ type SensorData struct {
 	StallWarning     bool    `json:"stallWarning"`
 	OverspeedWarning bool    `json:"overspeedWarning"`
 	SimulationRate   float64 `json:"simulationRate"`
+	Pushback         bool    `json:"pushback"` // being pushed or towed by a tug
 }
 
 type RadioData struct {
@@ -90,6 +106,9 @@ type FlightControlData struct {
 	Flaps    float64 `json:"flaps"`
 	Spoilers float64 `json:"spoilers"`
 	GearDown bool    `json:"gearDown"`
+
+	FlapsDetent  float64 `json:"flapsDetent"` // flap handle position, 0=up
+	ParkingBrake bool    `json:"parkingBrake"`
 }
 
 type SimTimeData struct {
@@ -112,8 +131,24 @@ type DoorData struct {
 }
 
 type WeightData struct {
-	TotalWeight float64 `json:"totalWeight"` // lbs
-	FuelWeight  float64 `json:"fuelWeight"`  // lbs
+	TotalWeight float64    `json:"totalWeight"` // lbs
+	FuelWeight  float64    `json:"fuelWeight"`  // lbs
+	FuelTanks   [4]float64 `json:"fuelTanks"`   // lbs, see fuelTankNames
+}
+
+// fuelTankNames label WeightData.FuelTanks: the main tank of the left wing,
+// the centre tank, the main tank of the right wing and every other tank added
+// up. SimConnect has the tanks by name; X-Plane's are sorted by where they sit,
+// see xplaneFuelTanks.
+var fuelTankNames = [4]string{"left", "center", "right", "auxiliary"}
+
+// EnvironmentData is the weather at the aircraft.
+type EnvironmentData struct {
+	OAT           float64 `json:"oat"`           // °C
+	WindDirection float64 `json:"windDirection"` // degrees true, where the wind blows from
+	WindSpeed     float64 `json:"windSpeed"`     // kts
+	QNH           float64 `json:"qnh"`           // hPa, sea level pressure
+	Visibility    float64 `json:"visibility"`    // m
 }
 
 // TransponderStateString maps a numeric transponder mode to a human-readable string.
@@ -137,4 +172,45 @@ type SimConnector interface {
 	GetFlightData() (*FlightData, error)
 	Name() string
 	LastReceived() time.Time
+	Capabilities() ConnectorCapabilities
+}
+
+// ConnectorCapabilities describes what a connector can supply. Fields it
+// lacks read zero; samples also list them in FlightData.Invalid.
+type ConnectorCapabilities struct {
+	Fields []string `json:"fields"` // sorted, see allFlightDataFields
+}
+
+// Supports reports whether the connector fills field.
+func (c ConnectorCapabilities) Supports(field string) bool {
+	_, found := slices.BinarySearch(c.Fields, field)
+	return found
+}
+
+// RateAdjustable is implemented by connectors that can deliver samples faster
+// than the default 1 Hz, e.g. for touchdown analysis.
+type RateAdjustable interface {
+	SetUpdateRate(hz int)
+}
+
+// SamplePusher is implemented by connectors that receive samples as the
+// simulator sends them. The connector calls sink with a copy of every new
+// sample; sink must not block. Connectors without it are polled.
+type SamplePusher interface {
+	SetSampleSink(sink func(*FlightData))
+}
+
+// RemoteEndpoint is implemented by connectors that reach the simulator over
+// the network. Endpoint returns the remote address for display.
+type RemoteEndpoint interface {
+	Endpoint() string
+}
+
+// connectorLabel names a connector for the UI, with the remote address when
+// the simulator runs on another machine or port.
+func connectorLabel(c SimConnector) string {
+	if re, ok := c.(RemoteEndpoint); ok {
+		return fmt.Sprintf("%s (%s)", c.Name(), re.Endpoint())
+	}
+	return c.Name()
 }
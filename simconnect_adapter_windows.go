@@ -19,6 +19,8 @@ type SimConnectAdapter struct {
 	lastReceived time.Time
 	stopCh       chan struct{}
 	stopped      chan struct{}
+	rateCh       chan int
+	sink         func(*FlightData)
 }
 
 type simReport struct {
@@ -47,6 +49,7 @@ type simReport struct {
 	Eng1Throttle    float64 `name:"GENERAL ENG THROTTLE LEVER POSITION:1" unit:"Percent"`
 	Eng1Mixture     float64 `name:"GENERAL ENG MIXTURE LEVER POSITION:1" unit:"Percent"`
 	Eng1Prop        float64 `name:"GENERAL ENG PROPELLER LEVER POSITION:1" unit:"Percent"`
+	Eng1FuelFlow    float64 `name:"ENG FUEL FLOW PPH:1" unit:"Pounds per hour"`
 
 	// Engine 2
 	Eng2Running     float64 `name:"GENERAL ENG COMBUSTION:2" unit:"Bool"`
@@ -55,6 +58,7 @@ type simReport struct {
 	Eng2Throttle    float64 `name:"GENERAL ENG THROTTLE LEVER POSITION:2" unit:"Percent"`
 	Eng2Mixture     float64 `name:"GENERAL ENG MIXTURE LEVER POSITION:2" unit:"Percent"`
 	Eng2Prop        float64 `name:"GENERAL ENG PROPELLER LEVER POSITION:2" unit:"Percent"`
+	Eng2FuelFlow    float64 `name:"ENG FUEL FLOW PPH:2" unit:"Pounds per hour"`
 
 	// Engine 3
 	Eng3Running     float64 `name:"GENERAL ENG COMBUSTION:3" unit:"Bool"`
@@ -63,6 +67,7 @@ type simReport struct {
 	Eng3Throttle    float64 `name:"GENERAL ENG THROTTLE LEVER POSITION:3" unit:"Percent"`
 	Eng3Mixture     float64 `name:"GENERAL ENG MIXTURE LEVER POSITION:3" unit:"Percent"`
 	Eng3Prop        float64 `name:"GENERAL ENG PROPELLER LEVER POSITION:3" unit:"Percent"`
+	Eng3FuelFlow    float64 `name:"ENG FUEL FLOW PPH:3" unit:"Pounds per hour"`
 
 	// Engine 4
 	Eng4Running     float64 `name:"GENERAL ENG COMBUSTION:4" unit:"Bool"`
@@ -71,12 +76,14 @@ type simReport struct {
 	Eng4Throttle    float64 `name:"GENERAL ENG THROTTLE LEVER POSITION:4" unit:"Percent"`
 	Eng4Mixture     float64 `name:"GENERAL ENG MIXTURE LEVER POSITION:4" unit:"Percent"`
 	Eng4Prop        float64 `name:"GENERAL ENG PROPELLER LEVER POSITION:4" unit:"Percent"`
+	Eng4FuelFlow    float64 `name:"ENG FUEL FLOW PPH:4" unit:"Pounds per hour"`
 
 	// Sensors
 	OnGround         float64 `name:"SIM ON GROUND" unit:"Bool"`
 	StallWarning     float64 `name:"STALL WARNING" unit:"Bool"`
 	OverspeedWarning float64 `name:"OVERSPEED WARNING" unit:"Bool"`
 	SimulationRate   float64 `name:"SIMULATION RATE" unit:"number"`
+	PushbackState    float64 `name:"PUSHBACK STATE" unit:"Enum"` // 3=no pushback
 
 	// Radios
 	Com1      float64 `name:"COM ACTIVE FREQUENCY:1" unit:"MHz"`
@@ -113,6 +120,9 @@ type simReport struct {
 	Spoilers float64 `name:"SPOILERS HANDLE POSITION" unit:"Percent Over 100"`
 	GearDown float64 `name:"GEAR HANDLE POSITION" unit:"Bool"`
 
+	FlapsDetent  float64 `name:"FLAPS HANDLE INDEX" unit:"number"`
+	ParkingBrake float64 `name:"BRAKE PARKING POSITION" unit:"Bool"`
+
 	// SimTime
 	ZuluTime  float64 `name:"ZULU TIME" unit:"seconds"`
 	ZuluDay   float64 `name:"ZULU DAY OF MONTH" unit:"number"`
@@ -140,6 +150,19 @@ type simReport struct {
 	TotalWeight float64 `name:"TOTAL WEIGHT" unit:"pounds"`
 	FuelWeight  float64 `name:"FUEL TOTAL QUANTITY WEIGHT" unit:"pounds"`
 
+	// Fuel tanks, in gallons as there are no weight simvars for them
+	FuelPerGallon float64 `name:"FUEL WEIGHT PER GALLON" unit:"pounds"`
+	FuelLeftMain  float64 `name:"FUEL TANK LEFT MAIN QUANTITY" unit:"gallons"`
+	FuelCenter    float64 `name:"FUEL TANK CENTER QUANTITY" unit:"gallons"`
+	FuelRightMain float64 `name:"FUEL TANK RIGHT MAIN QUANTITY" unit:"gallons"`
+
+	// Environment
+	OAT           float64 `name:"AMBIENT TEMPERATURE" unit:"celsius"`
+	WindDirection float64 `name:"AMBIENT WIND DIRECTION" unit:"degrees"`
+	WindSpeed     float64 `name:"AMBIENT WIND VELOCITY" unit:"knots"`
+	QNH           float64 `name:"SEA LEVEL PRESSURE" unit:"millibars"`
+	Visibility    float64 `name:"AMBIENT VISIBILITY" unit:"meters"`
+
 	// Engine count
 	NumberOfEngines float64 `name:"NUMBER OF ENGINES" unit:"number"`
 
@@ -158,6 +181,7 @@ func (s *SimConnectAdapter) Name() string {
 func (s *SimConnectAdapter) Connect() error {
 	s.stopCh = make(chan struct{})
 	s.stopped = make(chan struct{})
+	s.rateCh = make(chan int, 1)
 	errCh := make(chan error, 1)
 
 	go s.run(errCh)
@@ -224,6 +248,8 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 		select {
 		case <-s.stopCh:
 			return
+		case hz := <-s.rateCh:
+			requestTicker.Reset(time.Second / time.Duration(hz))
 		case <-requestTicker.C:
 			sc.RequestDataOnSimObjectType(0, defineID, 0, sim.SIMOBJECT_TYPE_USER)
 		default:
@@ -265,6 +291,7 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 							ThrottlePos: r.Eng1Throttle,
 							MixturePos:  r.Eng1Mixture,
 							PropPos:     r.Eng1Prop,
+							FuelFlow:    r.Eng1FuelFlow,
 						},
 						{
 							Exists:      int(r.NumberOfEngines) >= 2,
@@ -274,6 +301,7 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 							ThrottlePos: r.Eng2Throttle,
 							MixturePos:  r.Eng2Mixture,
 							PropPos:     r.Eng2Prop,
+							FuelFlow:    r.Eng2FuelFlow,
 						},
 						{
 							Exists:      int(r.NumberOfEngines) >= 3,
@@ -283,6 +311,7 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 							ThrottlePos: r.Eng3Throttle,
 							MixturePos:  r.Eng3Mixture,
 							PropPos:     r.Eng3Prop,
+							FuelFlow:    r.Eng3FuelFlow,
 						},
 						{
 							Exists:      int(r.NumberOfEngines) >= 4,
@@ -292,6 +321,7 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 							ThrottlePos: r.Eng4Throttle,
 							MixturePos:  r.Eng4Mixture,
 							PropPos:     r.Eng4Prop,
+							FuelFlow:    r.Eng4FuelFlow,
 						},
 					},
 					Sensors: SensorData{
@@ -299,6 +329,7 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 						StallWarning:     r.StallWarning != 0,
 						OverspeedWarning: r.OverspeedWarning != 0,
 						SimulationRate:   r.SimulationRate,
+						Pushback:         r.PushbackState != 3,
 					},
 					Radios: RadioData{
 						Com1:      r.Com1,
@@ -332,6 +363,9 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 						Flaps:    r.Flaps * 100,    // Percent Over 100 → percent
 						Spoilers: r.Spoilers * 100, // Percent Over 100 → percent
 						GearDown: r.GearDown != 0,
+
+						FlapsDetent:  r.FlapsDetent,
+						ParkingBrake: r.ParkingBrake != 0,
 					},
 					SimTime: SimTimeData{
 						ZuluTime:  r.ZuluTime,
@@ -357,12 +391,26 @@ func (s *SimConnectAdapter) run(errCh chan<- error) {
 					Weight: WeightData{
 						TotalWeight: r.TotalWeight,
 						FuelWeight:  r.FuelWeight,
+						FuelTanks:   simConnectFuelTanks(r),
+					},
+					Environment: EnvironmentData{
+						OAT:           r.OAT,
+						WindDirection: r.WindDirection,
+						WindSpeed:     r.WindSpeed,
+						QNH:           r.QNH,
+						Visibility:    r.Visibility,
 					},
 				}
+				fd.Invalid = simConnectUnsupported
 				s.mu.Lock()
 				s.latestData = fd
 				s.lastReceived = time.Now()
+				sink := s.sink
 				s.mu.Unlock()
+				if sink != nil {
+					sample := *fd
+					sink(&sample)
+				}
 			case sim.RECV_ID_EXCEPTION:
 				slog.Warn("SimConnect exception received")
 			}
@@ -380,6 +428,16 @@ func trimNullBytes(b []byte) string {
 	return string(b)
 }
 
+// simConnectFuelTanks fills the slots of WeightData.FuelTanks. The auxiliary
+// slot is whatever fuel is not in the three main tanks: auxiliary, tip,
+// additional centre and external tanks.
+func simConnectFuelTanks(r *simReport) [4]float64 {
+	left := r.FuelLeftMain * r.FuelPerGallon
+	center := r.FuelCenter * r.FuelPerGallon
+	right := r.FuelRightMain * r.FuelPerGallon
+	return [4]float64{left, center, right, max(r.FuelWeight-left-center-right, 0)}
+}
+
 // GetFlightData returns the most recently cached flight data.
 func (s *SimConnectAdapter) GetFlightData() (*FlightData, error) {
 	s.mu.RLock()
@@ -394,6 +452,36 @@ func (s *SimConnectAdapter) GetFlightData() (*FlightData, error) {
 	return &data, nil
 }
 
+// SetUpdateRate changes how often data is requested from the simulator.
+func (s *SimConnectAdapter) SetUpdateRate(hz int) {
+	if hz <= 0 || s.rateCh == nil {
+		return
+	}
+	// Keep only the most recent request if run has not picked up the last one.
+	select {
+	case <-s.rateCh:
+	default:
+	}
+	select {
+	case s.rateCh <- hz:
+	default:
+	}
+}
+
+// simConnectUnsupported are the fields simReport does not request.
+var simConnectUnsupported = []string{"aircraftIcao", "tailNumber"}
+
+func (s *SimConnectAdapter) Capabilities() ConnectorCapabilities {
+	return ConnectorCapabilities{Fields: fieldsExcept(simConnectUnsupported)}
+}
+
+// SetSampleSink implements SamplePusher; sink gets every data dispatch.
+func (s *SimConnectAdapter) SetSampleSink(sink func(*FlightData)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sink = sink
+}
+
 // LastReceived returns the time the most recent data dispatch was received.
 func (s *SimConnectAdapter) LastReceived() time.Time {
 	s.mu.RLock()
@@ -28,3 +28,12 @@ func TestTrimNullBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestSimConnectFuelTanks(t *testing.T) {
+	r := &simReport{FuelWeight: 5000, FuelPerGallon: 6.7, FuelLeftMain: 200, FuelCenter: 100, FuelRightMain: 200}
+	tanks := simConnectFuelTanks(r)
+	assert.InDeltaSlice(t, []float64{1340, 670, 1340, 1650}, tanks[:], 0.01, "the rest of the fuel is auxiliary")
+
+	r.FuelWeight = 3000 // rounding in the simulator
+	assert.Zero(t, simConnectFuelTanks(r)[3])
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// FlightProfile declares a gate-to-gate flight for the synthetic connector.
+// Fields missing from a profile file keep the values of defaultFlightProfile.
+type FlightProfile struct {
+	Aircraft           string  `json:"aircraft"`
+	DepartureLat       float64 `json:"departureLat"`
+	DepartureLon       float64 `json:"departureLon"`
+	DepartureElevation float64 `json:"departureElevation"` // ft MSL
+	ArrivalLat         float64 `json:"arrivalLat"`
+	ArrivalLon         float64 `json:"arrivalLon"`
+	ArrivalElevation   float64 `json:"arrivalElevation"` // ft MSL
+	CruiseAltitude     float64 `json:"cruiseAltitude"`   // ft MSL
+	CruiseSpeed        float64 `json:"cruiseSpeed"`      // kts ground speed
+	ClimbRate          float64 `json:"climbRate"`        // fpm
+	DescentRate        float64 `json:"descentRate"`      // fpm
+	BoardingTime       float64 `json:"boardingTime"`     // seconds at the gate before pushback
+	TaxiOutTime        float64 `json:"taxiOutTime"`      // seconds
+	TaxiInTime         float64 `json:"taxiInTime"`       // seconds
+	Engines            int     `json:"engines"`
+	Fuel               float64 `json:"fuel"`           // lbs at the gate
+	ZeroFuelWeight     float64 `json:"zeroFuelWeight"` // lbs
+}
+
+const (
+	syntheticPushbackTime  = 90 * time.Second
+	syntheticTakeoffTime   = 35 * time.Second
+	syntheticRolloutTime   = 30 * time.Second
+	syntheticOnBlockTime   = 120 * time.Second
+	syntheticTaxiGS        = 15.0  // kts
+	syntheticPushbackGS    = 2.0   // kts
+	syntheticRotateGS      = 150.0 // kts
+	syntheticInitialGS     = 160.0 // kts
+	syntheticApproachGS    = 140.0 // kts
+	syntheticApproachRate  = 700.0 // fpm on final
+	syntheticClimbSpeedFac = 0.8   // climb and descent end at this fraction of cruise speed
+	syntheticFlapDetents   = 4     // flap lever positions besides up
+)
+
+// defaultFlightProfile is a short Lisbon → Porto hop.
+func defaultFlightProfile() FlightProfile {
+	return FlightProfile{
+		Aircraft:           "Synthetic A320",
+		DepartureLat:       38.7742,
+		DepartureLon:       -9.1342,
+		DepartureElevation: 374,
+		ArrivalLat:         41.2481,
+		ArrivalLon:         -8.6814,
+		ArrivalElevation:   228,
+		CruiseAltitude:     24000,
+		CruiseSpeed:        420,
+		ClimbRate:          2500,
+		DescentRate:        2000,
+		BoardingTime:       120,
+		TaxiOutTime:        300,
+		TaxiInTime:         240,
+		Engines:            2,
+		Fuel:               12000,
+		ZeroFuelWeight:     130000,
+	}
+}
+
+// loadFlightProfile reads a profile file over the defaults. An empty path
+// returns the default profile.
+func loadFlightProfile(path string) (FlightProfile, error) {
+	p := defaultFlightProfile()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return p, fmt.Errorf("read profile: %w", err)
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return p, fmt.Errorf("parse profile: %w", err)
+		}
+	}
+	return p, p.validate()
+}
+
+func (p FlightProfile) validate() error {
+	switch {
+	case p.Engines < 1 || p.Engines > len(FlightData{}.Engines):
+		return fmt.Errorf("engines must be between 1 and %d", len(FlightData{}.Engines))
+	case p.ClimbRate <= 0 || p.DescentRate <= 0:
+		return fmt.Errorf("climb and descent rates must be positive")
+	case p.CruiseSpeed <= syntheticInitialGS:
+		return fmt.Errorf("cruise speed must be above %.0f kts", syntheticInitialGS)
+	case p.CruiseAltitude < p.DepartureElevation+phaseInitialAGL || p.CruiseAltitude < p.ArrivalElevation+phaseApproachAGL:
+		return fmt.Errorf("cruise altitude too low for the departure and arrival elevations")
+	}
+	return nil
+}
+
+// syntheticSegment is one leg of the generated flight. Altitude and ground
+// speed change linearly across the segment.
+type syntheticSegment struct {
+	phase      FlightPhase // phase the segment is meant to produce
+	start      time.Duration
+	duration   time.Duration
+	alt0, alt1 float64 // ft MSL
+	gs0, gs1   float64 // kts
+	backward   bool    // pushback moves against the track
+	onGround   bool
+	engines    bool // running for the whole segment
+	startUp    bool // engines are started half way through
+	doorsOpen  bool
+	gearDown   bool
+	flaps      float64
+	n1         float64
+	pitch      float64
+	fuelFlow   float64 // lbs/hr per running engine
+
+	dist0 float64 // nm along the track at segment start
+	fuel0 float64 // lbs at segment start
+}
+
+func (s *syntheticSegment) distance(τ time.Duration) float64 {
+	t, total := τ.Hours(), s.duration.Hours()
+	d := s.gs0 * t
+	if total > 0 {
+		d += (s.gs1 - s.gs0) * t * t / (2 * total)
+	}
+	if s.backward {
+		return -d
+	}
+	return d
+}
+
+// syntheticFlight generates FlightData for any point of a profile's flight.
+// Samples are a pure function of the time since boarding started.
+type syntheticFlight struct {
+	profile  FlightProfile
+	segments []syntheticSegment
+	track    float64 // nm flown along the segments, including ground movement
+	duration time.Duration
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func minutesFor(altChange, rate float64) time.Duration {
+	return time.Duration(math.Abs(altChange) / rate * float64(time.Minute))
+}
+
+func newSyntheticFlight(p FlightProfile) *syntheticFlight {
+	dep, arr := p.DepartureElevation, p.ArrivalElevation
+	climbGS := p.CruiseSpeed * syntheticClimbSpeedFac
+	initialTop := dep + phaseInitialAGL
+	approachTop := arr + phaseApproachAGL
+
+	segments := []syntheticSegment{
+		{phase: PhaseBoarding, duration: seconds(p.BoardingTime), alt0: dep, alt1: dep,
+			onGround: true, gearDown: true, doorsOpen: true},
+		{phase: PhasePushback, duration: syntheticPushbackTime, alt0: dep, alt1: dep,
+			gs0: syntheticPushbackGS, gs1: syntheticPushbackGS, backward: true, onGround: true, gearDown: true, startUp: true, n1: 20, fuelFlow: 600},
+		{phase: PhaseTaxiOut, duration: seconds(p.TaxiOutTime), alt0: dep, alt1: dep,
+			gs0: syntheticTaxiGS, gs1: syntheticTaxiGS, onGround: true, engines: true, gearDown: true, flaps: 0.25, n1: 25, fuelFlow: 600},
+		{phase: PhaseTakeoffRoll, duration: syntheticTakeoffTime, alt0: dep, alt1: dep,
+			gs0: syntheticTaxiGS, gs1: syntheticRotateGS, onGround: true, engines: true, gearDown: true, flaps: 0.25, n1: 95, fuelFlow: 6000},
+		{phase: PhaseInitialClimb, duration: minutesFor(phaseInitialAGL, p.ClimbRate), alt0: dep, alt1: initialTop,
+			gs0: syntheticInitialGS, gs1: syntheticInitialGS, engines: true, gearDown: true, flaps: 0.25, n1: 92, pitch: 12, fuelFlow: 5000},
+		{phase: PhaseClimb, duration: minutesFor(p.CruiseAltitude-initialTop, p.ClimbRate), alt0: initialTop, alt1: p.CruiseAltitude,
+			gs0: syntheticInitialGS, gs1: climbGS, engines: true, n1: 88, pitch: 7, fuelFlow: 4000},
+		{phase: PhaseCruise, alt0: p.CruiseAltitude, alt1: p.CruiseAltitude,
+			gs0: p.CruiseSpeed, gs1: p.CruiseSpeed, engines: true, n1: 80, pitch: 2.5, fuelFlow: 2500},
+		{phase: PhaseDescent, duration: minutesFor(p.CruiseAltitude-approachTop, p.DescentRate), alt0: p.CruiseAltitude, alt1: approachTop,
+			gs0: climbGS, gs1: syntheticApproachGS + 40, engines: true, n1: 35, pitch: -1, fuelFlow: 800},
+		{phase: PhaseApproach, duration: minutesFor(phaseApproachAGL, syntheticApproachRate), alt0: approachTop, alt1: arr,
+			gs0: syntheticApproachGS, gs1: syntheticApproachGS, engines: true, gearDown: true, flaps: 1, n1: 55, pitch: 3, fuelFlow: 1500},
+		{phase: PhaseLandingRollout, duration: syntheticRolloutTime, alt0: arr, alt1: arr,
+			gs0: syntheticApproachGS, gs1: syntheticTaxiGS + 5, onGround: true, engines: true, gearDown: true, flaps: 1, n1: 70, fuelFlow: 1500},
+		{phase: PhaseTaxiIn, duration: seconds(p.TaxiInTime), alt0: arr, alt1: arr,
+			gs0: syntheticTaxiGS, gs1: syntheticTaxiGS, onGround: true, engines: true, gearDown: true, n1: 25, fuelFlow: 600},
+		{phase: PhaseOnBlock, duration: syntheticOnBlockTime, alt0: arr, alt1: arr,
+			onGround: true, gearDown: true, doorsOpen: true},
+	}
+
+	// Cruise fills whatever distance the other segments leave. On very short
+	// profiles there is no cruise and positions are scaled to still end at
+	// the arrival airport.
+	var other float64
+	for i := range segments {
+		other += segments[i].distance(segments[i].duration)
+	}
+	gcd := greatCircleNM(p.DepartureLat, p.DepartureLon, p.ArrivalLat, p.ArrivalLon)
+	if remaining := gcd - other; remaining > 0 {
+		for i := range segments {
+			if segments[i].phase == PhaseCruise {
+				segments[i].duration = time.Duration(remaining / p.CruiseSpeed * float64(time.Hour))
+			}
+		}
+	}
+
+	f := &syntheticFlight{profile: p}
+	var start time.Duration
+	var dist float64
+	fuel := p.Fuel
+	for _, s := range segments {
+		s.start, s.dist0, s.fuel0 = start, dist, fuel
+		start += s.duration
+		dist += s.distance(s.duration)
+		fuel -= s.burn(s.duration, p.Engines)
+		f.segments = append(f.segments, s)
+	}
+	f.duration = start
+	f.track = dist
+	return f
+}
+
+// burn is the fuel used τ into the segment.
+func (s *syntheticSegment) burn(τ time.Duration, engines int) float64 {
+	running := τ
+	if !s.engines {
+		running = 0
+		if s.startUp && τ > s.duration/2 {
+			running = τ - s.duration/2
+		}
+	}
+	return s.fuelFlow * float64(engines) * running.Hours()
+}
+
+func (s *syntheticSegment) enginesRunning(τ time.Duration) bool {
+	return s.engines || (s.startUp && τ >= s.duration/2)
+}
+
+// sampleAt returns the aircraft state at pos since the start of boarding.
+// Past the end of the flight the aircraft stays on block.
+func (f *syntheticFlight) sampleAt(pos time.Duration) *FlightData {
+	p := f.profile
+	i := len(f.segments) - 1
+	for j, s := range f.segments {
+		if pos < s.start+s.duration {
+			i = j
+			break
+		}
+	}
+	s := &f.segments[i]
+
+	τ := max(0, min(pos-s.start, s.duration))
+	frac := 0.0
+	if s.duration > 0 {
+		frac = float64(τ) / float64(s.duration)
+	}
+	gs := s.gs0 + (s.gs1-s.gs0)*frac
+	alt := s.alt0 + (s.alt1-s.alt0)*frac
+	vs := 0.0
+	if s.duration > 0 {
+		vs = (s.alt1 - s.alt0) / s.duration.Minutes()
+	}
+
+	along := 0.0
+	if f.track != 0 {
+		along = (s.dist0 + s.distance(τ)) / f.track
+	}
+	lat, lon := intermediatePoint(p.DepartureLat, p.DepartureLon, p.ArrivalLat, p.ArrivalLon, along)
+	// The course towards the arrival is undefined once there; keep the final course.
+	var heading float64
+	if greatCircleNM(lat, lon, p.ArrivalLat, p.ArrivalLon) > 0.1 {
+		heading = initialBearing(lat, lon, p.ArrivalLat, p.ArrivalLon)
+	} else {
+		heading = math.Mod(initialBearing(p.ArrivalLat, p.ArrivalLon, p.DepartureLat, p.DepartureLon)+180, 360)
+	}
+
+	terrain := p.DepartureElevation
+	if along >= 0.5 {
+		terrain = p.ArrivalElevation
+	}
+	agl := 0.0
+	if !s.onGround {
+		agl = max(0, alt-terrain)
+	}
+
+	tas := gs
+	ias := tas / (1 + 0.02*alt/1000)
+	running := s.enginesRunning(τ)
+	runwayOrAir := !s.onGround || s.phase == PhaseTakeoffRoll || s.phase == PhaseLandingRollout
+
+	fd := &FlightData{
+		Position: PositionData{Latitude: lat, Longitude: lon, Altitude: alt, AltitudeAGL: agl},
+		Attitude: AttitudeData{
+			Pitch:       s.pitch,
+			HeadingTrue: heading,
+			HeadingMag:  heading,
+			VS:          vs,
+			IAS:         ias,
+			TAS:         tas,
+			GS:          gs,
+			GForce:      1,
+		},
+		Sensors:   SensorData{OnGround: s.onGround, SimulationRate: 1, Pushback: s.phase == PhasePushback},
+		Radios:    RadioData{Com1: 118.1, Com2: 121.5, Nav1: 110.3, Nav2: 113.9, XpdrCode: 2000, XpdrState: TransponderStateString(1)},
+		Autopilot: AutopilotData{Altitude: p.CruiseAltitude},
+		Altimeter: 29.92,
+		Lights: LightData{
+			Beacon:  running,
+			Strobe:  runwayOrAir,
+			Landing: runwayOrAir && agl < 10_000,
+		},
+		Controls: FlightControlData{
+			Flaps:        s.flaps,
+			GearDown:     s.gearDown,
+			FlapsDetent:  math.Round(s.flaps * syntheticFlapDetents),
+			ParkingBrake: s.phase == PhaseBoarding || s.phase == PhaseOnBlock,
+		},
+		APU: APUData{SwitchOn: !running, RPMPercent: boolToPercent(!running), GenSwitch: !running, GenActive: !running},
+	}
+	if runwayOrAir {
+		fd.Radios.XpdrState = TransponderStateString(2)
+	}
+	if !s.onGround && agl >= phaseInitialAGL {
+		fd.Autopilot = AutopilotData{Master: true, Heading: heading, Altitude: p.CruiseAltitude, VS: vs, Speed: ias}
+	}
+
+	for e := 0; e < p.Engines; e++ {
+		fd.Engines[e] = EngineData{Exists: true, Running: running, MixturePos: 1, PropPos: 1}
+		if running {
+			fd.Engines[e].N1 = s.n1
+			fd.Engines[e].N2 = min(100, s.n1+10)
+			fd.Engines[e].ThrottlePos = max(0, (s.n1-25)/75)
+			fd.Engines[e].FuelFlow = s.fuelFlow
+		}
+	}
+	if s.doorsOpen {
+		fd.Doors[0].OpenRatio = 1
+	}
+
+	fuel := s.fuel0 - s.burn(τ, p.Engines)
+	fd.Weight = WeightData{
+		TotalWeight: p.ZeroFuelWeight + fuel,
+		FuelWeight:  fuel,
+		FuelTanks:   [4]float64{fuel / 2, 0, fuel / 2, 0}, // wing tanks only
+	}
+	// Calm standard atmosphere.
+	fd.Environment = EnvironmentData{
+		OAT:        15 - 1.98*alt/1000,
+		QNH:        1013.25,
+		Visibility: 10000,
+	}
+	fd.AircraftName = p.Aircraft
+	return fd
+}
+
+func boolToPercent(b bool) float64 {
+	if b {
+		return 100
+	}
+	return 0
+}
+
+// SyntheticAdapter generates a plausible gate-to-gate flight from a
+// FlightProfile, for demos and for testing phase-dependent behaviour without
+// a simulator. Playback can be paused, moved and sped up like a replay.
+type SyntheticAdapter struct {
+	profilePath string // empty uses defaultFlightProfile
+	speed       float64
+
+	mu           sync.Mutex
+	flight       *syntheticFlight
+	clock        *playbackClock
+	startTime    time.Time // sim zulu time when boarding starts
+	lastReceived time.Time
+}
+
+func NewSyntheticAdapter(profilePath string, speed float64) *SyntheticAdapter {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &SyntheticAdapter{profilePath: profilePath, speed: speed}
+}
+
+func (s *SyntheticAdapter) Name() string {
+	return "Synthetic"
+}
+
+func (s *SyntheticAdapter) Connect() error {
+	profile, err := loadFlightProfile(s.profilePath)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flight = newSyntheticFlight(profile)
+	s.clock = newPlaybackClock(s.flight.duration, s.speed)
+	s.startTime = time.Now().UTC()
+
+	slog.Info("synthetic flight generated", "profile", s.sourceName(), "duration", s.flight.duration)
+	return nil
+}
+
+func (s *SyntheticAdapter) Disconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flight = nil
+	s.clock = nil
+	return nil
+}
+
+func (s *SyntheticAdapter) GetFlightData() (*FlightData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clock == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	pos := s.clock.position()
+	fd := s.flight.sampleAt(pos)
+	zulu := s.startTime.Add(pos)
+	fd.SimTime = SimTimeData{
+		ZuluTime:  float64(zulu.Hour()*3600 + zulu.Minute()*60 + zulu.Second()),
+		ZuluDay:   float64(zulu.Day()),
+		ZuluMonth: float64(zulu.Month()),
+		ZuluYear:  float64(zulu.Year()),
+	}
+	fd.SimTime.LocalTime = fd.SimTime.ZuluTime
+	fd.Invalid = syntheticUnsupported
+
+	s.lastReceived = time.Now()
+	return fd, nil
+}
+
+// syntheticUnsupported are the fields a FlightProfile has nothing for.
+var syntheticUnsupported = []string{"aircraftIcao", "tailNumber"}
+
+func (s *SyntheticAdapter) Capabilities() ConnectorCapabilities {
+	return ConnectorCapabilities{Fields: fieldsExcept(syntheticUnsupported)}
+}
+
+func (s *SyntheticAdapter) LastReceived() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastReceived
+}
+
+func (s *SyntheticAdapter) Pause()  { s.withClock(func(c *playbackClock) { c.setPaused(true) }) }
+func (s *SyntheticAdapter) Resume() { s.withClock(func(c *playbackClock) { c.setPaused(false) }) }
+
+// Seek moves the flight to pos from the start of boarding.
+func (s *SyntheticAdapter) Seek(pos time.Duration) {
+	s.withClock(func(c *playbackClock) { c.seek(pos) })
+}
+
+// SetSpeed changes the playback speed multiplier (1 = real time).
+func (s *SyntheticAdapter) SetSpeed(speed float64) {
+	s.withClock(func(c *playbackClock) { c.setSpeed(speed) })
+}
+
+func (s *SyntheticAdapter) Status() ReplayStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := ReplayStatus{Source: s.sourceName(), Speed: s.speed}
+	if s.clock != nil {
+		status.Position = s.clock.position().Seconds()
+		status.Duration = s.clock.duration.Seconds()
+		status.Speed = s.clock.getSpeed()
+		status.Paused = s.clock.isPaused()
+	}
+	return status
+}
+
+func (s *SyntheticAdapter) withClock(fn func(c *playbackClock)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clock != nil {
+		fn(s.clock)
+	}
+}
+
+func (s *SyntheticAdapter) sourceName() string {
+	if s.profilePath == "" {
+		return "default profile"
+	}
+	return s.profilePath
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flySynthetic samples a whole synthetic flight once per step.
+func flySynthetic(flight *syntheticFlight, step time.Duration, fn func(pos time.Duration, fd *FlightData)) {
+	for pos := time.Duration(0); pos <= flight.duration+step; pos += step {
+		fn(pos, flight.sampleAt(pos))
+	}
+}
+
+func TestSyntheticFlightPhaseSequence(t *testing.T) {
+	flight := newSyntheticFlight(defaultFlightProfile())
+	start := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	var d PhaseDetector
+	var phases []FlightPhase
+	flySynthetic(flight, time.Second, func(pos time.Duration, fd *FlightData) {
+		if phase, changed := d.Update(fd, start.Add(pos)); changed {
+			phases = append(phases, phase)
+		}
+	})
+
+	assert.Equal(t, []FlightPhase{
+		PhaseBoarding, PhasePushback, PhaseTaxiOut, PhaseTakeoffRoll,
+		PhaseInitialClimb, PhaseClimb, PhaseCruise, PhaseDescent,
+		PhaseApproach, PhaseLandingRollout, PhaseTaxiIn, PhaseOnBlock,
+	}, phases)
+}
+
+func TestSyntheticFlightGateToGate(t *testing.T) {
+	p := defaultFlightProfile()
+	flight := newSyntheticFlight(p)
+
+	first := flight.sampleAt(0)
+	assert.InDelta(t, 0, greatCircleNM(first.Position.Latitude, first.Position.Longitude, p.DepartureLat, p.DepartureLon), 0.01)
+	assert.True(t, first.Sensors.OnGround)
+	assert.False(t, first.Engines[0].Running)
+	assert.Equal(t, 1.0, first.Doors[0].OpenRatio)
+
+	last := flight.sampleAt(flight.duration + time.Hour)
+	assert.InDelta(t, 0, greatCircleNM(last.Position.Latitude, last.Position.Longitude, p.ArrivalLat, p.ArrivalLon), 0.01)
+	assert.True(t, last.Sensors.OnGround)
+	assert.False(t, last.Engines[0].Running)
+	assert.False(t, last.Engines[2].Exists, "only the profile's engines exist")
+
+	var maxAlt float64
+	prevFuel := first.Weight.FuelWeight
+	flySynthetic(flight, 10*time.Second, func(_ time.Duration, fd *FlightData) {
+		maxAlt = max(maxAlt, fd.Position.Altitude)
+		assert.LessOrEqual(t, fd.Weight.FuelWeight, prevFuel, "fuel never increases")
+		prevFuel = fd.Weight.FuelWeight
+	})
+	assert.Equal(t, p.CruiseAltitude, maxAlt)
+	assert.Less(t, last.Weight.FuelWeight, p.Fuel)
+	assert.Positive(t, last.Weight.FuelWeight)
+}
+
+func TestSyntheticFlightShortProfileHasNoCruise(t *testing.T) {
+	p := defaultFlightProfile()
+	p.ArrivalLat, p.ArrivalLon = 38.9, -9.1
+	flight := newSyntheticFlight(p)
+
+	for _, s := range flight.segments {
+		if s.phase == PhaseCruise {
+			assert.Zero(t, s.duration)
+		}
+	}
+	last := flight.sampleAt(flight.duration)
+	assert.InDelta(t, 0, greatCircleNM(last.Position.Latitude, last.Position.Longitude, p.ArrivalLat, p.ArrivalLon), 0.01)
+}
+
+func TestLoadFlightProfile(t *testing.T) {
+	p, err := loadFlightProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultFlightProfile(), p)
+
+	path := filepath.Join(t.TempDir(), "profile.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"cruiseAltitude": 35000, "engines": 4}`), 0o644))
+	p, err = loadFlightProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 35000.0, p.CruiseAltitude)
+	assert.Equal(t, 4, p.Engines)
+	assert.Equal(t, defaultFlightProfile().ClimbRate, p.ClimbRate, "missing fields keep defaults")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"cruiseAltitude": 2000}`), 0o644))
+	_, err = loadFlightProfile(path)
+	assert.Error(t, err)
+
+	_, err = loadFlightProfile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestSyntheticAdapterPlayback(t *testing.T) {
+	s := NewSyntheticAdapter("", 1)
+	_, err := s.GetFlightData()
+	require.Error(t, err, "not connected")
+
+	require.NoError(t, s.Connect())
+	fc := newFakeClock()
+	fc.attach(s.clock)
+
+	fd, err := s.GetFlightData()
+	require.NoError(t, err)
+	assert.True(t, fd.Sensors.OnGround)
+	assert.False(t, s.LastReceived().IsZero())
+
+	s.SetSpeed(60)
+	fc.advance(30 * time.Second)
+	status := s.Status()
+	assert.Equal(t, 1800.0, status.Position)
+	assert.Equal(t, "default profile", status.Source)
+
+	fd, err = s.GetFlightData()
+	require.NoError(t, err)
+	assert.False(t, fd.Sensors.OnGround, "airborne half an hour in")
+	assert.Equal(t, "Synthetic A320", fd.AircraftName)
+	assert.NotZero(t, fd.SimTime.ZuluYear)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const statsWindow = 10 * time.Second // span of the rate and latency statistics
+
+// telemetrySample is one FlightData reading and the time it was taken. The
+// data is shared between subscribers and must not be modified.
+type telemetrySample struct {
+	data *FlightData
+	at   time.Time
+}
+
+// telemetryBroadcaster fans simulator samples out to subscribers. Connectors
+// that push (see SamplePusher) publish as samples arrive; the others are
+// polled by the data stream. The zero value is ready to use.
+type telemetryBroadcaster struct {
+	mu        sync.Mutex
+	subs      map[*telemetrySubscription]struct{}
+	latest    telemetrySample
+	samples   int64
+	arrivals  []time.Time // within statsWindow
+	latencies []sampleLatency
+}
+
+type sampleLatency struct {
+	at time.Time
+	d  time.Duration
+}
+
+// ConnectorStats describes how samples arrive from the connected simulator.
+type ConnectorStats struct {
+	Adapter      string  `json:"adapter"`
+	Push         bool    `json:"push"`         // the connector pushes samples rather than being polled
+	Samples      int64   `json:"samples"`      // since connecting
+	RateHz       float64 `json:"rateHz"`       // over the last statsWindow
+	MaxGapMs     float64 `json:"maxGapMs"`     // longest wait for a sample in the window, including the current one
+	LatencyMs    float64 `json:"latencyMs"`    // mean time from a sample's arrival until the data stream handled it
+	MaxLatencyMs float64 `json:"maxLatencyMs"` // in the window
+
+	Rejected   int64            `json:"rejected"`   // samples dropped by the sanity filter since connecting
+	RejectedBy map[string]int64 `json:"rejectedBy"` // by reason, see telemetryFilter
+}
+
+// telemetrySubscription delivers samples no closer together than its
+// interval. When the consumer falls behind, the oldest buffered sample is
+// dropped so it catches up with the newest instead of stalling the source.
+type telemetrySubscription struct {
+	C <-chan telemetrySample
+
+	ch       chan telemetrySample
+	b        *telemetryBroadcaster
+	interval time.Duration // guarded by b.mu
+	last     time.Time     // time of the last delivered sample, guarded by b.mu
+}
+
+// subscribe registers a consumer with room for buffer samples (at least one)
+// that wants at most one sample per interval; 0 delivers every sample.
+func (b *telemetryBroadcaster) subscribe(buffer int, interval time.Duration) *telemetrySubscription {
+	ch := make(chan telemetrySample, max(buffer, 1))
+	s := &telemetrySubscription{C: ch, ch: ch, b: b, interval: interval}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[*telemetrySubscription]struct{})
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// publish hands a sample taken now to every subscriber. It never blocks, so
+// connectors may call it from their receive loops.
+func (b *telemetryBroadcaster) publish(data *FlightData) {
+	b.publishAt(data, time.Now())
+}
+
+func (b *telemetryBroadcaster) publishAt(data *FlightData, at time.Time) {
+	sample := telemetrySample{data: data, at: at}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest = sample
+	b.samples++
+	b.arrivals = append(trimBefore(b.arrivals, at.Add(-statsWindow), func(t time.Time) time.Time { return t }), at)
+	for s := range b.subs {
+		s.offerLocked(sample)
+	}
+}
+
+// latestSample returns the most recent sample, if any was published since
+// the last reset.
+func (b *telemetryBroadcaster) latestSample() (telemetrySample, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.latest, b.latest.data != nil
+}
+
+// reset forgets the latest sample and the statistics, e.g. when the
+// connector changes.
+func (b *telemetryBroadcaster) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest = telemetrySample{}
+	b.samples = 0
+	b.arrivals = nil
+	b.latencies = nil
+}
+
+// recordLatency notes how long a sample took to reach a consumer.
+func (b *telemetryBroadcaster) recordLatency(at time.Time, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latencies = append(trimBefore(b.latencies, at.Add(-statsWindow), func(l sampleLatency) time.Time { return l.at }), sampleLatency{at, d})
+}
+
+// stats summarises the samples of the last statsWindow before now.
+func (b *telemetryBroadcaster) stats(now time.Time) ConnectorStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st := ConnectorStats{Samples: b.samples}
+	arrivals := trimBefore(b.arrivals, now.Add(-statsWindow), func(t time.Time) time.Time { return t })
+	if n := len(arrivals); n > 0 {
+		if span := arrivals[n-1].Sub(arrivals[0]); n > 1 && span > 0 {
+			st.RateHz = float64(n-1) / span.Seconds()
+		}
+		gap := now.Sub(arrivals[n-1])
+		for i := 1; i < n; i++ {
+			gap = max(gap, arrivals[i].Sub(arrivals[i-1]))
+		}
+		st.MaxGapMs = milliseconds(gap)
+	}
+
+	latencies := trimBefore(b.latencies, now.Add(-statsWindow), func(l sampleLatency) time.Time { return l.at })
+	if len(latencies) > 0 {
+		var sum, worst time.Duration
+		for _, l := range latencies {
+			sum += l.d
+			worst = max(worst, l.d)
+		}
+		st.LatencyMs = milliseconds(sum / time.Duration(len(latencies)))
+		st.MaxLatencyMs = milliseconds(worst)
+	}
+	return st
+}
+
+// trimBefore drops the leading entries older than cutoff.
+func trimBefore[T any](entries []T, cutoff time.Time, at func(T) time.Time) []T {
+	i := 0
+	for i < len(entries) && at(entries[i]).Before(cutoff) {
+		i++
+	}
+	return entries[i:]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// offerLocked queues sample unless it arrives too soon after the last one.
+// A tenth of the interval is allowed as slack so a source ticking at exactly
+// the subscriber's rate is not halved by timer jitter. Must be called with
+// b.mu held, which also makes it the only sender.
+func (s *telemetrySubscription) offerLocked(sample telemetrySample) {
+	if !s.last.IsZero() && sample.at.Sub(s.last) < s.interval-s.interval/10 {
+		return
+	}
+	s.last = sample.at
+	for {
+		select {
+		case s.ch <- sample:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
+
+// setInterval changes the subscriber's rate, e.g. when the position report
+// cadence changes.
+func (s *telemetrySubscription) setInterval(interval time.Duration) {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.interval = interval
+}
+
+// close unsubscribes and closes C.
+func (s *telemetrySubscription) close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, ok := s.b.subs[s]; ok {
+		delete(s.b.subs, s)
+		close(s.ch)
+	}
+}
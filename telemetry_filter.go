@@ -0,0 +1,213 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSpikeWindow = 3.0    // s, a jump must persist this long to be believed
+	maxClimbRate       = 30000  // fpm; faster altitude changes are spikes
+	maxGroundSpeed     = 2000.0 // kts; faster position changes are spikes
+	spikeAltitudeSlack = 100.0  // ft allowed on top of maxClimbRate, for jitter
+	spikeDistanceSlack = 0.5    // NM allowed on top of maxGroundSpeed
+
+	rejectedEventInterval = time.Second // shortest time between telemetry-rejected events
+)
+
+// Reasons a sample is rejected, as counted in ConnectorStats.RejectedBy.
+const (
+	rejectNaN        = "nan"
+	rejectRange      = "range"
+	rejectNullIsland = "nullIsland"
+	rejectSpike      = "spike"
+)
+
+// valueRange bounds a numeric FlightData field.
+type valueRange struct {
+	min, max float64
+	reject   bool // an out-of-range value rejects the sample instead of being clamped
+}
+
+// telemetryRanges are the plausible values of the fields that simulators get
+// wrong during loading screens and aircraft swaps. Without a position the
+// sample is useless, so those reject it; the rest are clamped.
+var telemetryRanges = map[string]valueRange{
+	"position.latitude":    {-90, 90, true},
+	"position.longitude":   {-180, 180, true},
+	"position.altitude":    {-2000, 100000, true},
+	"position.altitudeAGL": {-100, 100000, false},
+
+	"attitude.pitch":       {-90, 90, false},
+	"attitude.roll":        {-180, 180, false},
+	"attitude.headingTrue": {0, 360, false},
+	"attitude.headingMag":  {0, 360, false},
+	"attitude.vs":          {-maxClimbRate, maxClimbRate, false},
+	"attitude.ias":         {0, maxGroundSpeed, false},
+	"attitude.tas":         {0, maxGroundSpeed, false},
+	"attitude.gs":          {0, maxGroundSpeed, false},
+	"attitude.gForce":      {-10, 10, false},
+
+	"controls.flaps":    {0, 100, false},
+	"controls.spoilers": {0, 100, false},
+	"door1.openRatio":   {0, 1, false},
+	"door2.openRatio":   {0, 1, false},
+	"door3.openRatio":   {0, 1, false},
+	"door4.openRatio":   {0, 1, false},
+	"door5.openRatio":   {0, 1, false},
+
+	"environment.windDirection": {0, 360, false},
+}
+
+// sanitizeFlightData returns a copy of d with NaN and infinite values zeroed
+// and marked invalid, and out-of-range values clamped. It returns a rejection
+// reason instead when the sample has no usable position.
+func sanitizeFlightData(d *FlightData) (*FlightData, string) {
+	clean := *d
+	clean.Invalid = slices.Clone(d.Invalid)
+
+	for name, field := range flightDataFloats {
+		v := field(&clean)
+		r, bounded := telemetryRanges[name]
+		switch {
+		case math.IsNaN(*v) || math.IsInf(*v, 0):
+			if r.reject {
+				return nil, rejectNaN
+			}
+			*v = 0
+			if !slices.Contains(clean.Invalid, name) {
+				clean.Invalid = append(clean.Invalid, name)
+			}
+		case bounded && (*v < r.min || *v > r.max):
+			if r.reject {
+				return nil, rejectRange
+			}
+			*v = min(max(*v, r.min), r.max)
+		}
+	}
+	if clean.Position.Latitude == 0 && clean.Position.Longitude == 0 && clean.Valid("position.latitude") {
+		return nil, rejectNullIsland
+	}
+	slices.Sort(clean.Invalid)
+	return &clean, ""
+}
+
+// telemetryFilter sits between the connector and the telemetry broadcaster.
+// Besides sanitizing every sample it drops single-frame jumps in position and
+// altitude. A jump that persists for the spike window, e.g. after the pilot
+// relocates the aircraft, is accepted as the new position.
+type telemetryFilter struct {
+	mu           sync.Mutex
+	window       time.Duration
+	last         *FlightData // last accepted sample
+	lastAt       time.Time
+	pending      *FlightData // latest sample of a jump not yet believed
+	pendingAt    time.Time
+	pendingSince time.Time
+	rejected     map[string]int64
+	notifiedAt   time.Time // of the last telemetry-rejected event
+}
+
+// reset forgets the previous samples and the counters and sets the spike
+// window in seconds; 0 uses the default.
+func (t *telemetryFilter) reset(window float64) {
+	if window <= 0 {
+		window = defaultSpikeWindow
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.window = time.Duration(window * float64(time.Second))
+	t.last, t.pending = nil, nil
+	t.rejected = nil
+	t.notifiedAt = time.Time{}
+}
+
+// check returns the sanitized sample taken at at, or the reason it was
+// rejected.
+func (t *telemetryFilter) check(d *FlightData, at time.Time) (*FlightData, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clean, reason := sanitizeFlightData(d)
+	if reason == "" {
+		reason = t.spikeLocked(clean, at)
+	}
+	if reason != "" {
+		if t.rejected == nil {
+			t.rejected = make(map[string]int64)
+		}
+		t.rejected[reason]++
+		return nil, reason
+	}
+	return clean, ""
+}
+
+// spikeLocked compares d with the last accepted sample. Must be called with
+// t.mu held.
+func (t *telemetryFilter) spikeLocked(d *FlightData, at time.Time) string {
+	window := t.window
+	if window <= 0 {
+		window = time.Duration(defaultSpikeWindow * float64(time.Second))
+	}
+
+	if t.last == nil || at.Sub(t.lastAt) > window || plausibleMove(t.last, d, at.Sub(t.lastAt)) {
+		t.accept(d, at)
+		return ""
+	}
+
+	// A jump. Believe it once the samples after it agree for a whole window.
+	if t.pending == nil || !plausibleMove(t.pending, d, at.Sub(t.pendingAt)) {
+		t.pendingSince = at
+	}
+	t.pending, t.pendingAt = d, at
+	if at.Sub(t.pendingSince) >= window {
+		t.accept(d, at)
+		return ""
+	}
+	return rejectSpike
+}
+
+func (t *telemetryFilter) accept(d *FlightData, at time.Time) {
+	t.last, t.lastAt = d, at
+	t.pending = nil
+}
+
+// plausibleMove reports whether an aircraft can get from a to b in dt of
+// real time, at the simulation rate of b.
+func plausibleMove(a, b *FlightData, dt time.Duration) bool {
+	seconds := max(dt.Seconds(), 0) * max(b.Sensors.SimulationRate, 1)
+	climb := math.Abs(b.Position.Altitude - a.Position.Altitude)
+	if climb > maxClimbRate/60*seconds+spikeAltitudeSlack {
+		return false
+	}
+	distance := greatCircleNM(a.Position.Latitude, a.Position.Longitude, b.Position.Latitude, b.Position.Longitude)
+	return distance <= maxGroundSpeed/3600*seconds+spikeDistanceSlack
+}
+
+// notifyDue reports whether the UI should be told about a rejection at now.
+// A loading screen can reject every sample, so the UI hears about them at
+// most once per rejectedEventInterval; GetConnectorStats has the exact count.
+func (t *telemetryFilter) notifyDue(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.notifiedAt) < rejectedEventInterval {
+		return false
+	}
+	t.notifiedAt = now
+	return true
+}
+
+// rejections returns the number of rejected samples by reason.
+func (t *telemetryFilter) rejections() (int64, map[string]int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var total int64
+	by := make(map[string]int64, len(t.rejected))
+	for reason, n := range t.rejected {
+		by[reason] = n
+		total += n
+	}
+	return total, by
+}
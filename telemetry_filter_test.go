@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryFilterGlitchyRecording(t *testing.T) {
+	// A climb-out with a loading-screen NaN, a 0/0 position, a 90,000 ft spike,
+	// then the pilot relocating the aircraft to another airport.
+	frames, err := loadReplayCSV("testdata/glitchy_climb.csv")
+	require.NoError(t, err)
+
+	var f telemetryFilter
+	f.reset(3)
+	start := time.Now()
+	var accepted []*FlightData
+	var reasons []string
+	for _, frame := range frames {
+		clean, reason := f.check(&frame.data, start.Add(frame.offset))
+		reasons = append(reasons, reason)
+		if clean != nil {
+			accepted = append(accepted, clean)
+			_, err := json.Marshal(clean)
+			assert.NoError(t, err, "accepted samples are valid JSON")
+		}
+	}
+
+	assert.Equal(t, []string{
+		"", "", rejectNaN, rejectNullIsland, rejectSpike, "", "", "",
+		rejectSpike, rejectSpike, rejectSpike, "", "",
+	}, reasons)
+	for _, d := range accepted[:5] {
+		assert.Less(t, d.Position.Altitude, 3000.0)
+	}
+
+	assert.Zero(t, accepted[3].Attitude.IAS)
+	assert.False(t, accepted[3].Valid("attitude.ias"), "a NaN field is marked invalid, not dropped with the sample")
+	assert.True(t, accepted[4].Valid("attitude.ias"))
+	assert.Equal(t, 360.0, accepted[4].Attitude.HeadingTrue, "out-of-range values are clamped")
+	assert.Equal(t, 38.7813, accepted[5].Position.Latitude, "a jump that persists is believed")
+
+	total, by := f.rejections()
+	assert.Equal(t, int64(6), total)
+	assert.Equal(t, map[string]int64{rejectNaN: 1, rejectNullIsland: 1, rejectSpike: 4}, by)
+}
+
+func TestTelemetryFilterSpikeWindow(t *testing.T) {
+	var f telemetryFilter
+	f.reset(1)
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	fd := sampleFlightData()
+	_, reason := f.check(fd, at(0))
+	require.Empty(t, reason)
+
+	// 20 Hz with a single-frame spike.
+	spike := *fd
+	spike.Position.Altitude += 50000
+	_, reason = f.check(&spike, at(50))
+	assert.Equal(t, rejectSpike, reason)
+	_, reason = f.check(fd, at(100))
+	assert.Empty(t, reason)
+
+	// At 16x simulation rate the aircraft covers more ground per second.
+	fast := *fd
+	fast.Sensors.SimulationRate = 16
+	fast.Position.Latitude += 0.1
+	_, reason = f.check(&fast, at(1100))
+	assert.Empty(t, reason)
+
+	// With a one-second window a relocation is believed after a second.
+	moved := fast
+	moved.Sensors.SimulationRate = 1
+	moved.Position.Latitude += 10
+	for ms := 1200; ms < 2200; ms += 100 {
+		_, reason = f.check(&moved, at(ms))
+		assert.Equal(t, rejectSpike, reason, "at %d ms", ms)
+	}
+	_, reason = f.check(&moved, at(2200))
+	assert.Empty(t, reason)
+}
+
+func TestTelemetryFilterNotifiesOncePerInterval(t *testing.T) {
+	var f telemetryFilter
+	now := time.Now()
+	notified := 0
+	for i := range 100 { // two seconds of rejections at 50 Hz
+		if f.notifyDue(now.Add(time.Duration(i) * 20 * time.Millisecond)) {
+			notified++
+		}
+	}
+	assert.Equal(t, 2, notified)
+
+	f.reset(0)
+	assert.True(t, f.notifyDue(now), "a new connection starts over")
+}
+
+func TestSanitizeFlightDataLeavesInputAlone(t *testing.T) {
+	fd := sampleFlightData()
+	fd.Engines[0].N1 = math.Inf(1)
+	fd.Invalid = []string{"weight.totalWeight"}
+
+	clean, reason := sanitizeFlightData(fd)
+	require.Empty(t, reason)
+	assert.Equal(t, []string{"engine1.n1", "weight.totalWeight"}, clean.Invalid)
+	assert.True(t, math.IsInf(fd.Engines[0].N1, 1), "the connector's sample is not modified")
+	assert.Equal(t, []string{"weight.totalWeight"}, fd.Invalid)
+
+	fd.Position.Altitude = math.NaN()
+	_, reason = sanitizeFlightData(fd)
+	assert.Equal(t, rejectNaN, reason)
+}
+
+func TestDataStreamFiltersSamples(t *testing.T) {
+	fd := sampleFlightData()
+	mock := &pushMockConnector{MockSimConnector: MockSimConnector{data: fd, name: "PushSim"}}
+	fds := &FlightDataService{connector: mock}
+	fds.attachTelemetry(mock)
+
+	sub := fds.subscribeTelemetry(8, 0)
+	defer sub.close()
+
+	mock.push(*fd)
+	glitch := *fd
+	glitch.Position.Latitude = math.NaN()
+	mock.push(glitch)
+	glitch.Position.Latitude, glitch.Position.Longitude = 0, 0
+	mock.push(glitch)
+
+	assert.Len(t, sub.C, 1, "only the good sample reaches consumers")
+	stats := fds.GetConnectorStats()
+	assert.Equal(t, int64(2), stats.Rejected)
+	assert.Equal(t, map[string]int64{rejectNaN: 1, rejectNullIsland: 1}, stats.RejectedBy)
+
+	mock.data = &glitch
+	fds.telemetry.reset()
+	_, err := fds.GetFlightDataNow()
+	assert.ErrorContains(t, err, "implausible sample")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestStream runs the service's data stream for the rest of the test.
+func startTestStream(t *testing.T, fds *FlightDataService) {
+	t.Helper()
+	fds.mu.Lock()
+	fds.startDataStreamLocked()
+	fds.mu.Unlock()
+	t.Cleanup(func() {
+		fds.mu.Lock()
+		fds.stopDataStreamLocked()
+		fds.mu.Unlock()
+	})
+}
+
+// pushMockConnector pushes samples through its sink and counts polls.
+type pushMockConnector struct {
+	MockSimConnector
+	mu    sync.Mutex
+	sink  func(*FlightData)
+	polls int
+}
+
+func (p *pushMockConnector) SetSampleSink(sink func(*FlightData)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sink = sink
+}
+
+func (p *pushMockConnector) GetFlightData() (*FlightData, error) {
+	p.mu.Lock()
+	p.polls++
+	p.mu.Unlock()
+	return p.MockSimConnector.GetFlightData()
+}
+
+func (p *pushMockConnector) push(fd FlightData) {
+	p.mu.Lock()
+	sink := p.sink
+	p.mu.Unlock()
+	sink(&fd)
+}
+
+func (p *pushMockConnector) pollCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.polls
+}
+
+func TestTelemetryDropsOldest(t *testing.T) {
+	var b telemetryBroadcaster
+	sub := b.subscribe(2, 0)
+	defer sub.close()
+
+	start := time.Now()
+	for i := range 5 {
+		fd := &FlightData{}
+		fd.Position.Altitude = float64(i)
+		b.publishAt(fd, start.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	assert.Equal(t, 3.0, (<-sub.C).data.Position.Altitude, "a slow consumer gets the newest samples")
+	assert.Equal(t, 4.0, (<-sub.C).data.Position.Altitude)
+	assert.Empty(t, sub.C)
+
+	latest, ok := b.latestSample()
+	require.True(t, ok)
+	assert.Equal(t, 4.0, latest.data.Position.Altitude)
+	b.reset()
+	_, ok = b.latestSample()
+	assert.False(t, ok)
+}
+
+func TestTelemetrySubscriptionInterval(t *testing.T) {
+	var b telemetryBroadcaster
+	sub := b.subscribe(100, time.Second)
+	defer sub.close()
+
+	// A 1 Hz source with timer jitter, and samples in between.
+	start := time.Now()
+	for _, ms := range []int{0, 500, 998, 2001, 2500, 2999} {
+		b.publishAt(&FlightData{}, start.Add(time.Duration(ms)*time.Millisecond))
+	}
+	assert.Len(t, sub.C, 4, "one sample per second, despite jitter")
+
+	sub.setInterval(0)
+	b.publishAt(&FlightData{}, start.Add(3*time.Second))
+	b.publishAt(&FlightData{}, start.Add(3*time.Second))
+	assert.Len(t, sub.C, 6)
+
+	sub.close()
+	b.publish(&FlightData{})
+	n := 0
+	for range sub.C {
+		n++
+	}
+	assert.Equal(t, 6, n, "nothing is delivered after close")
+}
+
+func TestTelemetryStats(t *testing.T) {
+	var b telemetryBroadcaster
+	start := time.Now()
+	for i := range 21 { // 20 Hz for a second
+		b.publishAt(&FlightData{}, start.Add(time.Duration(i)*50*time.Millisecond))
+	}
+	b.recordLatency(start.Add(time.Second), 2*time.Millisecond)
+	b.recordLatency(start.Add(time.Second), 4*time.Millisecond)
+
+	st := b.stats(start.Add(1200 * time.Millisecond))
+	assert.Equal(t, int64(21), st.Samples)
+	assert.InDelta(t, 20, st.RateHz, 0.01)
+	assert.InDelta(t, 200, st.MaxGapMs, 0.01, "the wait since the last sample counts")
+	assert.InDelta(t, 3, st.LatencyMs, 0.01)
+	assert.InDelta(t, 4, st.MaxLatencyMs, 0.01)
+
+	st = b.stats(start.Add(time.Minute))
+	assert.Equal(t, int64(21), st.Samples)
+	assert.Zero(t, st.RateHz, "nothing in the window")
+
+	b.reset()
+	assert.Equal(t, ConnectorStats{}, b.stats(start))
+}
+
+func TestDataStreamUsesPushedSamples(t *testing.T) {
+	fd := sampleFlightData()
+	mock := &pushMockConnector{MockSimConnector: MockSimConnector{data: fd, name: "PushSim"}}
+	fds := &FlightDataService{connector: mock}
+	fds.attachTelemetry(mock)
+	startTestStream(t, fds)
+
+	sub := fds.subscribeTelemetry(8, 0)
+	defer sub.close()
+
+	pushed := *fd
+	pushed.Position.Altitude = 1234
+	mock.push(pushed)
+
+	sample := <-sub.C
+	assert.Equal(t, 1234.0, sample.data.Position.Altitude)
+
+	now, err := fds.GetFlightDataNow()
+	require.NoError(t, err)
+	assert.Equal(t, 1234.0, now.Position.Altitude, "the latest sample is served without a read")
+
+	go func() {
+		for range 30 {
+			mock.push(pushed)
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+	require.Eventually(t, fds.IsConnected, 3*time.Second, 20*time.Millisecond)
+	assert.Zero(t, mock.pollCount(), "a connector that pushes is not polled")
+
+	stats := fds.GetConnectorStats()
+	assert.Equal(t, "PushSim", stats.Adapter)
+	assert.True(t, stats.Push)
+	assert.Positive(t, stats.Samples)
+}
@@ -0,0 +1,17 @@
+-- flight_data of the first releases, a column per field.
+CREATE TABLE flight_data (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	latitude REAL,
+	longitude REAL,
+	altitude REAL,
+	heading REAL,
+	ias REAL,
+	gs REAL,
+	vs REAL,
+	on_ground INTEGER
+);
+INSERT INTO flight_data (timestamp, latitude, longitude, altitude, heading, ias, gs, vs, on_ground)
+	VALUES ('2024-03-01 10:00:00', 51.47, -0.4543, 83, 270, 0, 0, 0, 1);
+INSERT INTO flight_data (timestamp, latitude, longitude, altitude, heading, ias, gs, vs, on_ground)
+	VALUES ('2024-03-01 10:00:01', 51.47, -0.4544, 83, 270, 0, 2, 0, 1);
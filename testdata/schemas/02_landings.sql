@@ -0,0 +1,14 @@
+-- Schema of databases created before schema_version existed, up to landings.
+CREATE TABLE flight_data (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
+INSERT INTO flight_data (timestamp, data) VALUES ('2025-06-15 12:00:00', '{"position": {"latitude": 51.47, "longitude": -0.4543, "altitude": 83}}');
+INSERT INTO flight_data (timestamp, data) VALUES ('2025-06-15 12:00:01', '{"position": {"latitude": 51.48, "longitude": -0.4543, "altitude": 120}}');
+CREATE TABLE landings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
+INSERT INTO landings (data) VALUES ('{"touchdownRate": -142}');
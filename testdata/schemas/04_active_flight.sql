@@ -0,0 +1,29 @@
+-- Schema of databases created before schema_version existed, up to active_flight.
+CREATE TABLE flight_data (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
+INSERT INTO flight_data (timestamp, data) VALUES ('2025-06-15 12:00:00', '{"position": {"latitude": 51.47, "longitude": -0.4543, "altitude": 83}}');
+INSERT INTO flight_data (timestamp, data) VALUES ('2025-06-15 12:00:01', '{"position": {"latitude": 51.48, "longitude": -0.4543, "altitude": 120}}');
+CREATE TABLE landings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
+INSERT INTO landings (data) VALUES ('{"touchdownRate": -142}');
+CREATE TABLE position_outbox (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	flight TEXT NOT NULL,
+	queued_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	sent_at DATETIME,
+	data TEXT NOT NULL
+);
+CREATE INDEX position_outbox_unsent ON position_outbox (flight) WHERE sent_at IS NULL;
+INSERT INTO position_outbox (flight, data) VALUES ('BAW123-1', '{"callsign": "BAW123"}');
+CREATE TABLE active_flight (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	data TEXT NOT NULL
+);
+INSERT INTO active_flight (id, data) VALUES (1, '{"flightId": "BAW123-1", "callsign": "BAW123"}');
@@ -2,139 +2,66 @@ package main
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"net"
+	"strconv"
 	"sync"
+	"syscall"
 	"time"
 )
 
+const (
+	defaultXPlaneHost = "127.0.0.1"
+	defaultXPlanePort = 49000
+)
+
+// xplaneEndpoint is where X-Plane listens for RREF requests and the local
+// port its replies are sent back to.
+type xplaneEndpoint struct {
+	host      string
+	port      int
+	localPort int // 0 picks a free port
+}
+
+// xplaneEndpointFromSettings applies the defaults to unset X-Plane settings.
+func xplaneEndpointFromSettings(s Settings) xplaneEndpoint {
+	e := xplaneEndpoint{host: s.XPlaneHost, port: s.XPlanePort, localPort: s.XPlaneLocalPort}
+	if e.host == "" {
+		e.host = defaultXPlaneHost
+	}
+	if e.port <= 0 {
+		e.port = defaultXPlanePort
+	}
+	return e
+}
+
+func (e xplaneEndpoint) String() string {
+	return net.JoinHostPort(e.host, strconv.Itoa(e.port))
+}
+
 type XPlaneAdapter struct {
-	host string
-	port int
+	endpoint     xplaneEndpoint
+	datarefsFile string // JSON overrides for add-on aircraft, optional
 
 	mu           sync.Mutex
 	conn         *net.UDPConn
 	data         FlightData
 	lastReceived time.Time
+	refused      bool // X-Plane's host answered with ICMP port unreachable
 	stop         chan struct{}
+	rate         int // RREF packets per second
+	datarefs     []xplaneDataref
+	bindings     []func(v float64) // by RREF index, store into data
+	derived      xplaneDerived
+	fields       fieldTracker
+	sink         func(*FlightData)
 }
 
-// RREF dataref paths — indices match the switch cases in listenLoop.
-var xplaneDatarefs = []string{
-	// Position (0-3)
-	"sim/flightmodel/position/latitude",          // 0: degrees
-	"sim/flightmodel/position/longitude",         // 1: degrees
-	"sim/flightmodel/position/elevation",         // 2: altitude (meters → feet)
-	"sim/flightmodel/position/y_agl",             // 3: AGL (meters → feet)
-
-	// Attitude (4-11)
-	"sim/flightmodel/position/theta",             // 4: pitch (degrees)
-	"sim/flightmodel/position/phi",               // 5: roll (degrees)
-	"sim/flightmodel/position/psi",               // 6: heading true (degrees)
-	"sim/flightmodel/position/mag_psi",           // 7: heading magnetic (degrees)
-	"sim/flightmodel/position/vh_ind_fpm",        // 8: vertical speed (fpm)
-	"sim/flightmodel/position/indicated_airspeed", // 9: IAS (kias)
-	"sim/flightmodel/position/true_airspeed",     // 10: TAS (m/s → knots)
-	"sim/flightmodel/position/groundspeed",       // 11: GS (m/s → knots)
-
-	// Engine 1 (12-17)
-	"sim/flightmodel/engine/ENGN_running[0]",              // 12: engine 1 running
-	"sim/flightmodel/engine/ENGN_N1_[0]",                  // 13: engine 1 N1
-	"sim/flightmodel/engine/ENGN_N2_[0]",                  // 14: engine 1 N2
-	"sim/cockpit2/engine/actuators/throttle_ratio[0]",     // 15: engine 1 throttle
-	"sim/cockpit2/engine/actuators/mixture_ratio[0]",      // 16: engine 1 mixture
-	"sim/cockpit2/engine/actuators/prop_ratio[0]",         // 17: engine 1 prop
-
-	// Sensors (18-21)
-	"sim/flightmodel/failures/onground_any",      // 18: on ground
-	"sim/cockpit2/annunciators/stall_warning",    // 19: stall warning
-	"sim/cockpit2/annunciators/overspeed",        // 20: overspeed warning
-	"sim/time/sim_speed",                         // 21: simulation rate
-
-	// Radios (22-29)
-	"sim/cockpit/radios/com1_freq_hz",            // 22: COM1
-	"sim/cockpit/radios/com2_freq_hz",            // 23: COM2
-	"sim/cockpit/radios/nav1_freq_hz",            // 24: NAV1
-	"sim/cockpit/radios/nav2_freq_hz",            // 25: NAV2
-	"sim/cockpit/radios/nav1_obs_degm",           // 26: NAV1 OBS
-	"sim/cockpit/radios/nav2_obs_degm",           // 27: NAV2 OBS
-	"sim/cockpit/radios/transponder_code",        // 28: transponder code
-	"sim/cockpit/radios/transponder_mode",        // 29: transponder state
-
-	// Autopilot (30-36)
-	"sim/cockpit/autopilot/autopilot_mode",       // 30: AP master
-	"sim/cockpit/autopilot/heading_mag",          // 31: AP heading
-	"sim/cockpit/autopilot/altitude",             // 32: AP altitude
-	"sim/cockpit/autopilot/vertical_velocity",    // 33: AP VS
-	"sim/cockpit/autopilot/airspeed",             // 34: AP speed
-	"sim/cockpit2/autopilot/approach_status",     // 35: AP approach hold
-	"sim/cockpit2/autopilot/nav_status",          // 36: AP nav lock
-
-	// Altimeter (37)
-	"sim/cockpit/misc/barometer_setting",         // 37: altimeter inHg
-
-	// Lights (38-40)
-	"sim/cockpit/electrical/beacon_lights_on",    // 38: beacon
-	"sim/cockpit/electrical/strobe_lights_on",    // 39: strobe
-	"sim/cockpit/electrical/landing_lights_on",   // 40: landing
-
-	// Controls (41-46)
-	"sim/cockpit2/controls/yoke_pitch_ratio",     // 41: elevator (-1 to 1)
-	"sim/cockpit2/controls/yoke_roll_ratio",      // 42: aileron (-1 to 1)
-	"sim/cockpit2/controls/yoke_heading_ratio",   // 43: rudder (-1 to 1)
-	"sim/cockpit2/controls/flap_ratio",           // 44: flaps (0-1 → percent)
-	"sim/cockpit2/controls/speedbrake_ratio",     // 45: spoilers (0-1 → percent)
-	"sim/cockpit/switches/gear_handle_status",    // 46: gear down
-
-	// SimTime (47-51)
-	"sim/time/zulu_time_sec",                     // 47: zulu time (seconds)
-	"sim/time/local_date_days",                   // 48: zulu day (proxy)
-	"sim/time/local_date_days",                   // 49: zulu month (proxy)
-	"sim/time/local_date_days",                   // 50: zulu year (proxy)
-	"sim/time/local_time_sec",                    // 51: local time (seconds)
-
-	// G-Force (52)
-	"sim/flightmodel/position/g_nrml",            // 52: normal G-force
-
-	// Engine 2 (53-58)
-	"sim/flightmodel/engine/ENGN_running[1]",              // 53: engine 2 running
-	"sim/flightmodel/engine/ENGN_N1_[1]",                  // 54: engine 2 N1
-	"sim/flightmodel/engine/ENGN_N2_[1]",                  // 55: engine 2 N2
-	"sim/cockpit2/engine/actuators/throttle_ratio[1]",     // 56: engine 2 throttle
-	"sim/cockpit2/engine/actuators/mixture_ratio[1]",      // 57: engine 2 mixture
-	"sim/cockpit2/engine/actuators/prop_ratio[1]",         // 58: engine 2 prop
-
-	// Engine 3 (59-64)
-	"sim/flightmodel/engine/ENGN_running[2]",              // 59: engine 3 running
-	"sim/flightmodel/engine/ENGN_N1_[2]",                  // 60: engine 3 N1
-	"sim/flightmodel/engine/ENGN_N2_[2]",                  // 61: engine 3 N2
-	"sim/cockpit2/engine/actuators/throttle_ratio[2]",     // 62: engine 3 throttle
-	"sim/cockpit2/engine/actuators/mixture_ratio[2]",      // 63: engine 3 mixture
-	"sim/cockpit2/engine/actuators/prop_ratio[2]",         // 64: engine 3 prop
-
-	// Engine 4 (65-70)
-	"sim/flightmodel/engine/ENGN_running[3]",              // 65: engine 4 running
-	"sim/flightmodel/engine/ENGN_N1_[3]",                  // 66: engine 4 N1
-	"sim/flightmodel/engine/ENGN_N2_[3]",                  // 67: engine 4 N2
-	"sim/cockpit2/engine/actuators/throttle_ratio[3]",     // 68: engine 4 throttle
-	"sim/cockpit2/engine/actuators/mixture_ratio[3]",      // 69: engine 4 mixture
-	"sim/cockpit2/engine/actuators/prop_ratio[3]",         // 70: engine 4 prop
-
-	// Weight (71-72)
-	"sim/flightmodel/weight/m_total",                      // 71: total weight (kg → lbs)
-	"sim/flightmodel/weight/m_fuel_total",                 // 72: fuel weight (kg → lbs)
-
-	// Engine count (73)
-	"sim/aircraft/engine/acf_num_engines",                 // 73: number of engines
-}
-
-func NewXPlaneAdapter(host string, port int) SimConnector {
-	return &XPlaneAdapter{
-		host: host,
-		port: port,
-	}
+func NewXPlaneAdapter(endpoint xplaneEndpoint, datarefsFile string) SimConnector {
+	return &XPlaneAdapter{endpoint: endpoint, datarefsFile: datarefsFile}
 }
 
 func (x *XPlaneAdapter) Name() string {
@@ -145,33 +72,81 @@ func (x *XPlaneAdapter) Connect() error {
 	x.mu.Lock()
 	defer x.mu.Unlock()
 
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", x.host, x.port))
+	datarefs, err := loadXPlaneDatarefs(x.datarefsFile)
 	if err != nil {
-		return fmt.Errorf("resolve addr: %w", err)
+		return fmt.Errorf("load X-Plane datarefs: %w", err)
 	}
+	x.bind(datarefs)
 
-	conn, err := net.DialUDP("udp", nil, addr)
+	addr, err := net.ResolveUDPAddr("udp", x.endpoint.String())
+	if err != nil {
+		return fmt.Errorf("resolve X-Plane host %q: %w", x.endpoint.host, err)
+	}
+
+	// One socket both sends the subscriptions and receives the replies, so
+	// X-Plane answers to the same port we send from. A local simulator is
+	// only reachable through loopback and needs no firewall exception.
+	local := &net.UDPAddr{Port: x.endpoint.localPort}
+	if addr.IP.IsLoopback() {
+		local.IP = addr.IP
+	}
+	conn, err := net.DialUDP("udp", local, addr)
 	if err != nil {
-		return fmt.Errorf("dial udp: %w", err)
+		if isAddrInUse(err) {
+			return fmt.Errorf("local UDP port %d is already in use by another program: %w", x.endpoint.localPort, err)
+		}
+		return fmt.Errorf("open UDP socket to %s: %w", addr, err)
 	}
 	x.conn = conn
+	x.refused = false
+
+	if x.rate <= 0 {
+		x.rate = 1
+	}
 
 	// Subscribe to datarefs using RREF protocol
-	for i, dref := range xplaneDatarefs {
-		if err := x.subscribeRREF(i, 1, dref); err != nil {
+	for i, ref := range x.datarefs {
+		if err := x.subscribeRREF(i, x.rate, ref.Dataref); err != nil {
 			conn.Close()
 			x.conn = nil
-			return fmt.Errorf("subscribe %s: %w", dref, err)
+			if isPortUnreachable(err) {
+				return x.refusedError()
+			}
+			return fmt.Errorf("subscribe %s: %w", ref.Dataref, err)
 		}
 	}
 
 	x.stop = make(chan struct{})
-	go x.listenLoop()
+	go x.listenLoop(conn, x.stop)
 
-	slog.Info("X-Plane UDP connected", "addr", addr.String())
+	slog.Info("X-Plane UDP connected", "addr", addr.String(), "local", conn.LocalAddr().String())
 	return nil
 }
 
+// Capabilities lists the fields of the dataref table, the default one until
+// Connect has loaded the overrides.
+func (x *XPlaneAdapter) Capabilities() ConnectorCapabilities {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.datarefs == nil {
+		return ConnectorCapabilities{Fields: xplaneFields(xplaneDatarefs)}
+	}
+	return ConnectorCapabilities{Fields: xplaneFields(x.datarefs)}
+}
+
+// Endpoint returns the X-Plane address for display.
+func (x *XPlaneAdapter) Endpoint() string {
+	return x.endpoint.String()
+}
+
+// SetSampleSink implements SamplePusher; sink gets the data after every RREF
+// packet.
+func (x *XPlaneAdapter) SetSampleSink(sink func(*FlightData)) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.sink = sink
+}
+
 func (x *XPlaneAdapter) Disconnect() error {
 	x.mu.Lock()
 	defer x.mu.Unlock()
@@ -183,8 +158,8 @@ func (x *XPlaneAdapter) Disconnect() error {
 
 	if x.conn != nil {
 		// Unsubscribe by sending frequency 0
-		for i, dref := range xplaneDatarefs {
-			x.subscribeRREF(i, 0, dref)
+		for i, ref := range x.datarefs {
+			x.subscribeRREF(i, 0, ref.Dataref)
 		}
 		x.conn.Close()
 		x.conn = nil
@@ -200,7 +175,13 @@ func (x *XPlaneAdapter) GetFlightData() (*FlightData, error) {
 		return nil, fmt.Errorf("not connected")
 	}
 
-	if x.lastReceived.IsZero() || time.Since(x.lastReceived) > 3*time.Second {
+	switch {
+	case x.refused:
+		return nil, x.refusedError()
+	case x.lastReceived.IsZero():
+		return nil, fmt.Errorf("no reply from X-Plane at %s — check the address, and that no firewall or NAT router blocks UDP replies to local port %s",
+			x.endpoint, x.localPortLocked())
+	case time.Since(x.lastReceived) > 3*time.Second:
 		return nil, fmt.Errorf("no data from simulator")
 	}
 
@@ -208,6 +189,50 @@ func (x *XPlaneAdapter) GetFlightData() (*FlightData, error) {
 	return &data, nil
 }
 
+// SetUpdateRate re-subscribes all datarefs at hz packets per second.
+func (x *XPlaneAdapter) SetUpdateRate(hz int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if hz <= 0 || hz == x.rate {
+		return
+	}
+	x.rate = hz
+
+	if x.conn == nil {
+		return
+	}
+	for i, ref := range x.datarefs {
+		if err := x.subscribeRREF(i, hz, ref.Dataref); err != nil {
+			slog.Warn("failed to change X-Plane update rate", "hz", hz, "error", err)
+			return
+		}
+	}
+}
+
+// bind turns the dataref table into one setter per RREF index.
+func (x *XPlaneAdapter) bind(datarefs []xplaneDataref) {
+	x.datarefs = datarefs
+	x.fields = fieldTracker{}
+	x.bindings = make([]func(float64), len(datarefs))
+	for i, ref := range datarefs {
+		x.bindings[i] = xplaneBinding(ref, &x.data, &x.derived, &x.fields)
+	}
+}
+
+func (x *XPlaneAdapter) refusedError() error {
+	return fmt.Errorf("X-Plane at %s refused the connection — is X-Plane running there and is its UDP port %d correct?",
+		x.endpoint, x.endpoint.port)
+}
+
+func (x *XPlaneAdapter) localPortLocked() string {
+	if x.conn == nil {
+		return strconv.Itoa(x.endpoint.localPort)
+	}
+	_, port, _ := net.SplitHostPort(x.conn.LocalAddr().String())
+	return port
+}
+
 func (x *XPlaneAdapter) LastReceived() time.Time {
 	x.mu.Lock()
 	defer x.mu.Unlock()
@@ -227,30 +252,27 @@ func (x *XPlaneAdapter) subscribeRREF(index, freq int, dataref string) error {
 	return err
 }
 
-func (x *XPlaneAdapter) listenLoop() {
+func (x *XPlaneAdapter) listenLoop(conn *net.UDPConn, stop chan struct{}) {
 	buf := make([]byte, 4096)
 
-	// Create a local UDP listener to receive responses
-	localAddr := x.conn.LocalAddr().(*net.UDPAddr)
-	listener, err := net.ListenUDP("udp", localAddr)
-	if err != nil {
-		slog.Error("failed to listen for X-Plane responses", "error", err)
-		return
-	}
-	defer listener.Close()
-
-	listener.SetReadDeadline(time.Now().Add(2 * time.Second))
-
 	for {
 		select {
-		case <-x.stop:
+		case <-stop:
 			return
 		default:
 		}
 
-		listener.SetReadDeadline(time.Now().Add(1 * time.Second))
-		n, err := listener.Read(buf)
+		conn.SetReadDeadline(time.Now().Add(1 * time.Second))
+		n, err := conn.Read(buf)
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if isPortUnreachable(err) {
+				x.mu.Lock()
+				x.refused = true
+				x.mu.Unlock()
+			}
 			continue
 		}
 
@@ -266,196 +288,35 @@ func (x *XPlaneAdapter) listenLoop() {
 			offset += 8
 
 			x.mu.Lock()
-			switch idx {
-			// Position
-			case 0:
-				x.data.Position.Latitude = float64(val)
-			case 1:
-				x.data.Position.Longitude = float64(val)
-			case 2:
-				x.data.Position.Altitude = float64(val) * 3.28084 // meters to feet
-			case 3:
-				x.data.Position.AltitudeAGL = float64(val) * 3.28084
-
-			// Attitude
-			case 4:
-				x.data.Attitude.Pitch = float64(val)
-			case 5:
-				x.data.Attitude.Roll = float64(val)
-			case 6:
-				x.data.Attitude.HeadingTrue = float64(val)
-			case 7:
-				x.data.Attitude.HeadingMag = float64(val)
-			case 8:
-				x.data.Attitude.VS = float64(val) // already fpm
-			case 9:
-				x.data.Attitude.IAS = float64(val)
-			case 10:
-				x.data.Attitude.TAS = float64(val) * 1.94384 // m/s to knots
-			case 11:
-				x.data.Attitude.GS = float64(val) * 1.94384
-
-			// Engine 1
-			case 12:
-				x.data.Engines[0].Running = val != 0
-			case 13:
-				x.data.Engines[0].N1 = float64(val)
-			case 14:
-				x.data.Engines[0].N2 = float64(val)
-			case 15:
-				x.data.Engines[0].ThrottlePos = float64(val) * 100
-			case 16:
-				x.data.Engines[0].MixturePos = float64(val) * 100
-			case 17:
-				x.data.Engines[0].PropPos = float64(val) * 100
-
-			// Sensors
-			case 18:
-				x.data.Sensors.OnGround = val != 0
-			case 19:
-				x.data.Sensors.StallWarning = val != 0
-			case 20:
-				x.data.Sensors.OverspeedWarning = val != 0
-			case 21:
-				x.data.Sensors.SimulationRate = float64(val)
-
-			// Radios
-			case 22:
-				x.data.Radios.Com1 = float64(val) / 100 // hz to MHz
-			case 23:
-				x.data.Radios.Com2 = float64(val) / 100
-			case 24:
-				x.data.Radios.Nav1 = float64(val) / 100
-			case 25:
-				x.data.Radios.Nav2 = float64(val) / 100
-			case 26:
-				x.data.Radios.Nav1OBS = float64(val)
-			case 27:
-				x.data.Radios.Nav2OBS = float64(val)
-			case 28:
-				x.data.Radios.XpdrCode = float64(val)
-			case 29:
-				x.data.Radios.XpdrState = TransponderStateString(float64(val))
-
-			// Autopilot
-			case 30:
-				x.data.Autopilot.Master = val != 0
-			case 31:
-				x.data.Autopilot.Heading = float64(val)
-			case 32:
-				x.data.Autopilot.Altitude = float64(val)
-			case 33:
-				x.data.Autopilot.VS = float64(val)
-			case 34:
-				x.data.Autopilot.Speed = float64(val)
-			case 35:
-				x.data.Autopilot.ApproachHold = val != 0
-			case 36:
-				x.data.Autopilot.NavLock = val != 0
-
-			// Altimeter
-			case 37:
-				x.data.Altimeter = float64(val)
-
-			// Lights
-			case 38:
-				x.data.Lights.Beacon = val != 0
-			case 39:
-				x.data.Lights.Strobe = val != 0
-			case 40:
-				x.data.Lights.Landing = val != 0
-
-			// Controls
-			case 41:
-				x.data.Controls.Elevator = float64(val)
-			case 42:
-				x.data.Controls.Aileron = float64(val)
-			case 43:
-				x.data.Controls.Rudder = float64(val)
-			case 44:
-				x.data.Controls.Flaps = float64(val) * 100 // 0-1 → percent
-			case 45:
-				x.data.Controls.Spoilers = float64(val) * 100
-			case 46:
-				x.data.Controls.GearDown = val != 0
-
-			// SimTime
-			case 47:
-				x.data.SimTime.ZuluTime = float64(val)
-			case 48:
-				x.data.SimTime.ZuluDay = float64(val)
-			case 49:
-				x.data.SimTime.ZuluMonth = float64(val)
-			case 50:
-				x.data.SimTime.ZuluYear = float64(val)
-			case 51:
-				x.data.SimTime.LocalTime = float64(val)
-
-			// G-Force
-			case 52:
-				x.data.Attitude.GForce = float64(val)
-
-			// Engine 2
-			case 53:
-				x.data.Engines[1].Running = val != 0
-			case 54:
-				x.data.Engines[1].N1 = float64(val)
-			case 55:
-				x.data.Engines[1].N2 = float64(val)
-			case 56:
-				x.data.Engines[1].ThrottlePos = float64(val) * 100
-			case 57:
-				x.data.Engines[1].MixturePos = float64(val) * 100
-			case 58:
-				x.data.Engines[1].PropPos = float64(val) * 100
-
-			// Engine 3
-			case 59:
-				x.data.Engines[2].Running = val != 0
-			case 60:
-				x.data.Engines[2].N1 = float64(val)
-			case 61:
-				x.data.Engines[2].N2 = float64(val)
-			case 62:
-				x.data.Engines[2].ThrottlePos = float64(val) * 100
-			case 63:
-				x.data.Engines[2].MixturePos = float64(val) * 100
-			case 64:
-				x.data.Engines[2].PropPos = float64(val) * 100
-
-			// Engine 4
-			case 65:
-				x.data.Engines[3].Running = val != 0
-			case 66:
-				x.data.Engines[3].N1 = float64(val)
-			case 67:
-				x.data.Engines[3].N2 = float64(val)
-			case 68:
-				x.data.Engines[3].ThrottlePos = float64(val) * 100
-			case 69:
-				x.data.Engines[3].MixturePos = float64(val) * 100
-			case 70:
-				x.data.Engines[3].PropPos = float64(val) * 100
-
-			// Weight
-			case 71:
-				x.data.Weight.TotalWeight = float64(val) * 2.20462 // kg to lbs
-			case 72:
-				x.data.Weight.FuelWeight = float64(val) * 2.20462
-
-			// Engine count
-			case 73:
-				n := int(val)
-				x.data.Engines[0].Exists = n >= 1
-				x.data.Engines[1].Exists = n >= 2
-				x.data.Engines[2].Exists = n >= 3
-				x.data.Engines[3].Exists = n >= 4
+			if idx >= 0 && idx < len(x.bindings) {
+				x.bindings[idx](float64(val))
 			}
 			x.mu.Unlock()
 		}
 
 		x.mu.Lock()
 		x.lastReceived = time.Now()
+		x.derived.apply(&x.data, x.lastReceived)
+		x.data.Invalid = x.fields.invalidFields()
+		x.refused = false
+		sample, sink := x.data, x.sink
 		x.mu.Unlock()
+
+		if sink != nil {
+			sink(&sample)
+		}
 	}
 }
+
+// isAddrInUse reports whether binding failed because the local port is taken.
+func isAddrInUse(err error) bool {
+	const wsaeaddrinuse = syscall.Errno(10048)
+	return errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, wsaeaddrinuse)
+}
+
+// isPortUnreachable reports whether a read failed because the remote host
+// answered with ICMP port unreachable, i.e. nothing listens on the X-Plane port.
+func isPortUnreachable(err error) bool {
+	const wsaeconnreset = syscall.Errno(10054) // Windows reports it as a reset
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, wsaeconnreset)
+}
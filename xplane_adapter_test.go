@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeXPlane answers RREF subscriptions on a loopback UDP port the way
+// X-Plane does: one reply per request, sent back to the sender's address.
+type fakeXPlane struct {
+	conn    *net.UDPConn
+	senders chan *net.UDPAddr
+
+	mu       sync.Mutex
+	datarefs map[string]bool // subscribed at a non-zero rate
+}
+
+func newFakeXPlane(t *testing.T, reply bool) *fakeXPlane {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	x := &fakeXPlane{conn: conn, senders: make(chan *net.UDPAddr, 256), datarefs: make(map[string]bool)}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if n < 13 || string(buf[0:4]) != "RREF" {
+				continue
+			}
+			dref := string(bytes.TrimRight(buf[13:n], "\x00"))
+			x.mu.Lock()
+			x.datarefs[dref] = binary.LittleEndian.Uint32(buf[5:9]) > 0
+			x.mu.Unlock()
+			select {
+			case x.senders <- from:
+			default:
+			}
+			if !reply {
+				continue
+			}
+			index := binary.LittleEndian.Uint32(buf[9:13])
+			if index != 0 { // answer the latitude subscription only
+				continue
+			}
+			out := make([]byte, 13)
+			copy(out, "RREF")
+			binary.LittleEndian.PutUint32(out[5:9], 0)
+			binary.LittleEndian.PutUint32(out[9:13], math.Float32bits(51.5))
+			conn.WriteToUDP(out, from)
+		}
+	}()
+	return x
+}
+
+func (x *fakeXPlane) subscribed(dref string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.datarefs[dref]
+}
+
+func (x *fakeXPlane) endpoint() xplaneEndpoint {
+	addr := x.conn.LocalAddr().(*net.UDPAddr)
+	return xplaneEndpoint{host: "127.0.0.1", port: addr.Port}
+}
+
+// freeUDPPort returns a loopback UDP port that is not in use.
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+	return port
+}
+
+func TestXPlaneEndpointFromSettings(t *testing.T) {
+	e := xplaneEndpointFromSettings(Settings{})
+	assert.Equal(t, xplaneEndpoint{host: "127.0.0.1", port: 49000}, e)
+
+	e = xplaneEndpointFromSettings(Settings{XPlaneHost: "192.168.1.20", XPlanePort: 49010, XPlaneLocalPort: 49008})
+	assert.Equal(t, xplaneEndpoint{host: "192.168.1.20", port: 49010, localPort: 49008}, e)
+	assert.Equal(t, "192.168.1.20:49010", e.String())
+}
+
+func TestXPlaneAdapterReceivesOnLocalPort(t *testing.T) {
+	sim := newFakeXPlane(t, true)
+	endpoint := sim.endpoint()
+	endpoint.localPort = freeUDPPort(t)
+
+	x := NewXPlaneAdapter(endpoint, "")
+	require.NoError(t, x.Connect())
+	defer x.Disconnect()
+
+	from := <-sim.senders
+	assert.Equal(t, endpoint.localPort, from.Port, "subscriptions are sent from the configured local port")
+
+	require.Eventually(t, func() bool {
+		fd, err := x.GetFlightData()
+		return err == nil && fd.Position.Latitude == 51.5
+	}, 3*time.Second, 20*time.Millisecond, "replies to the local port are received")
+	assert.Equal(t, "X-Plane ("+endpoint.String()+")", connectorLabel(x))
+}
+
+func TestXPlaneAdapterLocalPortInUse(t *testing.T) {
+	sim := newFakeXPlane(t, true)
+	busy, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer busy.Close()
+
+	endpoint := sim.endpoint()
+	endpoint.localPort = busy.LocalAddr().(*net.UDPAddr).Port
+
+	err = NewXPlaneAdapter(endpoint, "").Connect()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already in use")
+}
+
+func TestXPlaneAdapterNoReply(t *testing.T) {
+	sim := newFakeXPlane(t, false)
+	x := NewXPlaneAdapter(sim.endpoint(), "")
+	require.NoError(t, x.Connect())
+	defer x.Disconnect()
+
+	<-sim.senders
+	_, err := x.GetFlightData()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no reply from X-Plane at "+sim.endpoint().String())
+	assert.Contains(t, err.Error(), "firewall")
+}
+
+func TestXPlaneAdapterConnectionRefused(t *testing.T) {
+	x := NewXPlaneAdapter(xplaneEndpoint{host: "127.0.0.1", port: freeUDPPort(t)}, "")
+
+	// UDP has no handshake: the ICMP refusal shows up on a later write
+	// during the subscriptions, or on a read once connected.
+	err := x.Connect()
+	if err == nil {
+		defer x.Disconnect()
+		require.Eventually(t, func() bool {
+			_, err = x.GetFlightData()
+			return err != nil && strings.Contains(err.Error(), "refused")
+		}, 3*time.Second, 50*time.Millisecond)
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "X-Plane at 127.0.0.1:")
+	assert.Contains(t, err.Error(), "refused the connection")
+}
+
+func TestReconnectSimKeepsXPlaneEndpoint(t *testing.T) {
+	sim := newFakeXPlane(t, true)
+	fds := &FlightDataService{adapterName: "X-Plane", xplane: sim.endpoint()}
+
+	require.NoError(t, fds.reconnectSim())
+	defer fds.connector.Disconnect()
+
+	xp, ok := fds.connector.(*XPlaneAdapter)
+	require.True(t, ok)
+	assert.Equal(t, sim.endpoint(), xp.endpoint)
+}
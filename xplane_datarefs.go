@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xplaneDataref maps one X-Plane dataref onto a FlightData field (see
+// flightDataFields), converting units on the way. The RREF index of an entry
+// is its position in the table.
+type xplaneDataref struct {
+	Dataref string `json:"dataref"`
+	Field   string `json:"field"`
+	Convert string `json:"convert,omitempty"` // key of unitConversions
+}
+
+// Pseudo-fields for datarefs that are not FlightData fields themselves but
+// are needed to work out some, see xplaneDerived.
+const (
+	xplaneLocalDateField   = "simTime.localDateDays"    // the zulu date
+	xplaneFlapHandleField  = "controls.flapHandleRatio" // the flaps detent
+	xplaneFlapDetentsField = "controls.flapDetents"
+	xplaneWindMagField     = "environment.windDirectionMag" // the indicated wind
+)
+
+// xplaneMaxTanks is the number of fuel tanks an X-Plane aircraft can have.
+// Their pseudo-fields are weight.tankNFuel, weight.tankNLateral and
+// weight.tankNCapacity, N counting from 1; see xplaneFuelTanks.
+const xplaneMaxTanks = 9
+
+// xplaneTankFields maps the tank pseudo-fields to the tank and the value.
+var xplaneTankFields = buildXPlaneTankFields()
+
+type xplaneTankField struct {
+	tank  int
+	value string // "Fuel", "Lateral" or "Capacity"
+}
+
+func buildXPlaneTankFields() map[string]xplaneTankField {
+	fields := make(map[string]xplaneTankField, 3*xplaneMaxTanks)
+	for i := range xplaneMaxTanks {
+		for _, value := range []string{"Fuel", "Lateral", "Capacity"} {
+			fields[fmt.Sprintf("weight.tank%d%s", i+1, value)] = xplaneTankField{i, value}
+		}
+	}
+	return fields
+}
+
+// xplaneDerived holds the values of the pseudo-fields.
+type xplaneDerived struct {
+	localDate    float64 // day of the year
+	flapHandle   float64 // 0-1, the detents are evenly spaced
+	flapDetents  float64 // handle positions besides up
+	windMag      float64 // degrees magnetic
+	hasWind      bool
+	tankFuel     [xplaneMaxTanks]float64 // lbs
+	tankLateral  [xplaneMaxTanks]float64 // distance right of the centreline
+	tankCapacity [xplaneMaxTanks]float64 // share of the aircraft's capacity, 0 when there is no tank
+	hasTanks     bool
+}
+
+// field returns where the value of a pseudo-field goes, nil for other fields.
+func (x *xplaneDerived) field(name string) *float64 {
+	switch name {
+	case xplaneLocalDateField:
+		return &x.localDate
+	case xplaneFlapHandleField:
+		return &x.flapHandle
+	case xplaneFlapDetentsField:
+		return &x.flapDetents
+	case xplaneWindMagField:
+		return &x.windMag
+	}
+	if f, ok := xplaneTankFields[name]; ok {
+		switch f.value {
+		case "Fuel":
+			return &x.tankFuel[f.tank]
+		case "Lateral":
+			return &x.tankLateral[f.tank]
+		default:
+			return &x.tankCapacity[f.tank]
+		}
+	}
+	return nil
+}
+
+// received notes that a value of pseudo-field name arrived, so apply only
+// fills the fields of pseudo-fields in the table.
+func (x *xplaneDerived) received(name string) {
+	if name == xplaneWindMagField {
+		x.hasWind = true
+	} else if _, ok := xplaneTankFields[name]; ok {
+		x.hasTanks = true
+	}
+}
+
+// apply fills the fields of d worked out from the pseudo-fields.
+func (x *xplaneDerived) apply(d *FlightData, now time.Time) {
+	setXPlaneZuluDate(d, x.localDate, now)
+	if x.flapDetents > 0 {
+		d.Controls.FlapsDetent = math.Round(x.flapHandle * x.flapDetents)
+	}
+	if x.hasWind {
+		// The aircraft's two headings give the magnetic variation.
+		variation := d.Attitude.HeadingTrue - d.Attitude.HeadingMag
+		d.Environment.WindDirection = math.Mod(x.windMag+variation+720, 360)
+	}
+	if x.hasTanks {
+		d.Weight.FuelTanks = xplaneFuelTanks(x.tankFuel, x.tankLateral, x.tankCapacity)
+	}
+}
+
+// xplaneFuelTanks sorts the tanks of an X-Plane aircraft into the slots of
+// WeightData.FuelTanks. X-Plane numbers the tanks as the aircraft's author
+// chose, so they are placed by where they sit: the largest tank on the
+// centreline is the centre tank, the largest on either side is that wing's
+// main tank and every other tank is auxiliary.
+func xplaneFuelTanks(fuel, lateral, capacity [xplaneMaxTanks]float64) [4]float64 {
+	var span float64
+	for i := range fuel {
+		if capacity[i] > 0 {
+			span = max(span, math.Abs(lateral[i]))
+		}
+	}
+	// side is -1 for the left, 0 for the centreline and 1 for the right.
+	side := func(i int) int {
+		switch {
+		case math.Abs(lateral[i]) <= span/10:
+			return 0
+		case lateral[i] < 0:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	// The largest tank of each side, indexed by side+1 like the slots.
+	largest := [3]int{-1, -1, -1}
+	for i := range fuel {
+		if capacity[i] <= 0 {
+			continue
+		}
+		if l := &largest[side(i)+1]; *l < 0 || capacity[i] > capacity[*l] {
+			*l = i
+		}
+	}
+
+	var tanks [4]float64
+	for i := range fuel {
+		if capacity[i] <= 0 {
+			continue
+		}
+		slot := side(i) + 1
+		if largest[slot] != i {
+			slot = 3
+		}
+		tanks[slot] += fuel[i]
+	}
+	return tanks
+}
+
+// xplaneDatarefs is the default table, valid for any aircraft that drives the
+// standard datarefs. Add-ons with custom systems can override entries.
+var xplaneDatarefs = append([]xplaneDataref{
+	// Position
+	{"sim/flightmodel/position/latitude", "position.latitude", ""},
+	{"sim/flightmodel/position/longitude", "position.longitude", ""},
+	{"sim/flightmodel/position/elevation", "position.altitude", "m_to_ft"},
+	{"sim/flightmodel/position/y_agl", "position.altitudeAGL", "m_to_ft"},
+
+	// Attitude
+	{"sim/flightmodel/position/theta", "attitude.pitch", ""},
+	{"sim/flightmodel/position/phi", "attitude.roll", ""},
+	{"sim/flightmodel/position/psi", "attitude.headingTrue", ""},
+	{"sim/flightmodel/position/mag_psi", "attitude.headingMag", ""},
+	{"sim/flightmodel/position/vh_ind_fpm", "attitude.vs", ""},
+	{"sim/flightmodel/position/indicated_airspeed", "attitude.ias", ""},
+	{"sim/flightmodel/position/true_airspeed", "attitude.tas", "mps_to_kts"},
+	{"sim/flightmodel/position/groundspeed", "attitude.gs", "mps_to_kts"},
+	{"sim/flightmodel/position/g_nrml", "attitude.gForce", ""},
+
+	// Engines
+	{"sim/aircraft/engine/acf_num_engines", "engines.count", ""},
+	{"sim/flightmodel/engine/ENGN_running[0]", "engine1.running", ""},
+	{"sim/flightmodel/engine/ENGN_N1_[0]", "engine1.n1", ""},
+	{"sim/flightmodel/engine/ENGN_N2_[0]", "engine1.n2", ""},
+	{"sim/cockpit2/engine/actuators/throttle_ratio[0]", "engine1.throttlePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/mixture_ratio[0]", "engine1.mixturePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/prop_ratio[0]", "engine1.propPos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/indicators/fuel_flow_kg_sec[0]", "engine1.fuelFlow", "kgs_to_lbsh"},
+	{"sim/flightmodel/engine/ENGN_running[1]", "engine2.running", ""},
+	{"sim/flightmodel/engine/ENGN_N1_[1]", "engine2.n1", ""},
+	{"sim/flightmodel/engine/ENGN_N2_[1]", "engine2.n2", ""},
+	{"sim/cockpit2/engine/actuators/throttle_ratio[1]", "engine2.throttlePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/mixture_ratio[1]", "engine2.mixturePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/prop_ratio[1]", "engine2.propPos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/indicators/fuel_flow_kg_sec[1]", "engine2.fuelFlow", "kgs_to_lbsh"},
+	{"sim/flightmodel/engine/ENGN_running[2]", "engine3.running", ""},
+	{"sim/flightmodel/engine/ENGN_N1_[2]", "engine3.n1", ""},
+	{"sim/flightmodel/engine/ENGN_N2_[2]", "engine3.n2", ""},
+	{"sim/cockpit2/engine/actuators/throttle_ratio[2]", "engine3.throttlePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/mixture_ratio[2]", "engine3.mixturePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/prop_ratio[2]", "engine3.propPos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/indicators/fuel_flow_kg_sec[2]", "engine3.fuelFlow", "kgs_to_lbsh"},
+	{"sim/flightmodel/engine/ENGN_running[3]", "engine4.running", ""},
+	{"sim/flightmodel/engine/ENGN_N1_[3]", "engine4.n1", ""},
+	{"sim/flightmodel/engine/ENGN_N2_[3]", "engine4.n2", ""},
+	{"sim/cockpit2/engine/actuators/throttle_ratio[3]", "engine4.throttlePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/mixture_ratio[3]", "engine4.mixturePos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/actuators/prop_ratio[3]", "engine4.propPos", "ratio_to_percent"},
+	{"sim/cockpit2/engine/indicators/fuel_flow_kg_sec[3]", "engine4.fuelFlow", "kgs_to_lbsh"},
+
+	// Sensors
+	{"sim/flightmodel/failures/onground_any", "sensors.onGround", ""},
+	{"sim/cockpit2/annunciators/stall_warning", "sensors.stallWarning", ""},
+	{"sim/cockpit2/annunciators/overspeed", "sensors.overspeedWarning", ""},
+	{"sim/time/sim_speed", "sensors.simulationRate", ""},
+
+	// Radios
+	{"sim/cockpit/radios/com1_freq_hz", "radios.com1", "freq_10khz_to_mhz"},
+	{"sim/cockpit/radios/com2_freq_hz", "radios.com2", "freq_10khz_to_mhz"},
+	{"sim/cockpit/radios/nav1_freq_hz", "radios.nav1", "freq_10khz_to_mhz"},
+	{"sim/cockpit/radios/nav2_freq_hz", "radios.nav2", "freq_10khz_to_mhz"},
+	{"sim/cockpit/radios/nav1_obs_degm", "radios.nav1OBS", ""},
+	{"sim/cockpit/radios/nav2_obs_degm", "radios.nav2OBS", ""},
+	{"sim/cockpit/radios/transponder_code", "radios.xpdrCode", ""},
+	{"sim/cockpit/radios/transponder_mode", "radios.xpdrState", ""},
+
+	// Autopilot
+	{"sim/cockpit/autopilot/autopilot_mode", "autopilot.master", ""},
+	{"sim/cockpit/autopilot/heading_mag", "autopilot.heading", ""},
+	{"sim/cockpit/autopilot/altitude", "autopilot.altitude", ""},
+	{"sim/cockpit/autopilot/vertical_velocity", "autopilot.vs", ""},
+	{"sim/cockpit/autopilot/airspeed", "autopilot.speed", ""},
+	{"sim/cockpit2/autopilot/approach_status", "autopilot.approachHold", ""},
+	{"sim/cockpit2/autopilot/nav_status", "autopilot.navLock", ""},
+
+	// Altimeter
+	{"sim/cockpit/misc/barometer_setting", "altimeterInHg", ""},
+
+	// Lights
+	{"sim/cockpit/electrical/beacon_lights_on", "lights.beacon", ""},
+	{"sim/cockpit/electrical/strobe_lights_on", "lights.strobe", ""},
+	{"sim/cockpit/electrical/landing_lights_on", "lights.landing", ""},
+
+	// Controls
+	{"sim/cockpit2/controls/yoke_pitch_ratio", "controls.elevator", ""},
+	{"sim/cockpit2/controls/yoke_roll_ratio", "controls.aileron", ""},
+	{"sim/cockpit2/controls/yoke_heading_ratio", "controls.rudder", ""},
+	{"sim/cockpit2/controls/flap_ratio", "controls.flaps", "ratio_to_percent"},
+	{"sim/cockpit2/controls/speedbrake_ratio", "controls.spoilers", "ratio_to_percent"},
+	{"sim/cockpit/switches/gear_handle_status", "controls.gearDown", ""},
+	{"sim/cockpit2/controls/parking_brake_ratio", "controls.parkingBrake", ""},
+	{"sim/cockpit2/controls/flap_handle_request_ratio", xplaneFlapHandleField, ""},
+	{"sim/aircraft/controls/acf_flap_detents", xplaneFlapDetentsField, ""},
+
+	// Sim time; the zulu date is derived from the local day of the year
+	{"sim/time/zulu_time_sec", "simTime.zuluTime", ""},
+	{"sim/time/local_time_sec", "simTime.localTime", ""},
+	{"sim/time/local_date_days", xplaneLocalDateField, ""},
+
+	// Weight
+	{"sim/flightmodel/weight/m_total", "weight.totalWeight", "kg_to_lbs"},
+	{"sim/flightmodel/weight/m_fuel_total", "weight.fuelWeight", "kg_to_lbs"},
+	// The tanks are sorted into weight.fuelTankN by xplaneFuelTanks, from the
+	// entries of xplaneTankDatarefs.
+
+	// Environment at the aircraft. sim/weather/wind_* are the wind layers,
+	// not the wind where the aircraft is.
+	{"sim/weather/temperature_ambient_c", "environment.oat", ""},
+	{"sim/cockpit2/gauges/indicators/wind_heading_deg_mag", xplaneWindMagField, ""},
+	{"sim/cockpit2/gauges/indicators/wind_speed_kts", "environment.windSpeed", ""},
+	{"sim/weather/barometer_sealevel_inhg", "environment.qnh", "inhg_to_hpa"},
+	{"sim/weather/visibility_reported_m", "environment.visibility", ""},
+
+	// X-Plane has no standard dataref for sensors.pushback; map the one of
+	// the pushback plugin in use, e.g. BetterPushback's bp/started, in the
+	// override file.
+}, xplaneTankDatarefs()...)
+
+// xplaneTankDatarefs maps the quantity, lateral position and size of every
+// fuel tank onto the tank pseudo-fields.
+func xplaneTankDatarefs() []xplaneDataref {
+	var refs []xplaneDataref
+	for i := range xplaneMaxTanks {
+		refs = append(refs,
+			xplaneDataref{fmt.Sprintf("sim/flightmodel/weight/m_fuel[%d]", i), fmt.Sprintf("weight.tank%dFuel", i+1), "kg_to_lbs"},
+			xplaneDataref{fmt.Sprintf("sim/aircraft/overflow/acf_tank_X[%d]", i), fmt.Sprintf("weight.tank%dLateral", i+1), ""},
+			xplaneDataref{fmt.Sprintf("sim/aircraft/overflow/acf_tank_rat[%d]", i), fmt.Sprintf("weight.tank%dCapacity", i+1), ""},
+		)
+	}
+	return refs
+}
+
+// loadXPlaneDatarefs returns the default table with the entries of a JSON
+// override file applied. An override replaces the default entry for the same
+// field, or is added when the field has no default dataref.
+func loadXPlaneDatarefs(path string) ([]xplaneDataref, error) {
+	table := append([]xplaneDataref(nil), xplaneDatarefs...)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read dataref file: %w", err)
+		}
+		var overrides []xplaneDataref
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return nil, fmt.Errorf("parse dataref file: %w", err)
+		}
+
+		byField := make(map[string]int, len(table))
+		for i, ref := range table {
+			byField[ref.Field] = i
+		}
+		for _, ref := range overrides {
+			if i, ok := byField[ref.Field]; ok {
+				table[i] = ref
+			} else {
+				byField[ref.Field] = len(table)
+				table = append(table, ref)
+			}
+		}
+	}
+
+	for _, ref := range table {
+		if ref.Dataref == "" {
+			return nil, fmt.Errorf("field %q: dataref is empty", ref.Field)
+		}
+		if _, ok := flightDataFields[ref.Field]; !ok && (&xplaneDerived{}).field(ref.Field) == nil {
+			return nil, fmt.Errorf("dataref %s: unknown field %q", ref.Dataref, ref.Field)
+		}
+		if _, ok := unitConversions[ref.Convert]; !ok {
+			return nil, fmt.Errorf("dataref %s: unknown conversion %q", ref.Dataref, ref.Convert)
+		}
+	}
+	return table, nil
+}
+
+// xplaneBinding returns the function storing a value of ref, converted, into
+// d and marking the field as received. Pseudo-fields go to derived instead.
+func xplaneBinding(ref xplaneDataref, d *FlightData, derived *xplaneDerived, fields *fieldTracker) func(v float64) {
+	field := ref.Field
+	convert := unitConversions[ref.Convert]
+	if dst := derived.field(field); dst != nil {
+		return func(v float64) {
+			*dst = convert(v)
+			derived.received(field)
+			fields.mark(field)
+		}
+	}
+	set := flightDataFields[field]
+	return func(v float64) {
+		set(d, convert(v))
+		fields.mark(field)
+	}
+}
+
+// xplaneFields lists the FlightData fields a dataref table fills.
+func xplaneFields(table []xplaneDataref) []string {
+	fields := make([]string, len(table))
+	for i, ref := range table {
+		fields[i] = ref.Field
+	}
+	return withImpliedFields(fields)
+}
+
+// splitDatarefIndex splits "name[3]" into the dataref name and the array
+// index. The index is -1 for datarefs that are not array elements.
+func splitDatarefIndex(dataref string) (string, int) {
+	open := strings.LastIndexByte(dataref, '[')
+	if open < 0 || !strings.HasSuffix(dataref, "]") {
+		return dataref, -1
+	}
+	index, err := strconv.Atoi(dataref[open+1 : len(dataref)-1])
+	if err != nil || index < 0 {
+		return dataref, -1
+	}
+	return dataref[:open], index
+}
+
+// setXPlaneZuluDate fills the zulu date of d from the local date and times.
+func setXPlaneZuluDate(d *FlightData, localDate float64, now time.Time) {
+	zulu := xplaneZuluDate(localDate, d.SimTime.LocalTime, d.SimTime.ZuluTime, now)
+	d.SimTime.ZuluDay = float64(zulu.Day())
+	d.SimTime.ZuluMonth = float64(zulu.Month())
+	d.SimTime.ZuluYear = float64(zulu.Year())
+}
+
+// xplaneZuluDate works out the zulu date. X-Plane only publishes the local
+// day of the year, so the day is shifted when local time and zulu time are
+// on different sides of midnight, and the year is the one that puts the date
+// closest to now.
+func xplaneZuluDate(localDayOfYear, localSec, zuluSec float64, now time.Time) time.Time {
+	day := int(localDayOfYear)
+	switch diff := localSec - zuluSec; {
+	case diff < -12*3600: // local time is already past midnight, zulu is not
+		day--
+	case diff > 12*3600: // zulu time is already past midnight, local is not
+		day++
+	}
+
+	var best time.Time
+	for _, year := range []int{now.Year() - 1, now.Year(), now.Year() + 1} {
+		date := time.Date(year, 1, 1+day, 0, 0, 0, 0, time.UTC)
+		if best.IsZero() || absDuration(date.Sub(now)) < absDuration(best.Sub(now)) {
+			best = date
+		}
+	}
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
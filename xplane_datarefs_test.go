@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDatarefFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "datarefs.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestDefaultXPlaneDatarefs(t *testing.T) {
+	table, err := loadXPlaneDatarefs("")
+	require.NoError(t, err)
+	assert.Equal(t, xplaneDatarefs, table)
+
+	seen := make(map[string]bool)
+	for _, ref := range table {
+		assert.False(t, seen[ref.Field], "field %s is mapped twice", ref.Field)
+		seen[ref.Field] = true
+	}
+
+	fields := xplaneFields(table)
+	assert.Contains(t, fields, "simTime.zuluDay", "the local date stands in for the Zulu date")
+	assert.Contains(t, fields, "engine1.exists")
+	assert.Contains(t, fields, "controls.flapsDetent", "worked out from the handle and the number of detents")
+	assert.Contains(t, fields, "environment.windDirection", "worked out from the indicated wind")
+	assert.Contains(t, fields, "weight.fuelTank4", "sorted from the aircraft's tanks")
+	assert.NotContains(t, fields, "aircraftName")
+	assert.NotContains(t, fields, "sensors.pushback", "left to the pushback plugin's dataref")
+	assert.IsIncreasing(t, fields)
+}
+
+func TestLoadXPlaneDatarefOverrides(t *testing.T) {
+	path := writeDatarefFile(t, `[
+		{"dataref": "laminar/B738/electrical/apu_gen1_pos", "field": "apu.genSwitch"},
+		{"dataref": "AirbusFBW/FlapLeverRatio", "field": "controls.flaps", "convert": "ratio_to_percent"}
+	]`)
+
+	table, err := loadXPlaneDatarefs(path)
+	require.NoError(t, err)
+	require.Len(t, table, len(xplaneDatarefs)+1, "new fields are added, known ones replaced")
+	assert.Equal(t, xplaneDataref{"laminar/B738/electrical/apu_gen1_pos", "apu.genSwitch", ""}, table[len(table)-1])
+	for _, ref := range table {
+		if ref.Field == "controls.flaps" {
+			assert.Equal(t, "AirbusFBW/FlapLeverRatio", ref.Dataref)
+		}
+	}
+	assert.Contains(t, xplaneDatarefs, xplaneDataref{"sim/cockpit2/controls/flap_ratio", "controls.flaps", "ratio_to_percent"},
+		"the default table is left alone")
+}
+
+func TestLoadXPlaneDatarefsRejectsBadEntries(t *testing.T) {
+	_, err := loadXPlaneDatarefs(writeDatarefFile(t, `[{"dataref": "x/y", "field": "controls.warpDrive"}]`))
+	assert.ErrorContains(t, err, `unknown field "controls.warpDrive"`)
+
+	_, err = loadXPlaneDatarefs(writeDatarefFile(t, `[{"dataref": "x/y", "field": "controls.flaps", "convert": "furlongs"}]`))
+	assert.ErrorContains(t, err, `unknown conversion "furlongs"`)
+
+	_, err = loadXPlaneDatarefs(writeDatarefFile(t, `[{"field": "controls.flaps"}]`))
+	assert.ErrorContains(t, err, "dataref is empty")
+
+	_, err = loadXPlaneDatarefs(writeDatarefFile(t, `{"not": "a list"}`))
+	assert.ErrorContains(t, err, "parse dataref file")
+
+	_, err = loadXPlaneDatarefs(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "read dataref file")
+}
+
+func TestXPlaneZuluDate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                string
+		day, local, zulu    float64
+		wantY, wantM, wantD int
+	}{
+		{"same day", 68, 10 * 3600, 9 * 3600, 2026, 3, 10},
+		{"local ahead, zulu still yesterday", 68, 1 * 3600, 15 * 3600, 2026, 3, 9},
+		{"local behind, zulu already tomorrow", 68, 22 * 3600, 3 * 3600, 2026, 3, 11},
+		{"new year's eve flight in january", 364, 12 * 3600, 12 * 3600, 2025, 12, 31},
+		{"zulu crosses into the new year", 364, 20 * 3600, 2 * 3600, 2026, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := xplaneZuluDate(tt.day, tt.local, tt.zulu, now)
+			assert.Equal(t, time.Date(tt.wantY, time.Month(tt.wantM), tt.wantD, 0, 0, 0, 0, time.UTC), got)
+		})
+	}
+}
+
+func TestXPlaneFlapsDetent(t *testing.T) {
+	var d FlightData
+	var derived xplaneDerived
+	var fields fieldTracker
+	handle := xplaneBinding(xplaneDataref{Field: xplaneFlapHandleField}, &d, &derived, &fields)
+	detents := xplaneBinding(xplaneDataref{Field: xplaneFlapDetentsField}, &d, &derived, &fields)
+
+	handle(0.5)
+	derived.apply(&d, time.Now())
+	assert.Zero(t, d.Controls.FlapsDetent, "unknown until the number of detents arrives")
+
+	detents(8)    // a 737: flaps 1, 2, 5, 10, 15, 25, 30 and 40
+	handle(0.625) // flaps 15
+	derived.apply(&d, time.Now())
+	assert.Equal(t, 5.0, d.Controls.FlapsDetent)
+	assert.NotContains(t, fields.invalidFields(), "controls.flapsDetent")
+}
+
+func TestXPlaneFuelTanks(t *testing.T) {
+	// A 737 whose author numbered the tanks centre, left, right.
+	var fuel, lateral, capacity [xplaneMaxTanks]float64
+	fuel[0], lateral[0], capacity[0] = 8000, 0, 0.56
+	fuel[1], lateral[1], capacity[1] = 5000, -4.2, 0.22
+	fuel[2], lateral[2], capacity[2] = 5100, 4.2, 0.22
+	assert.Equal(t, [4]float64{5000, 8000, 5100, 0}, xplaneFuelTanks(fuel, lateral, capacity))
+
+	// Inner and outer wing tanks and a trim tank in the tail.
+	fuel[3], lateral[3], capacity[3] = 900, -12, 0.05
+	fuel[4], lateral[4], capacity[4] = 800, 12, 0.05
+	fuel[5], lateral[5], capacity[5] = 300, 0.3, 0.02
+	assert.Equal(t, [4]float64{5000, 8000, 5100, 2000}, xplaneFuelTanks(fuel, lateral, capacity))
+
+	// Slots the aircraft has no tank for stay empty.
+	var single [xplaneMaxTanks]float64
+	single[0] = 1
+	assert.Equal(t, [4]float64{0, 300, 0, 0}, xplaneFuelTanks([xplaneMaxTanks]float64{300}, [xplaneMaxTanks]float64{}, single))
+}
+
+func TestXPlaneWindDirection(t *testing.T) {
+	var d FlightData
+	var derived xplaneDerived
+	var fields fieldTracker
+	wind := xplaneBinding(xplaneDataref{Field: xplaneWindMagField}, &d, &derived, &fields)
+
+	d.Environment.WindDirection = 123
+	derived.apply(&d, time.Now())
+	assert.Equal(t, 123.0, d.Environment.WindDirection, "left alone without the indicated wind")
+
+	d.Attitude.HeadingTrue, d.Attitude.HeadingMag = 10, 358 // 12° east
+	wind(355)
+	derived.apply(&d, time.Now())
+	assert.InDelta(t, 7, d.Environment.WindDirection, 1e-9, "degrees true")
+	assert.NotContains(t, fields.invalidFields(), "environment.windDirection")
+}
+
+func TestXPlaneAdapterSubscribesOverrides(t *testing.T) {
+	sim := newFakeXPlane(t, true)
+	path := writeDatarefFile(t, `[{"dataref": "AirbusFBW/FlapLeverRatio", "field": "controls.flaps", "convert": "ratio_to_percent"}]`)
+
+	x := NewXPlaneAdapter(sim.endpoint(), path)
+	require.NoError(t, x.Connect())
+	defer x.Disconnect()
+
+	require.Eventually(t, func() bool {
+		return sim.subscribed("AirbusFBW/FlapLeverRatio") && sim.subscribed("sim/time/local_date_days")
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.False(t, sim.subscribed("sim/cockpit2/controls/flap_ratio"), "the overridden dataref is not requested")
+
+	err := NewXPlaneAdapter(sim.endpoint(), writeDatarefFile(t, `[{"dataref": "x", "field": "nope"}]`)).Connect()
+	assert.ErrorContains(t, err, "load X-Plane datarefs")
+}
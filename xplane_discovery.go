@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	xplaneBeaconAddr    = "239.255.1.1:49707" // X-Plane's BECN multicast group
+	xplaneInstanceTTL   = 5 * time.Second     // beacons arrive once per second
+	xplaneDiscoveryWait = 1500 * time.Millisecond
+)
+
+// XPlaneInstance is an X-Plane copy announcing itself with BECN beacons.
+type XPlaneInstance struct {
+	Host         string    `json:"host"`
+	Port         int       `json:"port"` // UDP port X-Plane accepts RREF requests on
+	ComputerName string    `json:"computerName"`
+	Version      int       `json:"version"` // e.g. 120100 for 12.1.0
+	Role         string    `json:"role"`    // master, external_visual or ios
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+func (i XPlaneInstance) endpoint() string {
+	return net.JoinHostPort(i.Host, fmt.Sprint(i.Port))
+}
+
+var xplaneRoles = map[uint32]string{1: "master", 2: "external_visual", 3: "ios"}
+
+// parseBeacon decodes a BECN packet. The packed payload after the "BECN\0"
+// prologue is: major and minor version (1 byte each), host id, X-Plane
+// version and role (4 bytes each), port (2 bytes) and the NUL-terminated
+// computer name.
+func parseBeacon(b []byte, from *net.UDPAddr) (XPlaneInstance, error) {
+	const fixed = 5 + 1 + 1 + 4 + 4 + 4 + 2
+	if len(b) < fixed || string(b[:5]) != "BECN\x00" {
+		return XPlaneInstance{}, fmt.Errorf("not a BECN packet")
+	}
+	if hostID := binary.LittleEndian.Uint32(b[7:11]); hostID != 1 {
+		return XPlaneInstance{}, fmt.Errorf("beacon from application %d, not X-Plane", hostID)
+	}
+
+	name := b[fixed:]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+
+	role := xplaneRoles[binary.LittleEndian.Uint32(b[15:19])]
+	if role == "" {
+		role = "unknown"
+	}
+	return XPlaneInstance{
+		Host:         from.IP.String(),
+		Port:         int(binary.LittleEndian.Uint16(b[19:21])),
+		ComputerName: string(name),
+		Version:      int(binary.LittleEndian.Uint32(b[11:15])),
+		Role:         role,
+	}, nil
+}
+
+// xplaneDiscovery collects BECN beacons in the background.
+type xplaneDiscovery struct {
+	addr string // multicast group, or a unicast address in tests
+	now  func() time.Time
+
+	mu        sync.Mutex
+	conn      *net.UDPConn
+	instances map[string]XPlaneInstance // by host:port
+	changed   chan struct{}             // closed and replaced on every new beacon
+}
+
+func newXPlaneDiscovery(addr string) *xplaneDiscovery {
+	return &xplaneDiscovery{
+		addr:      addr,
+		now:       time.Now,
+		instances: make(map[string]XPlaneInstance),
+		changed:   make(chan struct{}),
+	}
+}
+
+func (d *xplaneDiscovery) start() error {
+	addr, err := net.ResolveUDPAddr("udp4", d.addr)
+	if err != nil {
+		return fmt.Errorf("resolve beacon addr: %w", err)
+	}
+
+	var conn *net.UDPConn
+	if addr.IP.IsMulticast() {
+		conn, err = net.ListenMulticastUDP("udp4", nil, addr)
+	} else {
+		conn, err = net.ListenUDP("udp4", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("listen for X-Plane beacons: %w", err)
+	}
+
+	d.mu.Lock()
+	d.conn = conn
+	d.mu.Unlock()
+
+	go d.listenLoop(conn)
+	return nil
+}
+
+func (d *xplaneDiscovery) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
+func (d *xplaneDiscovery) listenLoop(conn *net.UDPConn) {
+	buf := make([]byte, 1024)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		inst, err := parseBeacon(buf[:n], from)
+		if err != nil {
+			continue
+		}
+
+		d.mu.Lock()
+		inst.LastSeen = d.now()
+		if _, known := d.instances[inst.endpoint()]; !known {
+			slog.Info("X-Plane discovered", "host", inst.Host, "port", inst.Port, "name", inst.ComputerName, "role", inst.Role)
+		}
+		d.instances[inst.endpoint()] = inst
+		close(d.changed)
+		d.changed = make(chan struct{})
+		d.mu.Unlock()
+	}
+}
+
+// list returns the instances heard from recently, master first.
+func (d *xplaneDiscovery) list() []XPlaneInstance {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.listLocked()
+}
+
+func (d *xplaneDiscovery) listLocked() []XPlaneInstance {
+	now := d.now()
+	list := make([]XPlaneInstance, 0, len(d.instances))
+	for key, inst := range d.instances {
+		if now.Sub(inst.LastSeen) > xplaneInstanceTTL {
+			delete(d.instances, key)
+			continue
+		}
+		list = append(list, inst)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].Role == "master") != (list[j].Role == "master") {
+			return list[i].Role == "master"
+		}
+		return list[i].endpoint() < list[j].endpoint()
+	})
+	return list
+}
+
+// wait blocks until done accepts the instance list or the timeout expires,
+// and returns the last list seen.
+func (d *xplaneDiscovery) wait(timeout time.Duration, done func([]XPlaneInstance) bool) []XPlaneInstance {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		d.mu.Lock()
+		list := d.listLocked()
+		changed := d.changed
+		d.mu.Unlock()
+
+		if done(list) {
+			return list
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return list
+		}
+	}
+}
+
+// pickXPlaneInstance chooses the instance to fly with: the computer the pilot
+// picked last time, otherwise the master. External visuals are never chosen
+// automatically.
+func pickXPlaneInstance(list []XPlaneInstance, preferred string) (XPlaneInstance, bool) {
+	if preferred != "" {
+		for _, inst := range list {
+			if inst.ComputerName == preferred {
+				return inst, true
+			}
+		}
+	}
+	for _, inst := range list {
+		if inst.Role == "master" {
+			return inst, true
+		}
+	}
+	return XPlaneInstance{}, false
+}
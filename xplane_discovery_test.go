@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func beaconPacket(hostID, role uint32, port uint16, name string) []byte {
+	b := make([]byte, 21, 21+len(name)+1)
+	copy(b, "BECN\x00")
+	b[5], b[6] = 1, 2
+	binary.LittleEndian.PutUint32(b[7:11], hostID)
+	binary.LittleEndian.PutUint32(b[11:15], 120100)
+	binary.LittleEndian.PutUint32(b[15:19], role)
+	binary.LittleEndian.PutUint16(b[19:21], port)
+	b = append(b, name...)
+	return append(b, 0, 0xc3, 0xbf) // NUL, then the RakNet port of minor version 2
+}
+
+// startFakeBeacon sends X-Plane beacons to target until the test ends.
+func startFakeBeacon(t *testing.T, target *net.UDPAddr, role uint32, port uint16, name string) {
+	t.Helper()
+	conn, err := net.DialUDP("udp4", nil, target)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done); conn.Close() })
+
+	go func() {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			conn.Write(beaconPacket(1, role, port, name))
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// startTestDiscovery listens for beacons on a loopback unicast port.
+func startTestDiscovery(t *testing.T) (*xplaneDiscovery, *net.UDPAddr) {
+	t.Helper()
+	d := newXPlaneDiscovery("127.0.0.1:0")
+	require.NoError(t, d.start())
+	t.Cleanup(d.close)
+	return d, d.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestParseBeacon(t *testing.T) {
+	from := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 49707}
+
+	inst, err := parseBeacon(beaconPacket(1, 1, 49000, "SIM-PC"), from)
+	require.NoError(t, err)
+	assert.Equal(t, XPlaneInstance{Host: "192.168.1.20", Port: 49000, ComputerName: "SIM-PC", Version: 120100, Role: "master"}, inst)
+
+	inst, err = parseBeacon(beaconPacket(1, 2, 49001, "VISUAL-L"), from)
+	require.NoError(t, err)
+	assert.Equal(t, "external_visual", inst.Role)
+
+	_, err = parseBeacon(beaconPacket(2, 1, 49000, "PM"), from)
+	assert.Error(t, err, "Plane Maker beacons are ignored")
+
+	_, err = parseBeacon([]byte("BECN\x00\x01"), from)
+	assert.Error(t, err)
+
+	_, err = parseBeacon([]byte("RREF\x00 some other packet......"), from)
+	assert.Error(t, err)
+}
+
+func TestXPlaneDiscoveryListsInstances(t *testing.T) {
+	d, addr := startTestDiscovery(t)
+	startFakeBeacon(t, addr, 2, 49001, "VISUAL-L")
+	startFakeBeacon(t, addr, 1, 49000, "SIM-PC")
+
+	list := d.wait(3*time.Second, func(list []XPlaneInstance) bool { return len(list) == 2 })
+	require.Len(t, list, 2)
+	assert.Equal(t, "SIM-PC", list[0].ComputerName, "master is listed first")
+	assert.Equal(t, "127.0.0.1", list[0].Host)
+	assert.Equal(t, 49000, list[0].Port)
+	assert.Equal(t, "VISUAL-L", list[1].ComputerName)
+
+	d.mu.Lock()
+	d.now = func() time.Time { return time.Now().Add(time.Minute) }
+	d.mu.Unlock()
+	assert.Empty(t, d.list(), "instances that stopped announcing expire")
+}
+
+func TestPickXPlaneInstance(t *testing.T) {
+	list := []XPlaneInstance{
+		{Host: "10.0.0.2", ComputerName: "SIM-PC", Role: "master"},
+		{Host: "10.0.0.3", ComputerName: "VISUAL-L", Role: "external_visual"},
+		{Host: "10.0.0.4", ComputerName: "HANGAR", Role: "master"},
+	}
+
+	inst, ok := pickXPlaneInstance(list, "")
+	require.True(t, ok)
+	assert.Equal(t, "SIM-PC", inst.ComputerName)
+
+	inst, ok = pickXPlaneInstance(list, "HANGAR")
+	require.True(t, ok)
+	assert.Equal(t, "HANGAR", inst.ComputerName, "the pilot's last choice wins")
+
+	inst, ok = pickXPlaneInstance(list, "GONE")
+	require.True(t, ok)
+	assert.Equal(t, "SIM-PC", inst.ComputerName)
+
+	_, ok = pickXPlaneInstance(list[1:2], "")
+	assert.False(t, ok, "external visuals are never picked automatically")
+}
+
+func TestAutoXPlaneEndpoint(t *testing.T) {
+	d, addr := startTestDiscovery(t)
+	startFakeBeacon(t, addr, 1, 49010, "SIM-PC")
+	fds := &FlightDataService{discovery: d}
+
+	e := fds.autoXPlaneEndpoint(Settings{XPlaneLocalPort: 49008})
+	assert.Equal(t, xplaneEndpoint{host: "127.0.0.1", port: 49010, localPort: 49008}, e)
+
+	e = fds.autoXPlaneEndpoint(Settings{XPlaneHost: "192.168.1.50", XPlanePort: 49000})
+	assert.Equal(t, xplaneEndpoint{host: "192.168.1.50", port: 49000}, e, "a typed host is used as is")
+}
+
+func TestConnectXPlaneInstance(t *testing.T) {
+	sim := newFakeXPlane(t, true)
+	d, addr := startTestDiscovery(t)
+	startFakeBeacon(t, addr, 1, uint16(sim.endpoint().port), "SIM-PC")
+	d.wait(3*time.Second, func(list []XPlaneInstance) bool { return len(list) == 1 })
+
+	settings := &SettingsService{filePath: filepath.Join(t.TempDir(), "settings.json")}
+	fds := &FlightDataService{settings: settings, discovery: d}
+	defer fds.DisconnectSim()
+
+	instances := fds.GetXPlaneInstances()
+	require.Len(t, instances, 1)
+
+	label, err := fds.ConnectXPlaneInstance(instances[0].Host, instances[0].Port)
+	require.NoError(t, err)
+	assert.Equal(t, "X-Plane ("+sim.endpoint().String()+")", label)
+	assert.Equal(t, "SIM-PC", settings.GetSettings().XPlaneComputer, "the choice is remembered for auto")
+}
+
+func TestConnectAutoDiscoversXPlaneLast(t *testing.T) {
+	t.Run("web API answering", func(t *testing.T) {
+		web := newFakeXPlaneWeb(t)
+		settings := &SettingsService{filePath: filepath.Join(t.TempDir(), "settings.json")}
+		s := settings.GetSettings()
+		s.XPlaneWebPort = web.endpoint().port
+		require.NoError(t, settings.UpdateSettings(s))
+		fds := &FlightDataService{settings: settings}
+
+		// The fake sends no datarefs, so no data arrives.
+		_, err := fds.ConnectSim("auto")
+		assert.ErrorContains(t, err, "X-Plane Web")
+		assert.Nil(t, fds.discovery, "no beacons are waited for")
+	})
+
+	t.Run("nothing else found", func(t *testing.T) {
+		closed, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closed.Close()
+		sim := newFakeXPlane(t, true)
+		d, addr := startTestDiscovery(t)
+		startFakeBeacon(t, addr, 1, uint16(sim.endpoint().port), "SIM-PC")
+
+		settings := &SettingsService{filePath: filepath.Join(t.TempDir(), "settings.json")}
+		s := settings.GetSettings()
+		s.XPlaneWebPort = closed.Addr().(*net.TCPAddr).Port
+		require.NoError(t, settings.UpdateSettings(s))
+		fds := &FlightDataService{settings: settings, discovery: d}
+		defer fds.DisconnectSim()
+
+		label, err := fds.ConnectSim("auto")
+		require.NoError(t, err)
+		assert.Equal(t, "X-Plane ("+sim.endpoint().String()+")", label)
+	})
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+const defaultXPlaneWebPort = 8086 // X-Plane 12.1+ local web API
+
+// xplaneWebEndpointFromSettings returns the web API address of the X-Plane
+// host in the settings.
+func xplaneWebEndpointFromSettings(s Settings) xplaneEndpoint {
+	e := xplaneEndpointFromSettings(s)
+	e.port, e.localPort = s.XPlaneWebPort, 0
+	if e.port <= 0 {
+		e.port = defaultXPlaneWebPort
+	}
+	return e
+}
+
+// xplaneWebStrings are the byte-array datarefs holding text, which only the
+// web API can deliver.
+var xplaneWebStrings = []struct {
+	dataref string
+	field   string
+	set     func(d *FlightData, s string)
+}{
+	{"sim/aircraft/view/acf_ui_name", "aircraftName", func(d *FlightData, s string) { d.AircraftName = s }},
+	{"sim/aircraft/view/acf_ICAO", "aircraftIcao", func(d *FlightData, s string) { d.AircraftICAO = s }},
+	{"sim/aircraft/view/acf_tailnum", "tailNumber", func(d *FlightData, s string) { d.TailNumber = s }},
+}
+
+// xplaneWebSubscription is one dataref subscribed over the WebSocket. Array
+// datarefs list the subscribed elements; updates carry their values in the
+// same order.
+type xplaneWebSubscription struct {
+	ID    int64 `json:"id"`
+	Index []int `json:"index,omitempty"`
+
+	sets    []func(v float64) // parallel to Index, or a single one for scalars
+	setText func(s string)    // byte-array datarefs holding text
+}
+
+// XPlaneWebAdapter reads X-Plane 12 through its web API: dataref ids are
+// looked up over REST, then their values are streamed over a WebSocket.
+type XPlaneWebAdapter struct {
+	endpoint     xplaneEndpoint // port is the web API port
+	datarefsFile string
+	client       *http.Client
+
+	mu           sync.Mutex
+	conn         *websocket.Conn
+	cancel       context.CancelFunc
+	data         FlightData
+	lastReceived time.Time
+	derived      xplaneDerived
+	readErr      error
+	subs         map[int64]*xplaneWebSubscription
+	fields       fieldTracker
+	supported    []string // fields of the datarefs X-Plane knows, set by resolve
+	sink         func(*FlightData)
+}
+
+func NewXPlaneWebAdapter(endpoint xplaneEndpoint, datarefsFile string) SimConnector {
+	return &XPlaneWebAdapter{
+		endpoint:     endpoint,
+		datarefsFile: datarefsFile,
+		client:       &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+func (x *XPlaneWebAdapter) Name() string {
+	return "X-Plane Web"
+}
+
+// Endpoint returns the web API address for display.
+func (x *XPlaneWebAdapter) Endpoint() string {
+	return x.endpoint.String()
+}
+
+func (x *XPlaneWebAdapter) Connect() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	version, err := x.apiVersion()
+	if err != nil {
+		return err
+	}
+
+	datarefs, err := loadXPlaneDatarefs(x.datarefsFile)
+	if err != nil {
+		return fmt.Errorf("load X-Plane datarefs: %w", err)
+	}
+	x.data = FlightData{}
+	x.derived = xplaneDerived{}
+	if err := x.resolve(version, datarefs); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dialCtx, dialCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer dialCancel()
+	conn, _, err := websocket.Dial(dialCtx, x.baseURL("ws")+"/api/"+version, nil)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("open X-Plane web API socket: %w", err)
+	}
+	conn.SetReadLimit(1 << 20)
+
+	subs := make([]*xplaneWebSubscription, 0, len(x.subs))
+	for _, s := range x.subs {
+		subs = append(subs, s)
+	}
+	req, _ := json.Marshal(map[string]any{
+		"req_id": 1,
+		"type":   "dataref_subscribe_values",
+		"params": map[string]any{"datarefs": subs},
+	})
+	if err := conn.Write(dialCtx, websocket.MessageText, req); err != nil {
+		conn.CloseNow()
+		cancel()
+		return fmt.Errorf("subscribe X-Plane datarefs: %w", err)
+	}
+
+	x.conn = conn
+	x.cancel = cancel
+	x.lastReceived = time.Time{}
+	x.readErr = nil
+	go x.readLoop(ctx, conn)
+
+	slog.Info("X-Plane web API connected", "addr", x.endpoint.String(), "api", version, "datarefs", len(subs))
+	return nil
+}
+
+func (x *XPlaneWebAdapter) Disconnect() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.cancel != nil {
+		x.cancel()
+		x.cancel = nil
+	}
+	if x.conn != nil {
+		x.conn.Close(websocket.StatusNormalClosure, "")
+		x.conn = nil
+	}
+	return nil
+}
+
+func (x *XPlaneWebAdapter) GetFlightData() (*FlightData, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	switch {
+	case x.conn == nil:
+		return nil, fmt.Errorf("not connected")
+	case x.readErr != nil:
+		return nil, fmt.Errorf("X-Plane web API connection lost: %w", x.readErr)
+	case x.lastReceived.IsZero():
+		return nil, fmt.Errorf("no data from X-Plane web API at %s yet", x.endpoint)
+	case time.Since(x.lastReceived) > 3*time.Second:
+		return nil, fmt.Errorf("no data from simulator")
+	}
+
+	data := x.data
+	return &data, nil
+}
+
+// SetSampleSink implements SamplePusher; sink gets the data after every
+// update message.
+func (x *XPlaneWebAdapter) SetSampleSink(sink func(*FlightData)) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.sink = sink
+}
+
+// Capabilities lists the fields of the datarefs this X-Plane has, or of the
+// default dataref table before Connect.
+func (x *XPlaneWebAdapter) Capabilities() ConnectorCapabilities {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.supported == nil {
+		fields := xplaneFields(xplaneDatarefs)
+		for _, s := range xplaneWebStrings {
+			fields = append(fields, s.field)
+		}
+		return ConnectorCapabilities{Fields: withImpliedFields(fields)}
+	}
+	return ConnectorCapabilities{Fields: x.supported}
+}
+
+func (x *XPlaneWebAdapter) LastReceived() time.Time {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.lastReceived
+}
+
+func (x *XPlaneWebAdapter) baseURL(scheme string) string {
+	return scheme + "://" + x.endpoint.String()
+}
+
+// apiVersion returns the newest dataref API version X-Plane offers.
+func (x *XPlaneWebAdapter) apiVersion() (string, error) {
+	resp, err := x.client.Get(x.baseURL("http") + "/api/capabilities")
+	if err != nil {
+		return "", fmt.Errorf("X-Plane web API not reachable at %s: %w", x.endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("X-Plane web API at %s: capabilities returned %s", x.endpoint, resp.Status)
+	}
+
+	var caps struct {
+		API struct {
+			Versions []string `json:"versions"`
+		} `json:"api"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&caps); err != nil {
+		return "", fmt.Errorf("decode X-Plane web API capabilities: %w", err)
+	}
+	for _, v := range []string{"v2", "v1"} {
+		if slices.Contains(caps.API.Versions, v) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("X-Plane web API at %s offers no supported version (%v)", x.endpoint, caps.API.Versions)
+}
+
+// resolve looks up the ids of the table's datarefs and of the text datarefs,
+// and builds one subscription per id. Datarefs the loaded aircraft does not
+// publish are skipped.
+func (x *XPlaneWebAdapter) resolve(version string, datarefs []xplaneDataref) error {
+	names := make([]string, 0, len(datarefs)+len(xplaneWebStrings))
+	for _, ref := range datarefs {
+		name, _ := splitDatarefIndex(ref.Dataref)
+		names = append(names, name)
+	}
+	for _, s := range xplaneWebStrings {
+		names = append(names, s.dataref)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	query := url.Values{"filter[name]": names}
+	resp, err := x.client.Get(x.baseURL("http") + "/api/" + version + "/datarefs?" + query.Encode())
+	if err != nil {
+		return fmt.Errorf("look up X-Plane datarefs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("look up X-Plane datarefs: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	var found struct {
+		Data []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return fmt.Errorf("decode X-Plane datarefs: %w", err)
+	}
+	ids := make(map[string]int64, len(found.Data))
+	for _, d := range found.Data {
+		ids[d.Name] = d.ID
+	}
+
+	x.subs = make(map[int64]*xplaneWebSubscription)
+	x.fields = fieldTracker{}
+	var supported []string
+	subscription := func(name string) *xplaneWebSubscription {
+		id, ok := ids[name]
+		if !ok {
+			slog.Warn("X-Plane dataref not found", "dataref", name)
+			return nil
+		}
+		s := x.subs[id]
+		if s == nil {
+			s = &xplaneWebSubscription{ID: id}
+			x.subs[id] = s
+		}
+		return s
+	}
+
+	for _, ref := range datarefs {
+		name, index := splitDatarefIndex(ref.Dataref)
+		s := subscription(name)
+		if s == nil {
+			continue
+		}
+		if index >= 0 {
+			s.Index = append(s.Index, index)
+		}
+		s.sets = append(s.sets, xplaneBinding(ref, &x.data, &x.derived, &x.fields))
+		supported = append(supported, ref.Field)
+	}
+	for _, str := range xplaneWebStrings {
+		if s := subscription(str.dataref); s != nil {
+			set, field := str.set, str.field
+			s.setText = func(v string) {
+				set(&x.data, v)
+				x.fields.mark(field)
+			}
+			supported = append(supported, field)
+		}
+	}
+	x.supported = withImpliedFields(supported)
+	return nil
+}
+
+func (x *XPlaneWebAdapter) readLoop(ctx context.Context, conn *websocket.Conn) {
+	for {
+		_, msg, err := conn.Read(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				x.mu.Lock()
+				x.readErr = err
+				x.mu.Unlock()
+				slog.Warn("X-Plane web API connection lost", "error", err)
+			}
+			return
+		}
+
+		var update struct {
+			Type         string                     `json:"type"`
+			Success      *bool                      `json:"success"`
+			ErrorMessage string                     `json:"error_message"`
+			Data         map[string]json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(msg, &update); err != nil {
+			continue
+		}
+		switch update.Type {
+		case "result":
+			if update.Success != nil && !*update.Success {
+				slog.Warn("X-Plane web API request failed", "error", update.ErrorMessage)
+			}
+		case "dataref_update_values":
+			x.apply(update.Data)
+		}
+	}
+}
+
+// apply stores one dataref_update_values message. Updates only carry the
+// datarefs that changed.
+func (x *XPlaneWebAdapter) apply(values map[string]json.RawMessage) {
+	x.mu.Lock()
+
+	for key, raw := range values {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		s := x.subs[id]
+		if s == nil {
+			continue
+		}
+		if err := s.apply(raw); err != nil {
+			slog.Debug("bad X-Plane dataref value", "id", id, "error", err)
+		}
+	}
+	x.lastReceived = time.Now()
+	x.derived.apply(&x.data, x.lastReceived)
+	x.data.Invalid = x.fields.invalidFields()
+	sample, sink := x.data, x.sink
+	x.mu.Unlock()
+
+	if sink != nil {
+		sink(&sample)
+	}
+}
+
+func (s *xplaneWebSubscription) apply(raw json.RawMessage) error {
+	if s.setText != nil {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return err
+		}
+		b, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return err
+		}
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		s.setText(string(b))
+		return nil
+	}
+
+	if len(s.Index) > 0 {
+		var values []float64
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return err
+		}
+		if len(values) != len(s.sets) {
+			return errors.New("array length does not match the subscription")
+		}
+		for i, v := range values {
+			s.sets[i](v)
+		}
+		return nil
+	}
+
+	var v float64
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	for _, set := range s.sets {
+		set(v)
+	}
+	return nil
+}
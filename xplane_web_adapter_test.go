@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeXPlaneWeb serves the parts of the X-Plane 12 web API the adapter uses.
+// Every requested dataref gets an id, except the ones listed as missing.
+type fakeXPlaneWeb struct {
+	server  *httptest.Server
+	ids     map[string]int64
+	missing map[string]bool
+	updates chan map[string]any // sent to the subscribed client
+}
+
+func newFakeXPlaneWeb(t *testing.T) *fakeXPlaneWeb {
+	t.Helper()
+	x := &fakeXPlaneWeb{
+		ids:     make(map[string]int64),
+		missing: make(map[string]bool),
+		updates: make(chan map[string]any, 8),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"api":{"versions":["v1","v2"]},"x-plane":{"version":"12.1.4"}}`))
+	})
+	mux.HandleFunc("/api/v2/datarefs", func(w http.ResponseWriter, r *http.Request) {
+		var data []map[string]any
+		for _, name := range r.URL.Query()["filter[name]"] {
+			if x.missing[name] {
+				continue
+			}
+			id := int64(len(x.ids) + 1000)
+			x.ids[name] = id
+			data = append(data, map[string]any{"id": id, "name": name, "value_type": "float"})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	})
+	mux.HandleFunc("/api/v2", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		ctx := r.Context()
+		if _, _, err := conn.Read(ctx); err != nil { // the subscription
+			return
+		}
+		conn.Write(ctx, websocket.MessageText, []byte(`{"type":"result","req_id":1,"success":true}`))
+		for update := range x.updates {
+			if update == nil {
+				conn.Close(websocket.StatusGoingAway, "X-Plane quit")
+				return
+			}
+			b, _ := json.Marshal(map[string]any{"type": "dataref_update_values", "data": update})
+			if err := conn.Write(ctx, websocket.MessageText, b); err != nil {
+				return
+			}
+		}
+	})
+
+	x.server = httptest.NewServer(mux)
+	t.Cleanup(func() {
+		close(x.updates)
+		x.server.Close()
+	})
+	return x
+}
+
+func (x *fakeXPlaneWeb) endpoint() xplaneEndpoint {
+	addr := x.server.Listener.Addr().(*net.TCPAddr)
+	return xplaneEndpoint{host: "127.0.0.1", port: addr.Port}
+}
+
+func (x *fakeXPlaneWeb) id(name string) string {
+	return strconv.FormatInt(x.ids[name], 10)
+}
+
+func TestXPlaneWebAdapterStreamsDatarefs(t *testing.T) {
+	sim := newFakeXPlaneWeb(t)
+	sim.missing["sim/aircraft/view/acf_tailnum"] = true
+
+	x := NewXPlaneWebAdapter(sim.endpoint(), "")
+	require.NoError(t, x.Connect())
+	defer x.Disconnect()
+
+	sim.updates <- map[string]any{
+		sim.id("sim/flightmodel/position/latitude"): 38.78,
+		sim.id("sim/flightmodel/engine/ENGN_N1_"):   []float64{62.5, 63, 0, 0},
+		sim.id("sim/cockpit2/controls/flap_ratio"):  0.25,
+		sim.id("sim/aircraft/view/acf_ICAO"):        base64.StdEncoding.EncodeToString([]byte("B738\x00\x00\x00")),
+		sim.id("sim/aircraft/view/acf_ui_name"):     base64.StdEncoding.EncodeToString([]byte("Boeing 737-800\x00")),
+	}
+
+	var fd *FlightData
+	require.Eventually(t, func() bool {
+		var err error
+		fd, err = x.GetFlightData()
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 38.78, fd.Position.Latitude)
+	assert.Equal(t, 62.5, fd.Engines[0].N1)
+	assert.Equal(t, 63.0, fd.Engines[1].N1)
+	assert.Equal(t, 25.0, fd.Controls.Flaps, "conversions from the dataref table apply")
+	assert.Equal(t, "B738", fd.AircraftICAO)
+	assert.Equal(t, "Boeing 737-800", fd.AircraftName)
+	assert.Empty(t, fd.TailNumber, "datarefs the aircraft lacks are skipped")
+	assert.False(t, fd.Valid("tailNumber"))
+	assert.False(t, x.Capabilities().Supports("tailNumber"))
+	assert.True(t, x.Capabilities().Supports("aircraftIcao"))
+	assert.True(t, fd.Valid("position.latitude"))
+	assert.False(t, fd.Valid("attitude.ias"), "no value received yet")
+	assert.Equal(t, "X-Plane Web ("+sim.endpoint().String()+")", connectorLabel(x))
+}
+
+func TestXPlaneWebAdapterConnectionLost(t *testing.T) {
+	sim := newFakeXPlaneWeb(t)
+	x := NewXPlaneWebAdapter(sim.endpoint(), "")
+	require.NoError(t, x.Connect())
+	defer x.Disconnect()
+
+	sim.updates <- map[string]any{sim.id("sim/flightmodel/position/latitude"): 38.78}
+	sim.updates <- nil
+	require.Eventually(t, func() bool {
+		_, err := x.GetFlightData()
+		return err != nil && strings.Contains(err.Error(), "connection lost")
+	}, 3*time.Second, 20*time.Millisecond)
+}
+
+func TestXPlaneWebAdapterUnavailable(t *testing.T) {
+	err := NewXPlaneWebAdapter(xplaneEndpoint{host: "127.0.0.1", port: freeUDPPort(t)}, "").Connect()
+	assert.ErrorContains(t, err, "X-Plane web API not reachable")
+
+	notXPlane := httptest.NewServer(http.NotFoundHandler())
+	defer notXPlane.Close()
+	port := notXPlane.Listener.Addr().(*net.TCPAddr).Port
+	err = NewXPlaneWebAdapter(xplaneEndpoint{host: "127.0.0.1", port: port}, "").Connect()
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestXPlaneWebSubscriptionRequest(t *testing.T) {
+	sim := newFakeXPlaneWeb(t)
+	x := NewXPlaneWebAdapter(sim.endpoint(), "").(*XPlaneWebAdapter)
+	require.NoError(t, x.resolve("v2", xplaneDatarefs))
+
+	n1 := x.subs[sim.ids["sim/flightmodel/engine/ENGN_N1_"]]
+	require.NotNil(t, n1)
+	b, err := json.Marshal(n1)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":`+sim.id("sim/flightmodel/engine/ENGN_N1_")+`,"index":[0,1,2,3]}`, string(b))
+
+	lat := x.subs[sim.ids["sim/flightmodel/position/latitude"]]
+	b, err = json.Marshal(lat)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":`+sim.id("sim/flightmodel/position/latitude")+`}`, string(b))
+}
+
+func TestXPlaneWebEndpointFromSettings(t *testing.T) {
+	assert.Equal(t, xplaneEndpoint{host: "127.0.0.1", port: 8086}, xplaneWebEndpointFromSettings(Settings{XPlaneLocalPort: 49008}))
+	assert.Equal(t, xplaneEndpoint{host: "10.0.0.5", port: 8090},
+		xplaneWebEndpointFromSettings(Settings{XPlaneHost: "10.0.0.5", XPlaneWebPort: 8090}))
+}
+
+func TestReconnectSimKeepsXPlaneWebEndpoint(t *testing.T) {
+	sim := newFakeXPlaneWeb(t)
+	fds := &FlightDataService{adapterName: "X-Plane Web", xplane: sim.endpoint()}
+
+	require.NoError(t, fds.reconnectSim())
+	defer fds.connector.Disconnect()
+	assert.IsType(t, &XPlaneWebAdapter{}, fds.connector)
+	assert.Equal(t, sim.endpoint().String(), fds.connector.(RemoteEndpoint).Endpoint())
+}